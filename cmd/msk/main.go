@@ -1,28 +1,37 @@
 package main
 
 import (
+	"context"
 	"os"
+	"os/signal"
 
 	"github.com/amauribechtoldjr/msk/internal/cli"
-	"github.com/amauribechtoldjr/msk/internal/clip"
-	"github.com/amauribechtoldjr/msk/internal/logger"
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
 	"github.com/awnumar/memguard"
 )
 
 func main() {
-	memguard.CatchInterrupt()
+	// The first Ctrl-C cancels the command's context, so the clipboard
+	// countdown clears early and bulk operations stop cleanly.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
 
-	defer memguard.Purge()
+	// A second one exits right away, e.g. from a password prompt that does
+	// not watch the context. Like memguard.CatchInterrupt, but also clears a
+	// password left on the clipboard.
+	go func() {
+		<-ctx.Done()
+		memguard.CatchSignal(func(os.Signal) { clip.ClearOnInterrupt() }, os.Interrupt)
+		stop()
+	}()
 
-	if err := clip.Init(); err != nil {
-		logger.PrintError("clipboard initialization failed")
-		os.Exit(1)
-	}
+	defer memguard.Purge()
 
 	rootCmd := cli.NewMSKCmd()
-	if err := rootCmd.Execute(); err != nil {
+	if err := rootCmd.ExecuteContext(ctx); err != nil {
+		cli.RenderError(rootCmd, err)
 		memguard.Purge()
-		os.Exit(1)
+		os.Exit(cli.ExitCode(err))
 	}
 
 	os.Exit(0)
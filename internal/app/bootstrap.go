@@ -4,15 +4,26 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/logger"
 	"github.com/amauribechtoldjr/msk/internal/session"
 	"github.com/amauribechtoldjr/msk/internal/storage"
 	"github.com/amauribechtoldjr/msk/internal/vault"
+	"golang.org/x/term"
 )
 
-func BootstrapWithAuth(vault vault.Vault) (Service, error) {
-	cfg, err := config.NewConfig()
+// BootstrapWithAuth unlocks the vault and opens the store configured in the
+// config file at configPath, or the default config when it is empty.
+func BootstrapWithAuth(vault vault.Vault, configPath string) (Service, error) {
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+
+	settings, err := cfg.LoadSettings()
 	if err != nil {
 		return nil, err
 	}
@@ -23,9 +34,11 @@ func BootstrapWithAuth(vault vault.Vault) (Service, error) {
 	}
 
 	if !exists {
-		return nil, errors.New("invalid config file")
+		return nil, config.ErrConfigNotFound
 	}
 
+	var vaultPath string
+
 	if token := os.Getenv("MSK_SESSION"); token != "" {
 		session, err := session.New()
 		if err != nil {
@@ -39,29 +52,115 @@ func BootstrapWithAuth(vault vault.Vault) (Service, error) {
 
 		err = vault.LoadSession(binarySession)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load session: %v", err)
+			return nil, fmt.Errorf("failed to load session: %w", err)
 		}
 
+		// The verifier, the config and the vault key share a salt, so unlocking
+		// derives a single key.
+		vault.EnableKeyCache()
+
+		vaultPath, err = cfg.Load(vault)
+		if err != nil {
+			vault.DestroyMK()
+			return nil, err
+		}
 	} else {
-		err := vault.LoadMK()
+		vaultPath, err = unlockWithRetries(cfg, vault, settings.PasswordRetries)
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	vaultPath, err := cfg.Load(vault)
+	// Only bulk commands keep derived keys past unlocking, see EnableKeyCache.
+	vault.DisableKeyCache()
+
+	store, err := storage.NewStoreWithModes(vaultPath, settings.FileMode, settings.DirMode)
 	if err != nil {
 		vault.DestroyMK()
 		return nil, err
 	}
+	store.CaseSensitive = settings.CaseSensitiveNames
+	store.BackupKeep = settings.BackupKeep
+	store.BackupMaxAge = settings.BackupMaxAge
+	store.MaxFileSize = settings.MaxFileSize
+
+	service := NewMSKService(store, vault)
 
-	store, err := storage.NewStore(vaultPath)
+	return service, nil
+}
+
+// BootstrapWithoutKey opens the store at vaultPath without unlocking the
+// vault, for commands that only look at the filesystem, such as checking
+// whether a secret exists. The vault path normally lives in the encrypted
+// config, so the caller has to supply it. Any operation that needs the master
+// key fails on the returned service.
+func BootstrapWithoutKey(vault vault.Vault, configPath, vaultPath string) (Service, error) {
+	cfg, err := config.NewConfig(configPath)
 	if err != nil {
-		vault.DestroyMK()
 		return nil, err
 	}
 
-	service := NewMSKService(store, vault)
+	settings, err := cfg.LoadSettings()
+	if err != nil {
+		return nil, err
+	}
 
-	return service, nil
+	if strings.TrimSpace(vaultPath) == "" {
+		return nil, fmt.Errorf("%w: the path is empty", storage.ErrInvalidVaultPath)
+	}
+
+	// --vault and MSK_VAULT may be relative to the working directory.
+	vaultPath, err = filepath.Abs(vaultPath)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", storage.ErrInvalidVaultPath, err)
+	}
+
+	info, err := os.Stat(vaultPath)
+	if err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("vault not found at %s", vaultPath)
+	}
+
+	store, err := storage.NewStoreWithModes(vaultPath, settings.FileMode, settings.DirMode)
+	if err != nil {
+		return nil, err
+	}
+	store.CaseSensitive = settings.CaseSensitiveNames
+	store.BackupKeep = settings.BackupKeep
+	store.BackupMaxAge = settings.BackupMaxAge
+	store.MaxFileSize = settings.MaxFileSize
+
+	return NewMSKService(store, vault), nil
+}
+
+// unlockWithRetries prompts for the master password and loads the config,
+// prompting again on a wrong password up to attempts times in total. When
+// stdin is not a terminal there is nobody to retype it, so only one attempt
+// is made. The prompt wipes every entered password once it is sealed.
+func unlockWithRetries(cfg *config.Config, vault vault.Vault, attempts int) (string, error) {
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if err := vault.LoadMK(); err != nil {
+			return "", err
+		}
+
+		// The verifier, the config and the vault key share a salt, so unlocking
+		// derives a single key.
+		vault.EnableKeyCache()
+
+		vaultPath, err := cfg.Load(vault)
+		if err == nil {
+			return vaultPath, nil
+		}
+
+		vault.DestroyMK()
+
+		if !errors.Is(err, config.ErrWrongMasterPassword) || attempt >= attempts {
+			return "", err
+		}
+
+		logger.PrintError("Wrong master password, %d attempt(s) left\n", attempts-attempt)
+	}
 }
@@ -0,0 +1,48 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/storage"
+	encryption "github.com/amauribechtoldjr/msk/internal/vault"
+)
+
+func TestBootstrapWithoutKey(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config.msk")
+
+	t.Run("should check existence without a master key", func(t *testing.T) {
+		vaultPath := t.TempDir()
+
+		store, err := storage.NewStore(vaultPath)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		if err := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key"))).AddSecret("github", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		service, err := BootstrapWithoutKey(encryption.NewVault(), configPath, vaultPath)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		exists, err := service.SecretExists("github")
+		if err != nil || !exists {
+			t.Fatalf("expected github to exist, got %v, %v", exists, err)
+		}
+
+		if _, err := service.GetSecret("github"); err == nil {
+			t.Fatal("expected decryption to fail without a master key")
+		}
+	})
+
+	t.Run("should not create a missing vault", func(t *testing.T) {
+		vaultPath := filepath.Join(t.TempDir(), "missing")
+
+		if _, err := BootstrapWithoutKey(encryption.NewVault(), configPath, vaultPath); err == nil {
+			t.Fatal("expected an error for a missing vault")
+		}
+	})
+}
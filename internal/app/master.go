@@ -0,0 +1,143 @@
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+)
+
+// ChangeMasterPassword moves the vault from the master password loaded in
+// from to the one loaded in to, and returns the vault path. Secrets in the
+// envelope format are sealed by the vault key, so only the vault key and the
+// config are re-wrapped. Secrets still in the older format, whose keys derive
+// from the master password, are first upgraded to the envelope format; it
+// returns how many were. They are all decrypted and staged before anything is
+// replaced, and once upgraded they depend only on the vault key. The vault
+// key, verifier and config are then replaced together, with the config last;
+// if that fails, the ones already replaced are put back and the old master
+// password still opens the vault. Backups in the older format are not
+// upgraded and can no longer be restored. Cancelling ctx while upgrading
+// leaves everything unchanged.
+func ChangeMasterPassword(ctx context.Context, configPath string, from, to vault.Vault) (string, int, error) {
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		return "", 0, err
+	}
+
+	vaultPath, err := cfg.Load(from)
+	if err != nil {
+		return "", 0, err
+	}
+
+	upgraded, err := UpgradeSecrets(ctx, configPath, vaultPath, from)
+	if err != nil {
+		return "", 0, err
+	}
+
+	key, err := from.VaultKey()
+	if err != nil {
+		return "", 0, err
+	}
+
+	if err := to.SetVaultKey(key); err != nil {
+		return "", 0, err
+	}
+
+	if err := cfg.Save(to, vaultPath); err != nil {
+		return "", upgraded, fmt.Errorf("failed to save config: %w", err)
+	}
+
+	return vaultPath, upgraded, nil
+}
+
+// UpgradeSecrets rewrites every secret in vaultPath still sealed with a
+// master-password key in the envelope format, so the vault key alone opens
+// the whole vault, as a recovery code needs. v must have loaded the config.
+// It returns how many secrets were upgraded; cancelling ctx leaves them all
+// unchanged.
+func UpgradeSecrets(ctx context.Context, configPath, vaultPath string, v vault.Vault) (int, error) {
+	cfg, err := config.NewConfig(configPath)
+	if err != nil {
+		return 0, err
+	}
+
+	settings, err := cfg.LoadSettings()
+	if err != nil {
+		return 0, err
+	}
+
+	store, err := storage.NewStoreWithModes(vaultPath, settings.FileMode, settings.DirMode)
+	if err != nil {
+		return 0, err
+	}
+	store.CaseSensitive = settings.CaseSensitiveNames
+
+	return upgradeSecrets(ctx, store, v)
+}
+
+// upgradeSecrets rewrites every secret still sealed with a master-password
+// key in the envelope format of v, which must have its vault key loaded.
+func upgradeSecrets(ctx context.Context, store *storage.Store, v vault.Vault) (int, error) {
+	names, err := store.GetFilesRecursive()
+	if err != nil {
+		return 0, err
+	}
+
+	tx := store.Begin()
+	upgraded := 0
+
+	for _, name := range names {
+		if err := ctx.Err(); err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("no secrets were changed: %w", err)
+		}
+
+		data, err := store.GetFile(name)
+		if err != nil {
+			tx.Rollback()
+			return 0, err
+		}
+
+		version, err := format.FileVersion(data)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		if version == meta.MSK_FILE_VERSION_ENVELOPE {
+			continue
+		}
+
+		plaintext, err := v.DecryptFile(data)
+		if err != nil {
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		// EncryptFile wipes the plaintext.
+		fileBytes, err := v.EncryptFile(plaintext)
+		if err == nil {
+			err = tx.SaveFile(fileBytes, name)
+		}
+
+		if err != nil {
+			wipe.Bytes(plaintext)
+			tx.Rollback()
+			return 0, fmt.Errorf("failed to upgrade %s: %w", name, err)
+		}
+
+		upgraded++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+
+	return upgraded, nil
+}
@@ -0,0 +1,186 @@
+package app
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+	encryption "github.com/amauribechtoldjr/msk/internal/vault"
+)
+
+func TestChangeMasterPassword(t *testing.T) {
+	setup := func(t *testing.T) (string, *storage.Store) {
+		t.Helper()
+
+		configPath := filepath.Join(t.TempDir(), "config.msk")
+		vaultPath := t.TempDir()
+
+		cfg, err := config.NewConfig(configPath)
+		if err != nil {
+			t.Fatalf("failed to create config: %v", err)
+		}
+
+		if err := cfg.Save(encryption.NewVaultWithMK([]byte("old-master")), vaultPath); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+
+		store, err := storage.NewStore(vaultPath)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("old-master")))
+		for _, name := range []string{"github", "work/aws"} {
+			if err := service.AddSecret(name, []byte("pass-"+name)); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+		}
+
+		return configPath, store
+	}
+
+	t.Run("should return the vault path", func(t *testing.T) {
+		configPath, store := setup(t)
+
+		vaultPath, _, err := ChangeMasterPassword(context.Background(), configPath, encryption.NewVaultWithMK([]byte("old-master")), encryption.NewVaultWithMK([]byte("new-master")))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if vaultPath != store.Path {
+			t.Fatalf("expected %s, got %s", store.Path, vaultPath)
+		}
+	})
+
+	t.Run("should upgrade older secrets and re-wrap the vault key", func(t *testing.T) {
+		configPath, store := setup(t)
+
+		_, count, err := ChangeMasterPassword(context.Background(), configPath, encryption.NewVaultWithMK([]byte("old-master")), encryption.NewVaultWithMK([]byte("new-master")))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if count != 2 {
+			t.Fatalf("expected 2 upgraded secrets, got %d", count)
+		}
+
+		next := encryption.NewVaultWithMK([]byte("new-master"))
+		cfg, _ := config.NewConfig(configPath)
+		if _, err := cfg.Load(next); err != nil {
+			t.Fatalf("expected the config to load with the new master password, got %v", err)
+		}
+
+		password, err := NewMSKService(store, next).GetSecret("work/aws")
+		if err != nil || string(password) != "pass-work/aws" {
+			t.Fatalf("expected the new master password to decrypt, got %q (%v)", password, err)
+		}
+	})
+
+	t.Run("should leave envelope secrets untouched", func(t *testing.T) {
+		configPath, store := setup(t)
+
+		if _, _, err := ChangeMasterPassword(context.Background(), configPath, encryption.NewVaultWithMK([]byte("old-master")), encryption.NewVaultWithMK([]byte("new-master"))); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		before, _ := store.GetFile("github")
+
+		_, count, err := ChangeMasterPassword(context.Background(), configPath, encryption.NewVaultWithMK([]byte("new-master")), encryption.NewVaultWithMK([]byte("newer-master")))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		after, _ := store.GetFile("github")
+		if count != 0 || !bytes.Equal(before, after) {
+			t.Fatalf("expected no secret to be rewritten, got %d upgraded", count)
+		}
+
+		next := encryption.NewVaultWithMK([]byte("newer-master"))
+		cfg, _ := config.NewConfig(configPath)
+		if _, err := cfg.Load(next); err != nil {
+			t.Fatalf("expected the config to load with the newer master password, got %v", err)
+		}
+
+		if _, err := NewMSKService(store, next).GetSecret("github"); err != nil {
+			t.Fatalf("expected the secret to stay readable, got %v", err)
+		}
+	})
+
+	t.Run("should change nothing when the current master password is wrong", func(t *testing.T) {
+		configPath, store := setup(t)
+
+		_, _, err := ChangeMasterPassword(context.Background(), configPath, encryption.NewVaultWithMK([]byte("wrong-master")), encryption.NewVaultWithMK([]byte("new-master")))
+		if !errors.Is(err, config.ErrWrongMasterPassword) {
+			t.Fatalf("expected ErrWrongMasterPassword, got %v", err)
+		}
+
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("old-master")))
+		if _, err := service.GetSecret("github"); err != nil {
+			t.Fatalf("expected secrets to keep the old master password, got %v", err)
+		}
+	})
+	t.Run("should change nothing when cancelled", func(t *testing.T) {
+		configPath, store := setup(t)
+		before, _ := store.GetFile("github")
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, err := ChangeMasterPassword(ctx, configPath, encryption.NewVaultWithMK([]byte("old-master")), encryption.NewVaultWithMK([]byte("new-master")))
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+
+		after, _ := store.GetFile("github")
+		if !bytes.Equal(before, after) {
+			t.Fatal("expected the secret to be left untouched")
+		}
+
+		cfg, _ := config.NewConfig(configPath)
+		if _, err := cfg.Load(encryption.NewVaultWithMK([]byte("old-master"))); err != nil {
+			t.Fatalf("expected the old master password to still work, got %v", err)
+		}
+	})
+}
+
+func TestUpgradeSecrets(t *testing.T) {
+	t.Run("should let the vault key alone open every secret", func(t *testing.T) {
+		configPath := filepath.Join(t.TempDir(), "config.msk")
+		vaultPath := t.TempDir()
+
+		cfg, _ := config.NewConfig(configPath)
+		if err := cfg.Save(encryption.NewVaultWithMK([]byte("master")), vaultPath); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+
+		store, _ := storage.NewStore(vaultPath)
+		if err := NewMSKService(store, encryption.NewVaultWithMK([]byte("master"))).AddSecret("github", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		v := encryption.NewVaultWithMK([]byte("master"))
+		if _, err := cfg.Load(v); err != nil {
+			t.Fatalf("load failed: %v", err)
+		}
+
+		count, err := UpgradeSecrets(context.Background(), configPath, vaultPath, v)
+		if err != nil || count != 1 {
+			t.Fatalf("expected 1 upgraded secret, got %d (%v)", count, err)
+		}
+
+		key, _ := v.VaultKey()
+		keyOnly := encryption.NewVault()
+		if err := keyOnly.SetVaultKey(key); err != nil {
+			t.Fatalf("failed to set vault key: %v", err)
+		}
+
+		password, err := NewMSKService(store, keyOnly).GetSecret("github")
+		if err != nil || string(password) != "pass" {
+			t.Fatalf("expected the vault key to decrypt, got %q (%v)", password, err)
+		}
+	})
+}
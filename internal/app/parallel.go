@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// MAX_PARALLEL caps how many secrets bulk operations process at once. A
+// secret in the older format needs an Argon2 derivation using 128 MiB, so
+// this bounds memory as well as CPU.
+const MAX_PARALLEL = 8
+
+// DefaultParallel is the number of CPUs, capped at MAX_PARALLEL.
+func DefaultParallel() int {
+	return min(runtime.NumCPU(), MAX_PARALLEL)
+}
+
+// SetParallel sets how many secrets bulk operations such as RekeySecrets,
+// GetSecretsMetadata and GetAllSecrets process at once, clamped to
+// 1..MAX_PARALLEL. Results keep the order of the names either way.
+func (s *MSKService) SetParallel(n int) {
+	s.parallel = min(max(n, 1), MAX_PARALLEL)
+}
+
+// eachSecret calls fn for every name, on up to s.parallel goroutines, and
+// reports progress as calls finish. fn receives the index of the name so it
+// can store results in order. After the first error no more names are handed
+// out and the error of the lowest failed index is returned; on cancellation
+// a PartialError is returned. It also returns how many calls succeeded.
+func (s *MSKService) eachSecret(ctx context.Context, names []string, fn func(i int, name string) error) (int, error) {
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		next     int
+		done     int
+		failedAt int
+		failErr  error
+	)
+
+	work := func() {
+		defer wg.Done()
+
+		for {
+			mu.Lock()
+			if failErr != nil || next >= len(names) || ctx.Err() != nil {
+				mu.Unlock()
+				return
+			}
+			i := next
+			next++
+			mu.Unlock()
+
+			err := fn(i, names[i])
+
+			mu.Lock()
+			if err != nil {
+				if failErr == nil || i < failedAt {
+					failedAt, failErr = i, err
+				}
+			} else {
+				done++
+				s.reportProgress(done, len(names))
+			}
+			mu.Unlock()
+		}
+	}
+
+	for range min(max(s.parallel, 1), len(names)) {
+		wg.Add(1)
+		go work()
+	}
+	wg.Wait()
+
+	if failErr != nil {
+		return done, failErr
+	}
+
+	if err := ctx.Err(); err != nil && done < len(names) {
+		return done, &PartialError{Done: done, Total: len(names), Err: err}
+	}
+
+	return done, nil
+}
@@ -1,8 +1,13 @@
 package app
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
 	"strings"
+	"time"
 
 	"github.com/amauribechtoldjr/msk/internal/domain"
 	"github.com/amauribechtoldjr/msk/internal/format"
@@ -14,25 +19,130 @@ import (
 var (
 	ErrSecretExists   = errors.New("secret already exists")
 	ErrSecretNotFound = errors.New("secret not found")
+	// ErrInvalidLoginField is returned for a login field other than those
+	// domain.IsLoginField accepts.
+	ErrInvalidLoginField = errors.New("invalid login field")
 )
 
 type Service interface {
 	DeleteSecret(name string) error
 	AddSecret(name string, rawP []byte) error
+	AddSecretWithMeta(ctx context.Context, secret domain.Secret) error
+	AddSecretWithPolicy(ctx context.Context, secret domain.Secret, policy ConflictPolicy) (AddResult, error)
 	UpdateSecret(name string, rawP []byte) error
+	SetSecret(ctx context.Context, name string, rawP []byte) error
 	GetSecret(name string) ([]byte, error)
+	SecretExists(name string) (bool, error)
+	SecretPath(name string) (string, error)
+	GetSecretWithMeta(name string) (domain.Secret, error)
 	GetSecrets() ([]string, error)
+	GetSecretsRecursive() ([]string, error)
+	GetSecretsMetadata(ctx context.Context, names []string) ([]domain.Secret, error)
+	VaultPath() string
+	Purge() ([]string, error)
+	RekeySecrets(ctx context.Context) (int, error)
+	BackupSecret(name string) error
+	SecretBackups(name string) ([]storage.Backup, error)
+	RestoreSecret(name string, backup storage.Backup) error
+	CheckSecret(name string) error
+	GetAllSecrets(ctx context.Context) ([]domain.Secret, []SecretError, error)
+	AddTag(name, tag string) (bool, error)
+	RemoveTag(name, tag string) (bool, error)
+	SetLoginFields(name string, fields []string) (bool, error)
+	OnProgress(fn ProgressFunc)
+	SetParallel(n int)
+}
+
+// ConflictPolicy decides what AddSecretWithPolicy does when a secret with the
+// same name already exists.
+type ConflictPolicy uint8
+
+const (
+	OnConflictError ConflictPolicy = iota
+	OnConflictSkip
+	OnConflictOverwrite
+)
+
+// AddResult reports what AddSecretWithPolicy did with the secret.
+type AddResult uint8
+
+const (
+	AddResultAdded AddResult = iota + 1
+	AddResultSkipped
+	AddResultOverwritten
+)
+
+func (r AddResult) String() string {
+	switch r {
+	case AddResultAdded:
+		return "added"
+	case AddResultSkipped:
+		return "skipped"
+	case AddResultOverwritten:
+		return "overwritten"
+	default:
+		return "unknown"
+	}
+}
+
+// ProgressFunc is called by bulk operations after each processed secret.
+type ProgressFunc func(done, total int)
+
+// SecretError records why a single secret could not be read during a bulk
+// operation.
+type SecretError struct {
+	Name string
+	Err  error
+}
+
+func (e SecretError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Name, e.Err)
+}
+
+func (e SecretError) Unwrap() error {
+	return e.Err
+}
+
+// PartialError reports a bulk operation that stopped early, e.g. because its
+// context was cancelled, after Done of Total secrets were processed.
+type PartialError struct {
+	Done  int
+	Total int
+	Err   error
+}
+
+func (e *PartialError) Error() string {
+	return fmt.Sprintf("stopped after %d of %d secrets: %v", e.Done, e.Total, e.Err)
+}
+
+func (e *PartialError) Unwrap() error {
+	return e.Err
 }
 
 type MSKService struct {
-	repo  storage.Repository
-	vault vault.Vault
+	repo     storage.Repository
+	vault    vault.Vault
+	progress ProgressFunc
+	parallel int
 }
 
 func NewMSKService(r storage.Repository, v vault.Vault) Service {
 	return &MSKService{
-		vault: v,
-		repo:  r,
+		vault:    v,
+		repo:     r,
+		parallel: 1,
+	}
+}
+
+// OnProgress registers fn to be told how far bulk operations such as
+// RekeySecrets, GetSecretsMetadata and GetAllSecrets have got.
+func (s *MSKService) OnProgress(fn ProgressFunc) {
+	s.progress = fn
+}
+
+func (s *MSKService) reportProgress(done, total int) {
+	if s.progress != nil {
+		s.progress(done, total)
 	}
 }
 
@@ -49,6 +159,38 @@ func (s *MSKService) DeleteSecret(name string) error {
 	return s.repo.DeleteFile(name)
 }
 
+// BackupSecret keeps an encrypted copy of a secret's current file, so a
+// following update or delete can be undone with RestoreSecret.
+func (s *MSKService) BackupSecret(name string) error {
+	_, err := s.repo.BackupFile(name)
+	if errors.Is(err, storage.ErrNotFound) {
+		return ErrSecretNotFound
+	}
+
+	return err
+}
+
+// SecretBackups lists the backups of a secret, newest first.
+func (s *MSKService) SecretBackups(name string) ([]storage.Backup, error) {
+	return s.repo.Backups(name)
+}
+
+// RestoreSecret puts a backup back in place of the secret, backing up the
+// current version first when there is one. Nothing is decrypted.
+func (s *MSKService) RestoreSecret(name string, backup storage.Backup) error {
+	return s.repo.RestoreBackup(name, backup)
+}
+
+func (s *MSKService) VaultPath() string {
+	return s.repo.Dir()
+}
+
+// Purge removes every secret in the vault and returns the paths that could
+// not be removed.
+func (s *MSKService) Purge() ([]string, error) {
+	return s.repo.Purge()
+}
+
 func (s *MSKService) AddSecret(name string, rawP []byte) error {
 	exists, err := s.repo.FileExists(name)
 	if err != nil {
@@ -59,25 +201,71 @@ func (s *MSKService) AddSecret(name string, rawP []byte) error {
 		return ErrSecretExists
 	}
 
+	now := time.Now().UTC()
 	secret := domain.Secret{
-		Name:     name,
-		Password: rawP,
+		Name:      name,
+		Password:  rawP,
+		CreatedAt: now,
+		UpdatedAt: now,
 	}
 	defer wipe.Bytes(secret.Password)
 
-	secretBytes := format.MarshalSecret(secret)
+	return s.writeSecret(secret)
+}
 
-	saltedGCM, err := s.vault.Encrypt(secretBytes)
-	if err != nil {
-		return err
+// AddSecretWithMeta adds a secret keeping its metadata, so imports can carry
+// over the original timestamps. A zero CreatedAt defaults to now and a zero
+// UpdatedAt to CreatedAt. Like AddSecret, the password is wiped once the
+// secret is written.
+func (s *MSKService) AddSecretWithMeta(ctx context.Context, secret domain.Secret) error {
+	_, err := s.AddSecretWithPolicy(ctx, secret, OnConflictError)
+	return err
+}
+
+// AddSecretWithPolicy adds a secret like AddSecretWithMeta, resolving a name
+// clash according to policy: OnConflictError returns ErrSecretExists and
+// leaves the password to the caller, OnConflictSkip keeps the stored secret,
+// and OnConflictOverwrite replaces its password like UpdateSecret, keeping the
+// stored metadata. Otherwise the password is wiped once it has been handled.
+func (s *MSKService) AddSecretWithPolicy(ctx context.Context, secret domain.Secret, policy ConflictPolicy) (AddResult, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
 	}
 
-	fileBytes, err := format.MarshalFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+	exists, err := s.repo.FileExists(secret.Name)
 	if err != nil {
-		return err
+		return 0, err
+	}
+
+	if exists {
+		switch policy {
+		case OnConflictSkip:
+			wipe.Bytes(secret.Password)
+			return AddResultSkipped, nil
+		case OnConflictOverwrite:
+			if err := s.UpdateSecret(secret.Name, secret.Password); err != nil {
+				return 0, err
+			}
+			return AddResultOverwritten, nil
+		default:
+			return 0, ErrSecretExists
+		}
+	}
+	defer wipe.Bytes(secret.Password)
+
+	if secret.CreatedAt.IsZero() {
+		secret.CreatedAt = time.Now().UTC()
+	}
+
+	if secret.UpdatedAt.IsZero() {
+		secret.UpdatedAt = secret.CreatedAt
+	}
+
+	if err := s.writeSecret(secret); err != nil {
+		return 0, err
 	}
 
-	return s.repo.SaveFile(fileBytes, name)
+	return AddResultAdded, nil
 }
 
 func (s *MSKService) UpdateSecret(name string, rawP []byte) error {
@@ -90,59 +278,209 @@ func (s *MSKService) UpdateSecret(name string, rawP []byte) error {
 		return ErrSecretNotFound
 	}
 
-	secret := domain.Secret{
-		Name:     name,
-		Password: rawP,
+	secret, err := s.readSecret(name)
+	if err != nil {
+		return err
 	}
+	wipe.Bytes(secret.Password)
+
+	secret.Password = rawP
+	secret.UpdatedAt = time.Now().UTC()
 	defer wipe.Bytes(secret.Password)
 
-	secretBytes := format.MarshalSecret(secret)
+	return s.writeSecret(secret)
+}
+
+// AddTag adds tag to a secret and re-encrypts it. It reports whether the
+// secret changed; a secret that already has the tag is left untouched.
+func (s *MSKService) AddTag(name, tag string) (bool, error) {
+	return s.editTags(name, func(tags []string) []string {
+		if slices.Contains(tags, tag) {
+			return tags
+		}
+
+		return append(tags, tag)
+	})
+}
+
+// RemoveTag removes tag from a secret like AddTag adds it.
+func (s *MSKService) RemoveTag(name, tag string) (bool, error) {
+	return s.editTags(name, func(tags []string) []string {
+		return slices.DeleteFunc(tags, func(t string) bool { return t == tag })
+	})
+}
+
+// editTags rewrites the tags of a secret with edit and stores it atomically
+// when they changed.
+func (s *MSKService) editTags(name string, edit func(tags []string) []string) (bool, error) {
+	return s.editSecret(name, func(secret *domain.Secret) bool {
+		tags := edit(slices.Clone(secret.Tags))
+		if slices.Equal(tags, secret.Tags) {
+			return false
+		}
+
+		secret.Tags = tags
+		return true
+	})
+}
+
+// SetLoginFields stores the order `msk login` copies the fields of a secret
+// in and re-encrypts it. No fields restores domain.DefaultLoginFields. It
+// reports whether the order changed.
+func (s *MSKService) SetLoginFields(name string, fields []string) (bool, error) {
+	for _, field := range fields {
+		if !domain.IsLoginField(field) {
+			return false, fmt.Errorf("%w: %q, expected username, password, url or notes", ErrInvalidLoginField, field)
+		}
+	}
+
+	return s.editSecret(name, func(secret *domain.Secret) bool {
+		if slices.Equal(fields, secret.LoginFields) {
+			return false
+		}
 
-	saltedGCM, err := s.vault.Encrypt(secretBytes)
+		secret.LoginFields = slices.Clone(fields)
+		return true
+	})
+}
+
+// editSecret applies edit to a secret and, when edit reports a change, bumps
+// UpdatedAt and stores it atomically.
+func (s *MSKService) editSecret(name string, edit func(secret *domain.Secret) bool) (bool, error) {
+	exists, err := s.repo.FileExists(name)
 	if err != nil {
-		return err
+		return false, err
+	}
+
+	if !exists {
+		return false, ErrSecretNotFound
 	}
 
-	fileBytes, err := format.MarshalFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+	secret, err := s.readSecret(name)
 	if err != nil {
-		return err
+		return false, err
+	}
+	defer wipe.Bytes(secret.Password)
+
+	if !edit(&secret) {
+		return false, nil
+	}
+
+	secret.UpdatedAt = time.Now().UTC()
+
+	if err := s.writeSecret(secret); err != nil {
+		return false, err
 	}
 
-	return s.repo.SaveFile(fileBytes, name)
+	return true, nil
+}
+
+// SetSecret updates the secret when it exists and adds it otherwise, so
+// scripts do not need to know which applies. Like AddSecret and UpdateSecret
+// it wipes rawP.
+func (s *MSKService) SetSecret(ctx context.Context, name string, rawP []byte) error {
+	_, err := s.AddSecretWithPolicy(ctx, domain.Secret{Name: name, Password: rawP}, OnConflictOverwrite)
+	if err != nil {
+		wipe.Bytes(rawP)
+	}
+
+	return err
 }
 
 func (s *MSKService) GetSecret(name string) ([]byte, error) {
-	exists, err := s.repo.FileExists(name)
+	secret, err := s.GetSecretWithMeta(name)
 	if err != nil {
 		return nil, err
 	}
 
+	return secret.Password, nil
+}
+
+// GetSecretWithMeta returns a secret together with its metadata, such as the
+// username. The caller owns the returned password and must wipe it.
+func (s *MSKService) GetSecretWithMeta(name string) (domain.Secret, error) {
+	exists, err := s.repo.FileExists(name)
+	if err != nil {
+		return domain.Secret{}, err
+	}
+
 	if !exists {
-		return nil, ErrSecretNotFound
+		return domain.Secret{}, ErrSecretNotFound
 	}
 
-	fileData, err := s.repo.GetFile(name)
+	return s.readSecret(name)
+}
+
+// SecretExists reports whether a secret is stored under name. It only looks
+// at the filesystem and decrypts nothing.
+func (s *MSKService) SecretExists(name string) (bool, error) {
+	return s.repo.FileExists(name)
+}
+
+// SecretPath returns the absolute path of the file holding a secret without
+// decrypting it.
+func (s *MSKService) SecretPath(name string) (string, error) {
+	exists, err := s.repo.FileExists(name)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+
+	if !exists {
+		return "", ErrSecretNotFound
 	}
 
-	salt, nonce, data, err := format.UnmarshalFile(fileData)
+	return filepath.Abs(s.repo.FilePath(name))
+}
+
+// CheckSecret reports whether a secret exists and decrypts cleanly, without
+// returning its contents.
+func (s *MSKService) CheckSecret(name string) error {
+	password, err := s.GetSecret(name)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	decryptedBytes, err := s.vault.Decrypt(salt, nonce, data)
+	wipe.Bytes(password)
+	return nil
+}
+
+// readSecret decrypts a stored secret. The decrypted payload is wiped once it
+// has been decoded; the caller owns the returned password and must wipe it.
+func (s *MSKService) readSecret(name string) (domain.Secret, error) {
+	fileData, err := s.repo.GetFile(name)
 	if err != nil {
-		return nil, err
+		return domain.Secret{}, err
+	}
+
+	decryptedBytes, err := s.vault.DecryptFile(fileData)
+	if err != nil {
+		return domain.Secret{}, err
 	}
 	defer wipe.Bytes(decryptedBytes)
 
-	secret, err := format.UnmarshalSecret(decryptedBytes)
+	return format.UnmarshalSecret(decryptedBytes)
+}
+
+// writeSecret encrypts and stores a secret under its name, replacing any
+// existing file.
+func (s *MSKService) writeSecret(secret domain.Secret) error {
+	fileBytes, err := s.sealSecret(secret)
+	if err != nil {
+		return err
+	}
+
+	return s.repo.SaveFile(fileBytes, secret.Name)
+}
+
+// sealSecret encrypts a secret into the bytes of its file.
+func (s *MSKService) sealSecret(secret domain.Secret) ([]byte, error) {
+	secretBytes, err := format.MarshalSecret(secret)
 	if err != nil {
 		return nil, err
 	}
+	defer wipe.Bytes(secretBytes)
 
-	return secret.Password, nil
+	return s.vault.EncryptFile(secretBytes)
 }
 
 func (s *MSKService) GetSecrets() ([]string, error) {
@@ -157,3 +495,103 @@ func (s *MSKService) GetSecrets() ([]string, error) {
 
 	return files, nil
 }
+
+// GetSecretsRecursive lists secrets including those stored in folders, using
+// slash-separated names such as "work/github".
+func (s *MSKService) GetSecretsRecursive() ([]string, error) {
+	return s.repo.GetFilesRecursive()
+}
+
+// GetSecretsMetadata decrypts the named secrets and returns them in the same
+// order with their passwords wiped, for commands that only need metadata such
+// as timestamps. Cancelling ctx stops it with a PartialError.
+func (s *MSKService) GetSecretsMetadata(ctx context.Context, names []string) ([]domain.Secret, error) {
+	secrets := make([]domain.Secret, len(names))
+
+	_, err := s.eachSecret(ctx, names, func(i int, name string) error {
+		secret, err := s.readSecret(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		wipe.Bytes(secret.Password)
+		secret.Password = nil
+
+		secrets[i] = secret
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return secrets, nil
+}
+
+// RekeySecrets re-encrypts every secret with a fresh salt and nonce under the
+// same master password, writing each file atomically and in the latest format.
+// It stops at the first failure, or with a PartialError once ctx is cancelled,
+// and returns how many secrets were rekeyed.
+func (s *MSKService) RekeySecrets(ctx context.Context) (int, error) {
+	names, err := s.repo.GetFilesRecursive()
+	if err != nil {
+		return 0, err
+	}
+
+	return s.eachSecret(ctx, names, func(_ int, name string) error {
+		secret, err := s.readSecret(name)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", name, err)
+		}
+
+		err = s.writeSecret(secret)
+		wipe.Bytes(secret.Password)
+
+		if err != nil {
+			return fmt.Errorf("failed to rekey %s: %w", name, err)
+		}
+
+		return nil
+	})
+}
+
+// GetAllSecrets decrypts every secret in the vault, including folders. Secrets
+// that fail to read are reported in the returned SecretError list instead of
+// aborting the run; the error result is only set when listing fails or, as a
+// PartialError, when ctx is cancelled. Ownership of the returned secrets passes
+// to the caller, who must wipe their passwords.
+func (s *MSKService) GetAllSecrets(ctx context.Context) ([]domain.Secret, []SecretError, error) {
+	names, err := s.repo.GetFilesRecursive()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	results := make([]domain.Secret, len(names))
+	errs := make([]error, len(names))
+
+	_, err = s.eachSecret(ctx, names, func(i int, name string) error {
+		results[i], errs[i] = s.readSecret(name)
+		return nil
+	})
+
+	if err != nil {
+		for _, secret := range results {
+			wipe.Bytes(secret.Password)
+		}
+		return nil, nil, err
+	}
+
+	secrets := make([]domain.Secret, 0, len(names))
+	var failures []SecretError
+
+	for i, name := range names {
+		if errs[i] != nil {
+			failures = append(failures, SecretError{Name: name, Err: errs[i]})
+			continue
+		}
+
+		secrets = append(secrets, results[i])
+	}
+
+	return secrets, failures, nil
+}
@@ -1,10 +1,19 @@
 package app
 
 import (
+	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/format"
 	"github.com/amauribechtoldjr/msk/internal/storage"
 	encryption "github.com/amauribechtoldjr/msk/internal/vault"
 )
@@ -22,6 +31,32 @@ func newTestService(t *testing.T, masterKey string) Service {
 	return NewMSKService(store, crypto)
 }
 
+func readStoredSecret(t *testing.T, store storage.Repository, crypto encryption.Vault, name string) domain.Secret {
+	t.Helper()
+
+	fileData, err := store.GetFile(name)
+	if err != nil {
+		t.Fatalf("failed to read file: %v", err)
+	}
+
+	salt, nonce, data, err := format.UnmarshalFile(fileData)
+	if err != nil {
+		t.Fatalf("failed to unmarshal file: %v", err)
+	}
+
+	payload, err := crypto.Decrypt(salt, nonce, data)
+	if err != nil {
+		t.Fatalf("failed to decrypt: %v", err)
+	}
+
+	secret, err := format.UnmarshalSecret(payload)
+	if err != nil {
+		t.Fatalf("failed to unmarshal secret: %v", err)
+	}
+
+	return secret
+}
+
 func TestNewMSKService(t *testing.T) {
 	t.Run("should return a properly initialized service", func(t *testing.T) {
 		service := newTestService(t, "master-key")
@@ -117,6 +152,27 @@ func TestGetSecret(t *testing.T) {
 			t.Fatal("expected error with wrong master key")
 		}
 	})
+
+	t.Run("should return ErrInvalidSecret when a directory sits in place of the secret", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		if err := os.Mkdir(filepath.Join(store.Dir(), "foo.msk"), 0o700); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key")))
+
+		if _, err := service.GetSecret("foo"); !errors.Is(err, storage.ErrInvalidSecret) {
+			t.Fatalf("expected ErrInvalidSecret, got %v", err)
+		}
+
+		if err := service.AddSecret("foo", []byte("pass")); !errors.Is(err, storage.ErrInvalidSecret) {
+			t.Fatalf("expected ErrInvalidSecret, got %v", err)
+		}
+	})
 }
 
 func TestDeleteSecret(t *testing.T) {
@@ -183,6 +239,38 @@ func TestUpdateSecret(t *testing.T) {
 		}
 	})
 
+	t.Run("should preserve creation time and bump update time", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		crypto := encryption.NewVaultWithMK([]byte("master-key"))
+		service := NewMSKService(store, crypto)
+
+		if err := service.AddSecret("timestamps", []byte("old-pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		added := readStoredSecret(t, store, crypto, "timestamps")
+		if added.CreatedAt.IsZero() || !added.CreatedAt.Equal(added.UpdatedAt) {
+			t.Fatalf("expected matching creation and update times, got %v and %v", added.CreatedAt, added.UpdatedAt)
+		}
+
+		if err := service.UpdateSecret("timestamps", []byte("new-pass")); err != nil {
+			t.Fatalf("update failed: %v", err)
+		}
+
+		updated := readStoredSecret(t, store, crypto, "timestamps")
+		if !updated.CreatedAt.Equal(added.CreatedAt) {
+			t.Fatalf("expected creation time %v to be preserved, got %v", added.CreatedAt, updated.CreatedAt)
+		}
+
+		if !updated.UpdatedAt.After(added.UpdatedAt) {
+			t.Fatalf("expected update time after %v, got %v", added.UpdatedAt, updated.UpdatedAt)
+		}
+	})
+
 	t.Run("should not return old password after update", func(t *testing.T) {
 		service := newTestService(t, "master-key")
 
@@ -207,6 +295,101 @@ func TestUpdateSecret(t *testing.T) {
 	})
 }
 
+func TestTags(t *testing.T) {
+	t.Run("should add and remove a tag and keep the password", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("github", []byte("hunter2")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		changed, err := service.AddTag("github", "work")
+		if err != nil || !changed {
+			t.Fatalf("expected the tag to be added, got changed=%v err=%v", changed, err)
+		}
+
+		changed, err = service.AddTag("github", "work")
+		if err != nil || changed {
+			t.Fatalf("expected adding the tag again to change nothing, got changed=%v err=%v", changed, err)
+		}
+
+		secret, err := service.GetSecretWithMeta("github")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(secret.Tags, []string{"work"}) || string(secret.Password) != "hunter2" {
+			t.Fatalf("expected tags [work] and the same password, got %v %q", secret.Tags, secret.Password)
+		}
+
+		changed, err = service.RemoveTag("github", "work")
+		if err != nil || !changed {
+			t.Fatalf("expected the tag to be removed, got changed=%v err=%v", changed, err)
+		}
+
+		changed, err = service.RemoveTag("github", "work")
+		if err != nil || changed {
+			t.Fatalf("expected removing a missing tag to change nothing, got changed=%v err=%v", changed, err)
+		}
+	})
+
+	t.Run("should return ErrSecretNotFound for a missing secret", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if _, err := service.AddTag("missing", "work"); !errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("expected ErrSecretNotFound, got %v", err)
+		}
+	})
+}
+
+func TestSetLoginFields(t *testing.T) {
+	t.Run("should store the login order and keep the password", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("github", []byte("hunter2")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		order := []string{"url", "username", "password"}
+
+		changed, err := service.SetLoginFields("github", order)
+		if err != nil || !changed {
+			t.Fatalf("expected the order to be stored, got changed=%v err=%v", changed, err)
+		}
+
+		changed, err = service.SetLoginFields("github", order)
+		if err != nil || changed {
+			t.Fatalf("expected the same order to change nothing, got changed=%v err=%v", changed, err)
+		}
+
+		secret, err := service.GetSecretWithMeta("github")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+
+		if !reflect.DeepEqual(secret.LoginFields, order) || string(secret.Password) != "hunter2" {
+			t.Fatalf("expected %v and the same password, got %v %q", order, secret.LoginFields, secret.Password)
+		}
+
+		if changed, err := service.SetLoginFields("github", nil); err != nil || !changed {
+			t.Fatalf("expected the order to be reset, got changed=%v err=%v", changed, err)
+		}
+	})
+
+	t.Run("should reject unknown fields", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("github", []byte("hunter2")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		_, err := service.SetLoginFields("github", []string{"username", "totp"})
+		if !errors.Is(err, ErrInvalidLoginField) {
+			t.Fatalf("expected ErrInvalidLoginField, got %v", err)
+		}
+	})
+}
+
 func TestListSecrets(t *testing.T) {
 	t.Run("should return list of secrets", func(t *testing.T) {
 		service := newTestService(t, "master-key")
@@ -244,3 +427,668 @@ func TestListSecrets(t *testing.T) {
 		}
 	})
 }
+
+func TestListSecretsRecursive(t *testing.T) {
+	t.Run("should return secrets stored in folders", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		err := service.AddSecret("root", []byte("pass1"))
+		if err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		err = service.AddSecret("work/github", []byte("pass2"))
+		if err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		flat, err := service.GetSecrets()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !reflect.DeepEqual(flat, []string{"root"}) {
+			t.Fatalf("expected only top-level secrets, got %v", flat)
+		}
+
+		all, err := service.GetSecretsRecursive()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !reflect.DeepEqual(all, []string{"root", "work/github"}) {
+			t.Fatalf("expected nested secrets, got %v", all)
+		}
+	})
+}
+
+func BenchmarkGetSecrets(b *testing.B) {
+	for _, count := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("%d", count), func(b *testing.B) {
+			store, err := storage.NewStore(b.TempDir())
+			if err != nil {
+				b.Fatalf("failed to create store: %v", err)
+			}
+
+			for i := range count {
+				if err := store.SaveFile([]byte{}, fmt.Sprintf("secret-%05d", i)); err != nil {
+					b.Fatalf("failed to save file: %v", err)
+				}
+			}
+
+			service := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key")))
+
+			for b.Loop() {
+				if _, err := service.GetSecrets(); err != nil {
+					b.Fatalf("GetSecrets failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestSecretPayloadFormat(t *testing.T) {
+	t.Run("should encrypt the binary secret encoding rather than JSON", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		crypto := encryption.NewVaultWithMK([]byte("master-key"))
+		service := NewMSKService(store, crypto)
+
+		if err := service.AddSecret("binary", []byte("p@ss")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		fileData, err := store.GetFile("binary")
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+
+		salt, nonce, data, err := format.UnmarshalFile(fileData)
+		if err != nil {
+			t.Fatalf("failed to unmarshal file: %v", err)
+		}
+
+		payload, err := crypto.Decrypt(salt, nonce, data)
+		if err != nil {
+			t.Fatalf("failed to decrypt: %v", err)
+		}
+
+		if json.Valid(payload) {
+			t.Fatalf("expected a binary payload, got JSON: %s", payload)
+		}
+
+		secret, err := format.UnmarshalSecret(payload)
+		if err != nil {
+			t.Fatalf("expected a binary secret payload, got %v", err)
+		}
+
+		if secret.Name != "binary" || string(secret.Password) != "p@ss" {
+			t.Fatalf("unexpected secret %q/%q", secret.Name, secret.Password)
+		}
+	})
+}
+
+func TestGetSecretsMetadata(t *testing.T) {
+	t.Run("should return metadata in order without passwords", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		for _, name := range []string{"first", "work/second"} {
+			if err := service.AddSecret(name, []byte("pass")); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+		}
+
+		secrets, err := service.GetSecretsMetadata(context.Background(), []string{"work/second", "first"})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(secrets) != 2 || secrets[0].Name != "work/second" || secrets[1].Name != "first" {
+			t.Fatalf("unexpected secrets: %+v", secrets)
+		}
+
+		for _, secret := range secrets {
+			if secret.Password != nil {
+				t.Fatalf("expected password of %s to be dropped", secret.Name)
+			}
+
+			if secret.CreatedAt.IsZero() {
+				t.Fatalf("expected creation time for %s", secret.Name)
+			}
+		}
+	})
+
+	t.Run("should return error for missing secret", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		_, err := service.GetSecretsMetadata(context.Background(), []string{"missing"})
+		if !errors.Is(err, storage.ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("should stop with a PartialError when ctx is cancelled", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("first", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, err := service.GetSecretsMetadata(ctx, []string{"first"})
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+
+		var partial *PartialError
+		if !errors.As(err, &partial) || partial.Done != 0 || partial.Total != 1 {
+			t.Fatalf("expected a PartialError for 0 of 1 secrets, got %v", err)
+		}
+	})
+}
+
+func TestRekeySecrets(t *testing.T) {
+	t.Run("should re-encrypt every secret with a fresh salt", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		crypto := encryption.NewVaultWithMK([]byte("master-key"))
+		service := NewMSKService(store, crypto)
+
+		names := []string{"first", "work/second"}
+		before := make(map[string][]byte)
+
+		for _, name := range names {
+			if err := service.AddSecret(name, []byte("pass-"+name)); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+
+			fileData, err := store.GetFile(name)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+
+			salt, _, _, err := format.UnmarshalFile(fileData)
+			if err != nil {
+				t.Fatalf("failed to unmarshal file: %v", err)
+			}
+
+			before[name] = salt
+		}
+
+		count, err := service.RekeySecrets(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if count != len(names) {
+			t.Fatalf("expected %d rekeyed secrets, got %d", len(names), count)
+		}
+
+		for _, name := range names {
+			fileData, err := store.GetFile(name)
+			if err != nil {
+				t.Fatalf("failed to read file: %v", err)
+			}
+
+			salt, _, _, err := format.UnmarshalFile(fileData)
+			if err != nil {
+				t.Fatalf("failed to unmarshal file: %v", err)
+			}
+
+			if reflect.DeepEqual(salt, before[name]) {
+				t.Fatalf("expected a new salt for %s", name)
+			}
+
+			secret := readStoredSecret(t, store, crypto, name)
+			if string(secret.Password) != "pass-"+name {
+				t.Fatalf("expected password of %s to be kept, got %q", name, secret.Password)
+			}
+		}
+	})
+
+	t.Run("should stop with the progress so far when the context is cancelled", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("first", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		count, err := service.RekeySecrets(ctx)
+		if count != 0 || !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected 0 rekeyed and context.Canceled, got %d, %v", count, err)
+		}
+
+		var partial *PartialError
+		if !errors.As(err, &partial) || partial.Total != 1 {
+			t.Fatalf("expected a PartialError, got %v", err)
+		}
+	})
+}
+
+func TestCheckSecret(t *testing.T) {
+	t.Run("should pass for a secret that decrypts", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("github", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		if err := service.CheckSecret("github"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrSecretNotFound for missing secret", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		err := service.CheckSecret("missing")
+		if !errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("expected ErrSecretNotFound, got %v", err)
+		}
+	})
+}
+
+// recordingVault keeps every plaintext buffer returned by Decrypt so tests can
+// check the service wipes them.
+type recordingVault struct {
+	encryption.Vault
+	plaintexts [][]byte
+}
+
+func (v *recordingVault) DecryptFile(file []byte) ([]byte, error) {
+	plaintext, err := v.Vault.DecryptFile(file)
+	if err == nil {
+		v.plaintexts = append(v.plaintexts, plaintext)
+	}
+	return plaintext, err
+}
+
+func TestDecryptWipesPlaintext(t *testing.T) {
+	t.Run("should zero the decrypted payload but keep the returned password", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		crypto := &recordingVault{Vault: encryption.NewVaultWithMK([]byte("master-key"))}
+		service := NewMSKService(store, crypto)
+
+		if err := service.AddSecret("github", []byte("p@ss")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		password, err := service.GetSecret("github")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+
+		if string(password) != "p@ss" {
+			t.Fatalf("expected password to survive the wipe, got %q", password)
+		}
+
+		if len(crypto.plaintexts) != 1 {
+			t.Fatalf("expected one decrypted payload, got %d", len(crypto.plaintexts))
+		}
+
+		for _, b := range crypto.plaintexts[0] {
+			if b != 0 {
+				t.Fatalf("expected decrypted payload to be wiped, got %x", crypto.plaintexts[0])
+			}
+		}
+	})
+}
+
+func TestGetAllSecrets(t *testing.T) {
+	t.Run("should return readable secrets and report failures", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key")))
+
+		for _, name := range []string{"first", "work/second"} {
+			if err := service.AddSecret(name, []byte("pass-"+name)); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+		}
+
+		if err := store.SaveFile([]byte("not a secret file"), "broken"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		secrets, failures, err := service.GetAllSecrets(context.Background())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(secrets) != 2 {
+			t.Fatalf("expected 2 secrets, got %d", len(secrets))
+		}
+
+		if len(failures) != 1 || failures[0].Name != "broken" {
+			t.Fatalf("expected one failure for broken, got %v", failures)
+		}
+	})
+
+	t.Run("should stop when the context is cancelled", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("first", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		_, _, err := service.GetAllSecrets(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+
+		var partial *PartialError
+		if !errors.As(err, &partial) || partial.Done != 0 || partial.Total != 1 {
+			t.Fatalf("expected a PartialError for 0 of 1 secrets, got %v", err)
+		}
+	})
+}
+
+func TestSetSecret(t *testing.T) {
+	t.Run("should add a missing secret and update an existing one", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.SetSecret(context.Background(), "github", []byte("first")); err != nil {
+			t.Fatalf("expected no error on add, got %v", err)
+		}
+
+		if err := service.SetSecret(context.Background(), "github", []byte("second")); err != nil {
+			t.Fatalf("expected no error on update, got %v", err)
+		}
+
+		password, err := service.GetSecret("github")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+
+		if string(password) != "second" {
+			t.Fatalf("expected updated password, got %q", password)
+		}
+	})
+}
+
+func TestAddSecretWithMeta(t *testing.T) {
+	t.Run("should keep the given creation time", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		crypto := encryption.NewVaultWithMK([]byte("master-key"))
+		service := NewMSKService(store, crypto)
+
+		created := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+		err = service.AddSecretWithMeta(context.Background(), domain.Secret{
+			Name:      "imported",
+			Password:  []byte("pass"),
+			CreatedAt: created,
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		secret := readStoredSecret(t, store, crypto, "imported")
+		if !secret.CreatedAt.Equal(created) || !secret.UpdatedAt.Equal(created) {
+			t.Fatalf("expected timestamps %v, got created %v updated %v", created, secret.CreatedAt, secret.UpdatedAt)
+		}
+	})
+
+	t.Run("should return ErrSecretExists without wiping the password", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("imported", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		password := []byte("new-pass")
+		err := service.AddSecretWithMeta(context.Background(), domain.Secret{Name: "imported", Password: password})
+		if !errors.Is(err, ErrSecretExists) {
+			t.Fatalf("expected ErrSecretExists, got %v", err)
+		}
+
+		if string(password) != "new-pass" {
+			t.Fatalf("expected password to be left for the caller, got %q", password)
+		}
+	})
+}
+
+func TestGetSecretWithMeta(t *testing.T) {
+	t.Run("should return the password and username", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		err := service.AddSecretWithMeta(context.Background(), domain.Secret{
+			Name:     "github",
+			Password: []byte("pass"),
+			Username: "octocat",
+		})
+		if err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		secret, err := service.GetSecretWithMeta("github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if string(secret.Password) != "pass" || secret.Username != "octocat" {
+			t.Fatalf("expected pass/octocat, got %q/%q", secret.Password, secret.Username)
+		}
+	})
+
+	t.Run("should return ErrSecretNotFound for a missing secret", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		_, err := service.GetSecretWithMeta("missing")
+		if !errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("expected ErrSecretNotFound, got %v", err)
+		}
+	})
+}
+
+func TestSecretPath(t *testing.T) {
+	t.Run("should return the absolute path of the secret file", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		if err := service.AddSecret("work/github", []byte("pass")); err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		path, err := service.SecretPath("work/github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		want := filepath.Join(service.VaultPath(), "work", "github.msk")
+		if !filepath.IsAbs(path) || path != want {
+			t.Fatalf("expected %s, got %s", want, path)
+		}
+	})
+
+	t.Run("should return ErrSecretNotFound for a missing secret", func(t *testing.T) {
+		service := newTestService(t, "master-key")
+
+		_, err := service.SecretPath("missing")
+		if !errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("expected ErrSecretNotFound, got %v", err)
+		}
+	})
+}
+
+func TestAddSecretWithPolicy(t *testing.T) {
+	setup := func(t *testing.T) (storage.Repository, encryption.Vault, Service) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		crypto := encryption.NewVaultWithMK([]byte("master-key"))
+		service := NewMSKService(store, crypto)
+
+		created := time.Date(2020, 5, 17, 10, 0, 0, 0, time.UTC)
+		err = service.AddSecretWithMeta(context.Background(), domain.Secret{
+			Name:      "github",
+			Password:  []byte("old-pass"),
+			Username:  "octocat",
+			CreatedAt: created,
+		})
+		if err != nil {
+			t.Fatalf("add failed: %v", err)
+		}
+
+		return store, crypto, service
+	}
+
+	t.Run("should add a new secret", func(t *testing.T) {
+		_, _, service := setup(t)
+
+		result, err := service.AddSecretWithPolicy(context.Background(), domain.Secret{Name: "gitlab", Password: []byte("pass")}, OnConflictError)
+		if err != nil || result != AddResultAdded {
+			t.Fatalf("expected added, got %v, %v", result, err)
+		}
+	})
+
+	t.Run("should return ErrSecretExists with the error policy", func(t *testing.T) {
+		_, _, service := setup(t)
+
+		_, err := service.AddSecretWithPolicy(context.Background(), domain.Secret{Name: "github", Password: []byte("new-pass")}, OnConflictError)
+		if !errors.Is(err, ErrSecretExists) {
+			t.Fatalf("expected ErrSecretExists, got %v", err)
+		}
+	})
+
+	t.Run("should keep the stored secret with the skip policy", func(t *testing.T) {
+		store, crypto, service := setup(t)
+
+		result, err := service.AddSecretWithPolicy(context.Background(), domain.Secret{Name: "github", Password: []byte("new-pass")}, OnConflictSkip)
+		if err != nil || result != AddResultSkipped {
+			t.Fatalf("expected skipped, got %v, %v", result, err)
+		}
+
+		if secret := readStoredSecret(t, store, crypto, "github"); string(secret.Password) != "old-pass" {
+			t.Fatalf("expected old-pass, got %q", secret.Password)
+		}
+	})
+
+	t.Run("should replace the password and keep metadata with the overwrite policy", func(t *testing.T) {
+		store, crypto, service := setup(t)
+
+		result, err := service.AddSecretWithPolicy(context.Background(), domain.Secret{Name: "github", Password: []byte("new-pass")}, OnConflictOverwrite)
+		if err != nil || result != AddResultOverwritten {
+			t.Fatalf("expected overwritten, got %v, %v", result, err)
+		}
+
+		secret := readStoredSecret(t, store, crypto, "github")
+		if string(secret.Password) != "new-pass" || secret.Username != "octocat" {
+			t.Fatalf("expected new-pass/octocat, got %q/%q", secret.Password, secret.Username)
+		}
+
+		if !secret.UpdatedAt.After(secret.CreatedAt) {
+			t.Fatalf("expected UpdatedAt after CreatedAt, got %v and %v", secret.UpdatedAt, secret.CreatedAt)
+		}
+	})
+}
+
+func TestStorageFailures(t *testing.T) {
+	cause := errors.New("disk full")
+
+	t.Run("should surface a failed write as ErrStorageIO", func(t *testing.T) {
+		store := storage.NewMemStore()
+		store.Fail = map[string]error{"SaveFile": cause}
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key")))
+
+		err := service.AddSecret("github", []byte("password"))
+		if !errors.Is(err, storage.ErrStorageIO) || !errors.Is(err, cause) {
+			t.Fatalf("expected ErrStorageIO wrapping the cause, got %v", err)
+		}
+	})
+
+	t.Run("should not report a failed read as a missing secret", func(t *testing.T) {
+		store := storage.NewMemStore()
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key")))
+
+		if err := service.AddSecret("github", []byte("password")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		store.Fail = map[string]error{"GetFile": cause}
+
+		_, err := service.GetSecret("github")
+		if !errors.Is(err, storage.ErrStorageIO) || errors.Is(err, ErrSecretNotFound) {
+			t.Fatalf("expected ErrStorageIO, got %v", err)
+		}
+	})
+}
+
+func TestParallel(t *testing.T) {
+	t.Run("should keep the order of the names when working in parallel", func(t *testing.T) {
+		service := NewMSKService(storage.NewMemStore(), encryption.NewVaultWithMK([]byte("master-key")))
+
+		var names []string
+		for i := range 6 {
+			name := fmt.Sprintf("secret-%d", i)
+			if err := service.AddSecret(name, []byte("pass")); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+			names = append(names, name)
+		}
+
+		service.SetParallel(4)
+
+		secrets, err := service.GetSecretsMetadata(context.Background(), names)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for i, secret := range secrets {
+			if secret.Name != names[i] {
+				t.Fatalf("expected %s at %d, got %s", names[i], i, secret.Name)
+			}
+		}
+
+		count, err := service.RekeySecrets(context.Background())
+		if err != nil || count != len(names) {
+			t.Fatalf("expected %d rekeyed, got %d (%v)", len(names), count, err)
+		}
+	})
+
+	t.Run("should report the first failing name", func(t *testing.T) {
+		store := storage.NewMemStore()
+		service := NewMSKService(store, encryption.NewVaultWithMK([]byte("master-key")))
+
+		for _, name := range []string{"a", "b", "c"} {
+			if err := service.AddSecret(name, []byte("pass")); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+		}
+		if err := store.SaveFile([]byte("broken"), "b"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		service.SetParallel(3)
+
+		_, err := service.GetSecretsMetadata(context.Background(), []string{"a", "b", "c"})
+		if err == nil || !strings.Contains(err.Error(), "failed to read b") {
+			t.Fatalf("expected the failure of b, got %v", err)
+		}
+	})
+}
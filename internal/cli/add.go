@@ -3,11 +3,10 @@ package cli
 import (
 	"errors"
 	"fmt"
+	"os"
 
 	clip "github.com/amauribechtoldjr/msk/internal/clip"
-	"github.com/amauribechtoldjr/msk/internal/generator"
 	"github.com/amauribechtoldjr/msk/internal/logger"
-	"github.com/amauribechtoldjr/msk/internal/prompt"
 	"github.com/amauribechtoldjr/msk/internal/validator"
 	"github.com/amauribechtoldjr/msk/internal/wipe"
 	"github.com/spf13/cobra"
@@ -15,39 +14,32 @@ import (
 
 func NewAddCmd(holder *ServiceHolder) *cobra.Command {
 	var (
-		generate  bool
-		length    int
-		noSymbols bool
+		source      passwordSource
+		noClipClear bool
+		saveToFile  string
+		overwrite   bool
 	)
 
 	addCmd := &cobra.Command{
 		Use:     "add <name>",
 		Aliases: []string{"a"},
 		Short:   "Used to add passwords to the vault.",
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
-				return errors.New("password name is required")
-			}
-
 			name := args[0]
 
-			err := validator.Validate(name)
+			err := validator.ValidatePath(name)
 			if err != nil {
 				return fmt.Errorf("invalid password name: %v", err)
 			}
 
-			var password []byte
+			if err := checkSaveToFile(saveToFile, source.generate, overwrite); err != nil {
+				return err
+			}
 
-			if generate {
-				password, err = generator.GeneratePassword(length, noSymbols)
-				if err != nil {
-					return fmt.Errorf("failed to generate password: %w", err)
-				}
-			} else {
-				password, err = prompt.ReadSafeValue("Enter password:")
-				if err != nil {
-					return err
-				}
+			password, err := source.password()
+			if err != nil {
+				return err
 			}
 			defer wipe.Bytes(password)
 
@@ -55,33 +47,66 @@ func NewAddCmd(holder *ServiceHolder) *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("failed to add secret: %w", err)
 			}
+			source.stored()
 
-			if generate {
+			if source.generate {
 				secret, err := holder.Service.GetSecret(name)
 				if err != nil {
 					return fmt.Errorf("failed to add secret: %w", err)
 				}
 				defer wipe.Bytes(secret)
 
-				err = clip.CopyText(secret)
-				if err != nil {
-					return fmt.Errorf("failed to copy password to your clipboard: %w", err)
+				if saveToFile != "" {
+					if err := writeOutFile(saveToFile, secret, "0600", overwrite, false); err != nil {
+						return fmt.Errorf("password stored, but failed to write %s: %w", saveToFile, err)
+					}
+					logger.PrintError("Warning: a plaintext copy of the password now exists at %s\n", saveToFile)
+
+					// The file already holds it, so there is no need to print it.
+					if clip.Disabled() {
+						logger.PrintSuccessf("Password generated and written to %s\n", saveToFile)
+						return nil
+					}
 				}
 
-				logger.PrintSuccess("Password generated and copied to clipboard (press Ctrl+V to paste)\n\n")
-
-				clip.Clear()
-			} else {
-				logger.PrintSuccess("Password added successfully\n")
+				return copyPassword(cmd.Context(), cmd.OutOrStdout(), secret, "Password generated and copied to clipboard (press Ctrl+V to paste)\n\n", noClipClear)
 			}
 
+			logger.PrintSuccess("Password added successfully\n")
+
 			return nil
 		},
 	}
 
-	addCmd.Flags().BoolVarP(&generate, "generate", "g", false, "Generate a random password instead of prompting")
-	addCmd.Flags().IntVarP(&length, "length", "l", 16, "Length of the generated password")
-	addCmd.Flags().BoolVar(&noSymbols, "no-symbols", false, "Exclude symbols from the generated password")
+	source.register(addCmd)
+	addCmd.Flags().BoolVar(&noClipClear, "no-clip-clear", false, "Keep the generated password on the clipboard instead of clearing it")
+	addCmd.Flags().StringVar(&saveToFile, "save-to-file", "", "With --generate, also write the password to this file with mode 0600")
+	addCmd.Flags().BoolVar(&overwrite, "overwrite", false, "With --save-to-file, replace the file if it already exists")
 
 	return addCmd
 }
+
+// checkSaveToFile validates --save-to-file before anything is stored, so an
+// existing file does not leave a new secret behind with no copy written.
+func checkSaveToFile(path string, generate, overwrite bool) error {
+	if path == "" {
+		if overwrite {
+			return errors.New("--overwrite only applies to --save-to-file")
+		}
+		return nil
+	}
+
+	if !generate {
+		return errors.New("--save-to-file only applies to --generate")
+	}
+
+	if overwrite {
+		return nil
+	}
+
+	if _, err := os.Lstat(path); err == nil {
+		return fmt.Errorf("%s already exists, use --overwrite to replace it", path)
+	}
+
+	return nil
+}
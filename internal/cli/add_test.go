@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckSaveToFile(t *testing.T) {
+	existing := filepath.Join(t.TempDir(), "password.txt")
+	if err := os.WriteFile(existing, []byte("old"), 0o600); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	missing := filepath.Join(t.TempDir(), "password.txt")
+
+	tests := []struct {
+		name      string
+		path      string
+		generate  bool
+		overwrite bool
+		wantErr   bool
+	}{
+		{name: "should accept no file", path: "", generate: true},
+		{name: "should accept a new file with --generate", path: missing, generate: true},
+		{name: "should reject a file without --generate", path: missing, wantErr: true},
+		{name: "should reject an existing file", path: existing, generate: true, wantErr: true},
+		{name: "should accept an existing file with --overwrite", path: existing, generate: true, overwrite: true},
+		{name: "should reject --overwrite without a file", path: "", generate: true, overwrite: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkSaveToFile(tt.path, tt.generate, tt.overwrite)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expected error %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+}
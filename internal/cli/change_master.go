@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/files"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+func NewChangeMasterCmd(holder *ServiceHolder, v vault.Vault) *cobra.Command {
+	return &cobra.Command{
+		Use:   "change-master",
+		Short: "Change the master password.",
+		Long: `Change the master password.
+
+Passwords are sealed by a random vault key that the master password wraps, so
+only the vault key and the config are re-encrypted. Passwords stored before
+vault keys existed are upgraded first. An existing recovery code stops working
+and a new one is printed.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.NewConfig(holder.ConfigPath)
+			if err != nil {
+				return err
+			}
+
+			exists, err := conf.Exists()
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				return config.ErrConfigNotFound
+			}
+
+			hadRecovery, err := files.FileExists(conf.RecoveryPath())
+			if err != nil {
+				return err
+			}
+
+			logger.PrintInfo("Current master password.\n")
+			if err := v.LoadMK(); err != nil {
+				return memoryLockHint(err)
+			}
+			v.EnableKeyCache()
+
+			next := vault.NewVault()
+			if allowsUnlockedMemory(cmd) {
+				next.AllowUnlockedMemory()
+			}
+
+			logger.PrintInfo("New master password.\n")
+			if err := next.LoadNewMK(); err != nil {
+				return memoryLockHint(err)
+			}
+			defer next.DestroyMK()
+
+			return changeMaster(cmd, holder, conf, v, next, hadRecovery)
+		},
+	}
+}
+
+// changeMaster moves the vault from the master password in from to the one
+// in to and, when a recovery code was set up, prints its replacement.
+func changeMaster(cmd *cobra.Command, holder *ServiceHolder, conf *config.Config, from, to vault.Vault, newRecoveryCode bool) error {
+	vaultPath, upgraded, err := app.ChangeMasterPassword(cmd.Context(), holder.ConfigPath, from, to)
+	if err != nil {
+		return err
+	}
+
+	if upgraded > 0 {
+		logger.PrintSuccessf("Upgraded %d passwords to the vault key format\n", upgraded)
+	}
+	logger.PrintSuccess("Master password changed\n")
+
+	if newRecoveryCode {
+		return printRecoveryCode(cmd.OutOrStdout(), conf, to, vaultPath)
+	}
+
+	return nil
+}
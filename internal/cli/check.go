@@ -0,0 +1,181 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+type checkResult struct {
+	Name  string `json:"name"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+func NewCheckCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		all      bool
+		fromFile string
+		decrypt  bool
+		parallel int
+	)
+
+	checkCmd := &cobra.Command{
+		Use:   "check [name...]",
+		Short: "Validate password names and optionally that they decrypt, without changing anything.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			names := args
+
+			if fromFile != "" {
+				fileNames, err := readNamesFile(fromFile)
+				if err != nil {
+					return err
+				}
+				names = append(names, fileNames...)
+			}
+
+			// With --all --decrypt the whole vault is decrypted in parallel up
+			// front, and the loop below only reports the outcome.
+			var decrypted map[string]error
+			if all && decrypt {
+				vaultNames, results, err := decryptAll(cmd.Context(), holder)
+				if err != nil {
+					return err
+				}
+				names = append(names, vaultNames...)
+				decrypted = results
+			} else if all {
+				vaultNames, err := holder.Service.GetSecretsRecursive()
+				if err != nil {
+					return fmt.Errorf("failed to list passwords: %w", err)
+				}
+				names = append(names, vaultNames...)
+			}
+
+			if len(names) == 0 {
+				return errors.New("nothing to check, pass names, --file or --all")
+			}
+
+			failed := 0
+			results := make([]checkResult, 0, len(names))
+			for _, name := range names {
+				err := validator.ValidatePath(name)
+				if err == nil && decrypt {
+					if result, ok := decrypted[name]; ok {
+						err = result
+					} else {
+						err = holder.Service.CheckSecret(name)
+					}
+				}
+
+				if err != nil {
+					failed++
+					results = append(results, checkResult{Name: name, OK: false, Error: err.Error()})
+					if !holder.JSON {
+						fmt.Fprintf(cmd.OutOrStdout(), "FAIL %s: %v\n", name, err)
+					}
+					continue
+				}
+
+				results = append(results, checkResult{Name: name, OK: true})
+				if !holder.JSON {
+					fmt.Fprintf(cmd.OutOrStdout(), "ok   %s\n", name)
+				}
+			}
+
+			if holder.JSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				if err := enc.Encode(results); err != nil {
+					return err
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d checks failed", failed, len(names))
+			}
+
+			logger.PrintSuccessf("All %d checks passed\n", len(names))
+			return nil
+		},
+	}
+
+	checkCmd.Flags().BoolVarP(&all, "all", "a", false, "Check every password in the vault")
+	checkCmd.Flags().StringVarP(&fromFile, "file", "f", "", "Read names to check from a file, one per line (# starts a comment)")
+	checkCmd.Flags().BoolVarP(&decrypt, "decrypt", "d", false, "Also check that each password exists and decrypts")
+	registerParallel(checkCmd, &parallel)
+
+	return checkCmd
+}
+
+// decryptAll decrypts every password in the vault and returns their names,
+// sorted so the output does not depend on which finished first, with the
+// error each one failed with, if any.
+func decryptAll(ctx context.Context, holder *ServiceHolder) ([]string, map[string]error, error) {
+	progress := logger.NewProgress("Decrypting")
+	holder.Service.OnProgress(progress.Update)
+
+	secrets, failures, err := holder.Service.GetAllSecrets(ctx)
+	progress.Done()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	names := make([]string, 0, len(secrets)+len(failures))
+	results := make(map[string]error, len(secrets)+len(failures))
+
+	for _, secret := range secrets {
+		wipe.Bytes(secret.Password)
+		names = append(names, secret.Name)
+		results[secret.Name] = nil
+	}
+
+	for _, failure := range failures {
+		names = append(names, failure.Name)
+		results[failure.Name] = failure.Err
+	}
+
+	slices.Sort(names)
+
+	return names, results, nil
+}
+
+func readNamesFile(path string) ([]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open names file: %w", err)
+	}
+	defer file.Close()
+
+	var names []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		names = append(names, line)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read names file: %w", err)
+	}
+
+	return names, nil
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// checkService decrypts the vault in bulk, returning secrets out of order as
+// parallel workers may, and fails any single-secret check.
+type checkService struct {
+	app.Service
+	parallel *int
+}
+
+func (s checkService) SetParallel(n int) {
+	*s.parallel = n
+}
+
+func (s checkService) OnProgress(app.ProgressFunc) {}
+
+func (s checkService) GetAllSecrets(context.Context) ([]domain.Secret, []app.SecretError, error) {
+	secrets := []domain.Secret{
+		{Name: "work/gitlab", Password: []byte("s3cret")},
+		{Name: "github", Password: []byte("hunter2")},
+	}
+	failures := []app.SecretError{{Name: "broken", Err: errors.New("decryption failed")}}
+
+	return secrets, failures, nil
+}
+
+func (s checkService) CheckSecret(string) error {
+	return errors.New("unexpected single-secret check")
+}
+
+func TestCheckAllDecrypt(t *testing.T) {
+	t.Run("should decrypt the vault in bulk and report in name order", func(t *testing.T) {
+		logger.SetOutput(io.Discard)
+		t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+		parallel := 0
+		holder := &ServiceHolder{Service: checkService{parallel: &parallel}}
+
+		var out bytes.Buffer
+		cmd := NewCheckCmd(holder)
+		cmd.SilenceUsage = true
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"--all", "--decrypt", "--parallel", "3"})
+
+		err := cmd.Execute()
+		if err == nil || err.Error() != "1 of 3 checks failed" {
+			t.Fatalf("expected one failed check, got %v", err)
+		}
+
+		want := "FAIL broken: decryption failed\nok   github\nok   work/gitlab\n"
+		if out.String() != want {
+			t.Fatalf("expected output %q, got %q", want, out.String())
+		}
+
+		if parallel != 3 {
+			t.Fatalf("expected --parallel 3 to reach the service, got %d", parallel)
+		}
+	})
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// copyText and clearIfCopied reach the system clipboard; tests swap them out
+// since they run without one.
+var (
+	copyText      = clip.CopyText
+	clearIfCopied = clip.ClearIfCopied
+)
+
+// copyPassword copies the password to the clipboard and, unless noClear is
+// set, runs the clear countdown. When the clipboard is unavailable (e.g.
+// headless servers) it prints the password to out with a warning instead of
+// failing. Cancelling ctx cuts the countdown short.
+func copyPassword(ctx context.Context, out io.Writer, password []byte, message string, noClear bool) error {
+	copied, err := copyOrPrint(out, password, message)
+	if err != nil || !copied {
+		return err
+	}
+
+	if noClear {
+		logger.PrintError("Warning: the clipboard will not be cleared automatically\n")
+		return nil
+	}
+
+	clip.Clear(ctx, clip.ClearDelay)
+
+	return nil
+}
+
+// copyOrPrint copies the password, or any other value such as a username, to
+// the clipboard, falling back to out when no clipboard is available. It
+// reports whether the clipboard was used. With the clipboard setting disabled
+// it prints without a warning, as that is what was asked for.
+func copyOrPrint(out io.Writer, password []byte, message string) (bool, error) {
+	err := copyText(password)
+	if errors.Is(err, clip.ErrClipboardDisabled) {
+		fmt.Fprintf(out, "%s\n", password)
+		return false, nil
+	}
+
+	if errors.Is(err, clip.ErrClipboardInit) {
+		logger.PrintError("Clipboard unavailable, printing to stdout instead\n")
+		fmt.Fprintf(out, "%s\n", password)
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("failed to copy password to your clipboard: %w", err)
+	}
+
+	logger.PrintSuccess(message)
+	return true, nil
+}
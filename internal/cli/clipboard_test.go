@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+func TestCopyOrPrintDisabled(t *testing.T) {
+	t.Run("should print without a warning when the clipboard is disabled", func(t *testing.T) {
+		var messages bytes.Buffer
+		logger.SetOutput(&messages)
+		clip.SetDisabled(true)
+		t.Cleanup(func() {
+			logger.SetOutput(os.Stderr)
+			clip.SetDisabled(false)
+		})
+
+		var out bytes.Buffer
+		copied, err := copyOrPrint(&out, []byte("hunter2"), "Password copied to clipboard\n")
+		if err != nil || copied {
+			t.Fatalf("expected a print without error, got copied=%v and %v", copied, err)
+		}
+
+		if out.String() != "hunter2\n" {
+			t.Fatalf("expected the password on stdout, got %q", out.String())
+		}
+
+		if messages.Len() != 0 {
+			t.Fatalf("expected no messages, got %q", messages.String())
+		}
+	})
+}
+
+func TestAsksForClipboard(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want bool
+	}{
+		{name: "should be false without clipboard flags", args: []string{"github"}, want: false},
+		{name: "should be true for --copy", args: []string{"github", "--copy"}, want: true},
+		{name: "should be true for --from-clipboard", args: []string{"github", "--from-clipboard"}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "test"}
+			cmd.Flags().Bool("copy", false, "")
+			cmd.Flags().Bool("from-clipboard", false, "")
+
+			if err := cmd.ParseFlags(tt.args); err != nil {
+				t.Fatalf("failed to parse flags: %v", err)
+			}
+
+			if got := asksForClipboard(cmd); got != tt.want {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
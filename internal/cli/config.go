@@ -2,21 +2,26 @@ package cli
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/amauribechtoldjr/msk/internal/app"
 	"github.com/amauribechtoldjr/msk/internal/config"
 	"github.com/amauribechtoldjr/msk/internal/logger"
 	"github.com/amauribechtoldjr/msk/internal/vault"
 	"github.com/spf13/cobra"
 )
 
-func NewConfigCmd(vault vault.Vault) *cobra.Command {
-	var showConfig bool
+func NewConfigCmd(holder *ServiceHolder, vault vault.Vault) *cobra.Command {
+	var (
+		showConfig   bool
+		recoveryCode bool
+	)
 
 	configCmd := &cobra.Command{
 		Use:   "config",
 		Short: "Configure MSK vault path and master password.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			conf, err := config.NewConfig()
+			conf, err := config.NewConfig(holder.ConfigPath)
 			if err != nil {
 				return err
 			}
@@ -31,8 +36,16 @@ func NewConfigCmd(vault vault.Vault) *cobra.Command {
 					return config.ErrConfigNotFound
 				}
 
-				logger.PrintInfo(conf.Path)
-				logger.Lb()
+				logger.PrintInfo(conf.Path + "\n")
+
+				fingerprint, err := conf.Fingerprint()
+				if err != nil {
+					return err
+				}
+
+				if fingerprint != "" {
+					logger.PrintInfo("Vault fingerprint: " + fingerprint + "\n")
+				}
 				return nil
 			}
 
@@ -41,6 +54,10 @@ func NewConfigCmd(vault vault.Vault) *cobra.Command {
 				return err
 			}
 
+			if exists && recoveryCode {
+				return addRecoveryCode(cmd, conf, vault)
+			}
+
 			var shouldOverwrite bool
 			if exists {
 				shouldOverwrite, err = conf.CheckOverwrite()
@@ -61,7 +78,7 @@ func NewConfigCmd(vault vault.Vault) *cobra.Command {
 
 			err = vault.LoadMK()
 			if err != nil {
-				return err
+				return memoryLockHint(err)
 			}
 			defer vault.DestroyMK()
 
@@ -70,11 +87,69 @@ func NewConfigCmd(vault vault.Vault) *cobra.Command {
 			}
 
 			logger.PrintSuccess(fmt.Sprintf("Vault path created successfully at: %s\n", vaultPath))
+
+			if recoveryCode {
+				return printRecoveryCode(cmd.OutOrStdout(), conf, vault, vaultPath)
+			}
+
 			return nil
 		},
 	}
 
 	configCmd.Flags().BoolVarP(&showConfig, "show", "s", false, "Show config and session path")
+	configCmd.Flags().BoolVar(&recoveryCode, "recovery-code", false, "Create a recovery code that can reset a forgotten master password (see 'msk recover'), replacing any previous one")
+
+	configCmd.AddCommand(newConfigSetCmd(holder))
 
 	return configCmd
 }
+
+// addRecoveryCode unlocks an existing config and creates a recovery code for
+// its vault key. Passwords still sealed with the master password are upgraded
+// first, since the recovery code only gives back the vault key.
+func addRecoveryCode(cmd *cobra.Command, conf *config.Config, vault vault.Vault) error {
+	if err := vault.LoadMK(); err != nil {
+		return memoryLockHint(err)
+	}
+	defer vault.DestroyMK()
+
+	// The verifier and the config share a salt, so derive the key once.
+	vault.EnableKeyCache()
+
+	vaultPath, err := conf.Load(vault)
+	if err != nil {
+		return err
+	}
+
+	upgraded, err := app.UpgradeSecrets(cmd.Context(), conf.Path, vaultPath, vault)
+	if err != nil {
+		return err
+	}
+
+	if upgraded > 0 {
+		logger.PrintSuccessf("Upgraded %d passwords to the vault key format\n", upgraded)
+	}
+
+	return printRecoveryCode(cmd.OutOrStdout(), conf, vault, vaultPath)
+}
+
+func newConfigSetCmd(holder *ServiceHolder) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "Change an MSK setting (" + strings.Join(config.SettingKeys(), ", ") + ").",
+		Args:  cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.NewConfig(holder.ConfigPath)
+			if err != nil {
+				return err
+			}
+
+			if err := conf.SaveSetting(args[0], args[1]); err != nil {
+				return err
+			}
+
+			logger.PrintSuccessf("%s set to %s\n", args[0], args[1])
+			return nil
+		},
+	}
+}
@@ -10,30 +10,75 @@ import (
 )
 
 func NewDeleteCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		force  bool
+		backup bool
+	)
+
 	delCmd := &cobra.Command{
-		Use:     "del <name>",
+		Use:     "del <name>...",
 		Aliases: []string{"d"},
 		Short:   "Used to delete passwords from the vault.",
+		Args:    cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
-				return errors.New("password name is required")
+			if len(args) == 1 {
+				name := args[0]
+
+				if err := validator.ValidatePath(name); err != nil {
+					return fmt.Errorf("invalid password name: %w", err)
+				}
+
+				err := deleteSecret(holder, name, backup)
+				if err != nil {
+					return err
+				}
+
+				logger.PrintSuccess("Password deleted successfully\n")
+				return nil
+			}
+
+			if !force {
+				return errors.New("deleting several passwords at once requires --force")
 			}
 
-			name := args[0]
+			failed := 0
+			for _, name := range args {
+				err := validator.ValidatePath(name)
+				if err == nil {
+					err = deleteSecret(holder, name, backup)
+				}
 
-			if err := validator.Validate(name); err != nil {
-				return fmt.Errorf("invalid password name: %w", err)
+				if err != nil {
+					failed++
+					logger.PrintError("%s: %v\n", name, err)
+					continue
+				}
+
+				logger.PrintSuccessf("%s deleted\n", name)
 			}
 
-			err := holder.Service.DeleteSecret(name)
-			if err != nil {
-				return err
+			if failed > 0 {
+				return fmt.Errorf("%d of %d passwords could not be deleted", failed, len(args))
 			}
 
-			logger.PrintSuccess("Password deleted successfully")
 			return nil
 		},
 	}
 
+	delCmd.Flags().BoolVarP(&force, "force", "f", false, "Confirm deleting several passwords at once")
+	delCmd.Flags().BoolVar(&backup, "backup", false, "Keep an encrypted copy of each password, see 'msk restore'")
+
 	return delCmd
 }
+
+// deleteSecret deletes name, backing it up first when asked to. A failed
+// backup leaves the secret in place.
+func deleteSecret(holder *ServiceHolder, name string, backup bool) error {
+	if backup {
+		if err := holder.Service.BackupSecret(name); err != nil {
+			return fmt.Errorf("failed to back up secret: %w", err)
+		}
+	}
+
+	return holder.Service.DeleteSecret(name)
+}
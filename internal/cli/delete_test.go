@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// deleteService accepts every delete.
+type deleteService struct {
+	app.Service
+}
+
+func (deleteService) DeleteSecret(name string) error {
+	return nil
+}
+
+func TestDeleteCmdOutput(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		wantMsg string
+	}{
+		{name: "should end the message for one password with a newline", args: []string{"github"}, wantMsg: "Password deleted successfully\n"},
+		{name: "should end each message for several passwords with a newline", args: []string{"github", "gitlab", "--force"}, wantMsg: "github deleted\ngitlab deleted\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages bytes.Buffer
+			logger.SetOutput(&messages)
+			t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+			var out bytes.Buffer
+			cmd := NewDeleteCmd(&ServiceHolder{Service: deleteService{}})
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if messages.String() != tt.wantMsg {
+				t.Fatalf("expected messages %q, got %q", tt.wantMsg, messages.String())
+			}
+
+			if out.Len() != 0 {
+				t.Fatalf("expected nothing on stdout, got %q", out.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/jsonexport"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/session"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// errorCodes gives the sentinel errors a stable machine name for --json
+// output. The first match wins, so more specific errors come first.
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{app.ErrSecretNotFound, "ErrSecretNotFound"},
+	{storage.ErrNotFound, "ErrSecretNotFound"},
+	{app.ErrSecretExists, "ErrSecretExists"},
+	{app.ErrInvalidLoginField, "ErrInvalidLoginField"},
+	{storage.ErrInvalidSecret, "ErrInvalidSecret"},
+	{storage.ErrSecretTooLarge, "ErrSecretTooLarge"},
+	{storage.ErrStorageIO, "ErrStorageIO"},
+	{storage.ErrLoosePermissions, "ErrLoosePermissions"},
+	{config.ErrConfigNotFound, "ErrConfigNotFound"},
+	{config.ErrInvalidConfig, "ErrInvalidConfig"},
+	{config.ErrNotConfigFile, "ErrNotConfigFile"},
+	{config.ErrWrongMasterPassword, "ErrWrongMasterPassword"},
+	{config.ErrUnknownSetting, "ErrUnknownSetting"},
+	{config.ErrInvalidSetting, "ErrInvalidSetting"},
+	{config.ErrLooseModes, "ErrLooseModes"},
+	{config.ErrInvalidVaultPath, "ErrInvalidVaultPath"},
+	{config.ErrRecoveryNotFound, "ErrRecoveryNotFound"},
+	{config.ErrRecoveryOutdated, "ErrRecoveryOutdated"},
+	{config.ErrVaultKeyLost, "ErrVaultKeyLost"},
+	{format.ErrCorruptedFile, "ErrCorruptedFile"},
+	{format.ErrUnsupportedFileVersion, "ErrUnsupportedFileVersion"},
+	{format.ErrFieldTooLong, "ErrFieldTooLong"},
+	{format.ErrWeakRandom, "ErrWeakRandom"},
+	{jsonexport.ErrMissingVersion, "ErrMissingExportVersion"},
+	{jsonexport.ErrUnsupportedVersion, "ErrUnsupportedExportVersion"},
+	{vault.ErrDecryption, "ErrDecryption"},
+	{vault.ErrMKConfirmation, "ErrMKConfirmation"},
+	{vault.ErrMemoryLock, "ErrMemoryLock"},
+	{vault.ErrWrongRecoveryCode, "ErrWrongRecoveryCode"},
+	{vault.ErrNoVaultKey, "ErrNoVaultKey"},
+	{session.ErrSessionExpired, "ErrSessionExpired"},
+	{session.ErrSessionInvalid, "ErrSessionInvalid"},
+	{session.ErrSessionNotFound, "ErrSessionNotFound"},
+	{clip.ErrClipboardInit, "ErrClipboardInit"},
+	{clip.ErrClipboardDisabled, "ErrClipboardDisabled"},
+	{prompt.ErrEmptyInput, "ErrEmptyInput"},
+	{prompt.ErrInputTooLarge, "ErrInputTooLarge"},
+	{prompt.ErrConfirmationMatch, "ErrConfirmationMatch"},
+	{prompt.ErrNoPrompt, "ErrNoPrompt"},
+	{prompt.ErrNoMasterPasswordSource, "ErrNoMasterPasswordSource"},
+	{validator.ErrEmptyName, "ErrInvalidName"},
+	{validator.ErrNameTooLong, "ErrInvalidName"},
+	{validator.ErrInvalidCharacters, "ErrInvalidName"},
+	{validator.ErrPathSeparator, "ErrInvalidName"},
+	{validator.ErrReservedName, "ErrInvalidName"},
+	{validator.ErrControlCharacter, "ErrInvalidName"},
+	{validator.ErrWhitespace, "ErrInvalidName"},
+	{validator.ErrEmptySegment, "ErrInvalidName"},
+	{validator.ErrPathTraversal, "ErrInvalidName"},
+	{ErrPurgeNotForced, "ErrPurgeNotForced"},
+	{ErrPurgeNotConfirmed, "ErrPurgeNotConfirmed"},
+}
+
+// errorCode returns the machine name of the first known error in err's chain,
+// or "Error" when there is none.
+func errorCode(err error) string {
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			return known.code
+		}
+	}
+
+	return "Error"
+}
+
+// RenderError reports an error returned by the root command on its error
+// output: as a {"error","code"} object under --json, or as colored text.
+func RenderError(cmd *cobra.Command, err error) {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) && exitErr.Err == nil {
+		return
+	}
+
+	jsonOutput, _ := cmd.PersistentFlags().GetBool("json")
+	if !jsonOutput {
+		logger.PrintError("Error: %v\n", err)
+		return
+	}
+
+	enc := json.NewEncoder(cmd.ErrOrStderr())
+	enc.Encode(struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}{err.Error(), errorCode(err)})
+}
+
+// Exit codes other than the generic 1, for commands meant for scripting.
+const (
+	ExitNotFound    = 2
+	ExitInvalidName = 3
+)
+
+// ExitError makes the process exit with Code. A nil Err exits without
+// printing anything.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// ExitCode returns the process exit code for an error returned by the root
+// command.
+func ExitCode(err error) int {
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+
+	return 1
+}
@@ -0,0 +1,54 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/config"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "should name a wrapped sentinel", err: fmt.Errorf("failed to get password: %w", app.ErrSecretNotFound), want: "ErrSecretNotFound"},
+		{name: "should name a config error", err: config.ErrWrongMasterPassword, want: "ErrWrongMasterPassword"},
+		{name: "should fall back for unknown errors", err: errors.New("boom"), want: "Error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errorCode(tt.err); got != tt.want {
+				t.Fatalf("expected %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestRenderError(t *testing.T) {
+	t.Run("should write a JSON object under --json", func(t *testing.T) {
+		var stderr bytes.Buffer
+		cmd := NewMSKCmd()
+		cmd.SetErr(&stderr)
+		if err := cmd.PersistentFlags().Set("json", "true"); err != nil {
+			t.Fatalf("failed to set --json: %v", err)
+		}
+
+		RenderError(cmd, fmt.Errorf("failed to get password: %w", app.ErrSecretNotFound))
+
+		var got map[string]string
+		if err := json.Unmarshal(stderr.Bytes(), &got); err != nil {
+			t.Fatalf("expected JSON, got %q: %v", stderr.String(), err)
+		}
+
+		if got["code"] != "ErrSecretNotFound" || got["error"] != "failed to get password: secret not found" {
+			t.Fatalf("unexpected error object %v", got)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+func NewExistsCmd(holder *ServiceHolder) *cobra.Command {
+	var printResult bool
+
+	existsCmd := &cobra.Command{
+		Use:   "exists <name>",
+		Short: "Exit with 0 if a password exists and 2 if it does not.",
+		Long: `Exit with 0 if a password exists and 2 if it does not.
+
+Nothing is printed unless --print is given. Invalid names exit with 3. The
+password is not decrypted.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			// A missing password is an answer, not misuse.
+			cmd.SilenceUsage = true
+
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return &ExitError{Code: ExitInvalidName, Err: fmt.Errorf("invalid password name: %w", err)}
+			}
+
+			exists, err := holder.Service.SecretExists(name)
+			if err != nil {
+				return err
+			}
+
+			if printResult {
+				fmt.Fprintln(cmd.OutOrStdout(), exists)
+			}
+
+			if !exists {
+				return &ExitError{Code: ExitNotFound}
+			}
+
+			return nil
+		},
+	}
+
+	existsCmd.Flags().BoolVarP(&printResult, "print", "p", false, "Print true or false")
+
+	return existsCmd
+}
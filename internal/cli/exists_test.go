@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"slices"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+)
+
+// existsService answers SecretExists from a fixed list of names.
+type existsService struct {
+	app.Service
+	names []string
+}
+
+func (s existsService) SecretExists(name string) (bool, error) {
+	return slices.Contains(s.names, name), nil
+}
+
+func TestExistsCmd(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  string
+	}{
+		{name: "should exit 0 for an existing password", args: []string{"github"}, wantCode: 0},
+		{name: "should exit 2 for a missing password", args: []string{"gitlab"}, wantCode: ExitNotFound},
+		{name: "should exit 3 for an invalid name", args: []string{"../etc"}, wantCode: ExitInvalidName},
+		{name: "should print true with --print", args: []string{"github", "--print"}, wantCode: 0, wantOut: "true\n"},
+		{name: "should print false with --print", args: []string{"gitlab", "--print"}, wantCode: ExitNotFound, wantOut: "false\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder := &ServiceHolder{Service: existsService{names: []string{"github"}}}
+
+			var out bytes.Buffer
+			cmd := NewExistsCmd(holder)
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(tt.args)
+
+			err := cmd.Execute()
+
+			code := 0
+			if err != nil {
+				code = ExitCode(err)
+			}
+
+			if code != tt.wantCode {
+				t.Fatalf("expected exit code %d, got %d (%v)", tt.wantCode, code, err)
+			}
+
+			if out.String() != tt.wantOut {
+				t.Fatalf("expected output %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+}
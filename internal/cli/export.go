@@ -0,0 +1,103 @@
+package cli
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/jsonexport"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewExportCmd(holder *ServiceHolder, v vault.Vault) *cobra.Command {
+	var (
+		outPath   string
+		overwrite bool
+		parallel  int
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export every password as a plaintext JSON document.",
+		Long: `Export every password and its metadata as a plaintext JSON document, e.g.
+to move them to another vault or tool:
+
+  msk export --out passwords.json
+  msk import passwords.json
+
+The document has a version, so future formats can be told apart:
+
+  {"version": 1, "exported_at": "...", "secrets": [{"name": "github",
+   "username": "...", "url": "...", "password": "...", "notes": "...",
+   "tags": ["..."], "created_at": "...", "updated_at": "...",
+   "login_fields": ["username", "password"]}]}
+
+Empty fields are left out. A password that is not valid UTF-8, such as one
+added with --stdin-raw, is written as standard base64 in "password_b64"
+instead of "password". Passwords are stored as plain strings, so anyone who
+can read the file can read every password: keep it out of synced folders and
+delete it once you are done.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			logger.PrintError("Warning: the export holds every password in plain text\n")
+
+			if err := v.ConfirmMK(); err != nil {
+				return err
+			}
+
+			progress := logger.NewProgress("Decrypting")
+			holder.Service.OnProgress(progress.Update)
+
+			secrets, failures, err := holder.Service.GetAllSecrets(cmd.Context())
+			progress.Done()
+
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				for _, secret := range secrets {
+					wipe.Bytes(secret.Password)
+				}
+			}()
+
+			if len(failures) > 0 {
+				for _, failure := range failures {
+					logger.PrintError("%v\n", failure)
+				}
+
+				return fmt.Errorf("%d passwords could not be decrypted, nothing was exported", len(failures))
+			}
+
+			data, err := jsonexport.Marshal(secrets, time.Now())
+			if err != nil {
+				return err
+			}
+			defer wipe.Bytes(data)
+
+			if outPath == "" {
+				_, err := cmd.OutOrStdout().Write(data)
+				return err
+			}
+
+			if err := writeOutFile(outPath, data, "0600", overwrite, false); err != nil {
+				return err
+			}
+
+			logger.PrintSuccessf("Exported %d passwords to %s\n", len(secrets), outPath)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&outPath, "out", "o", "", "Write the export to this file, with mode 0600, instead of stdout")
+	exportCmd.Flags().BoolVar(&overwrite, "overwrite", false, "With --out, replace the file if it already exists")
+	registerParallel(exportCmd, &parallel)
+
+	return exportCmd
+}
@@ -0,0 +1,161 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewExportEnvCmd(holder *ServiceHolder, v vault.Vault) *cobra.Command {
+	var (
+		tag      string
+		parallel int
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export-env [name...]",
+		Short: "Print shell export statements for passwords, for use with eval.",
+		Long: `Print shell export statements for passwords, for use with eval:
+
+  eval "$(msk export-env github gitlab)"
+  eval "$(msk export-env --tag ci)"
+
+Names become variable names by uppercasing them and replacing every other
+character with an underscore, so "work/github-token" is WORK_GITHUB_TOKEN.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			names := args
+
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			if tag != "" {
+				tagged, err := namesWithTag(cmd.Context(), holder, tag)
+				if err != nil {
+					return err
+				}
+				names = append(names, tagged...)
+			}
+
+			if len(names) == 0 {
+				return errors.New("pass password names or --tag")
+			}
+
+			for _, name := range names {
+				if err := validator.ValidatePath(name); err != nil {
+					return fmt.Errorf("invalid password name %q: %w", name, err)
+				}
+			}
+
+			logger.PrintError("Warning: this prints passwords in plain text, only pipe it into eval\n")
+
+			if err := v.ConfirmMK(); err != nil {
+				return err
+			}
+
+			for _, name := range names {
+				if err := exportSecret(cmd.OutOrStdout(), holder, name); err != nil {
+					return err
+				}
+			}
+
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVarP(&tag, "tag", "t", "", "Export every password with this tag")
+	registerParallel(exportCmd, &parallel)
+
+	return exportCmd
+}
+
+func namesWithTag(ctx context.Context, holder *ServiceHolder, tag string) ([]string, error) {
+	all, err := holder.Service.GetSecretsRecursive()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list passwords: %w", err)
+	}
+
+	progress := logger.NewProgress("Decrypting")
+	holder.Service.OnProgress(progress.Update)
+
+	secrets, err := holder.Service.GetSecretsMetadata(ctx, all)
+	progress.Done()
+
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for i, secret := range secrets {
+		if slices.Contains(secret.Tags, tag) {
+			names = append(names, all[i])
+		}
+	}
+
+	return names, nil
+}
+
+// exportSecret writes one export line straight to out and wipes every buffer
+// that held the password.
+func exportSecret(out io.Writer, holder *ServiceHolder, name string) error {
+	password, err := holder.Service.GetSecret(name)
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", name, err)
+	}
+	defer wipe.Bytes(password)
+
+	// Reserve room for worst-case quoting so the buffer never reallocates and
+	// leaves an unwiped copy behind.
+	line := make([]byte, 0, len(name)+4*len(password)+16)
+	line = fmt.Appendf(line, "export %s=", envName(name))
+	line = appendShellQuoted(line, password)
+	line = append(line, '\n')
+	defer wipe.Bytes(line)
+
+	_, err = out.Write(line)
+	return err
+}
+
+// envName maps a secret name to a valid environment variable name.
+func envName(name string) string {
+	var b strings.Builder
+	for i, r := range strings.ToUpper(name) {
+		switch {
+		case r >= 'A' && r <= 'Z', r == '_':
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteByte('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteByte('_')
+		}
+	}
+
+	return b.String()
+}
+
+// appendShellQuoted single-quotes value for POSIX shells, closing and
+// reopening the quotes around embedded single quotes.
+func appendShellQuoted(dst, value []byte) []byte {
+	dst = append(dst, '\'')
+	for _, c := range value {
+		if c == '\'' {
+			dst = append(dst, `'\''`...)
+			continue
+		}
+		dst = append(dst, c)
+	}
+
+	return append(dst, '\'')
+}
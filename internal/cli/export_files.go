@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewExportFilesCmd(holder *ServiceHolder, v vault.Vault) *cobra.Command {
+	var (
+		dir       string
+		withMeta  bool
+		overwrite bool
+		parallel  int
+	)
+
+	exportCmd := &cobra.Command{
+		Use:   "export-files --dir <dir>",
+		Short: "Export every password as its own plaintext file.",
+		Long: `Export every password as its own plaintext file, e.g. for a one-time
+migration to a tool that imports one file per entry:
+
+  msk export-files --dir ./passwords
+
+Each password is written to <dir>/<name>.txt with mode 0600, and names with
+folders such as "work/github" get matching folders. With --with-meta the
+file starts with a header of the other fields, followed by a blank line:
+
+  username: octocat
+  url: https://github.com
+  tags: work, git
+  created: 2024-01-02T03:04:05Z
+
+  the password
+
+Anyone who can read the files can read every password: keep them out of
+synced folders and delete them once you are done.`,
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			if err := checkExportDir(dir, overwrite); err != nil {
+				return err
+			}
+
+			logger.PrintError("Warning: this writes every password to disk in plain text\n")
+
+			if err := v.ConfirmMK(); err != nil {
+				return err
+			}
+
+			progress := logger.NewProgress("Decrypting")
+			holder.Service.OnProgress(progress.Update)
+
+			secrets, failures, err := holder.Service.GetAllSecrets(cmd.Context())
+			progress.Done()
+
+			if err != nil {
+				return err
+			}
+
+			defer func() {
+				for _, secret := range secrets {
+					wipe.Bytes(secret.Password)
+				}
+			}()
+
+			if len(failures) > 0 {
+				for _, failure := range failures {
+					logger.PrintError("%v\n", failure)
+				}
+
+				return fmt.Errorf("%d passwords could not be decrypted, nothing was exported", len(failures))
+			}
+
+			for _, secret := range secrets {
+				err := writeExportFile(dir, secret, withMeta, overwrite)
+				wipe.Bytes(secret.Password)
+
+				if err != nil {
+					return fmt.Errorf("failed to export %s: %w", secret.Name, err)
+				}
+			}
+
+			logger.PrintSuccessf("Exported %d passwords to %s\n", len(secrets), dir)
+			return nil
+		},
+	}
+
+	exportCmd.Flags().StringVar(&dir, "dir", "", "Directory to write the files to, created if missing")
+	exportCmd.Flags().BoolVar(&withMeta, "with-meta", false, "Start each file with a header of the other fields")
+	exportCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Write into a non-empty directory, replacing files of the same name")
+	// The root command checks required flags before unlocking the vault.
+	_ = exportCmd.MarkFlagRequired("dir")
+	registerParallel(exportCmd, &parallel)
+
+	return exportCmd
+}
+
+// checkExportDir creates dir if it is missing and, unless overwrite is set,
+// refuses one that already holds anything, so an export never mixes with or
+// replaces other files by accident.
+func checkExportDir(dir string, overwrite bool) error {
+	if strings.TrimSpace(dir) == "" {
+		return errors.New("--dir must not be empty")
+	}
+
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return os.MkdirAll(dir, 0o700)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if len(entries) > 0 && !overwrite {
+		return fmt.Errorf("%s is not empty, use --overwrite to write into it", dir)
+	}
+
+	return nil
+}
+
+// writeExportFile writes secret to <dir>/<name>.txt with mode 0600.
+func writeExportFile(dir string, secret domain.Secret, withMeta, overwrite bool) error {
+	data := exportFileBody(secret, withMeta)
+	defer wipe.Bytes(data)
+
+	path := filepath.Join(dir, filepath.FromSlash(secret.Name)+".txt")
+	return writeOutFile(path, data, "0600", overwrite, true)
+}
+
+// exportFileBody returns the password followed by a newline, preceded by a
+// "key: value" header and a blank line when withMeta is set. Empty fields are
+// left out of the header, and continuation lines of multi-line notes are
+// indented. The caller must wipe the result.
+func exportFileBody(secret domain.Secret, withMeta bool) []byte {
+	var buf bytes.Buffer
+
+	if withMeta {
+		field := func(key, value string) {
+			if value != "" {
+				fmt.Fprintf(&buf, "%s: %s\n", key, strings.ReplaceAll(value, "\n", "\n  "))
+			}
+		}
+
+		field("username", secret.Username)
+		field("url", secret.URL)
+		field("notes", secret.Notes)
+		field("tags", strings.Join(secret.Tags, ", "))
+		field("login", strings.Join(secret.LoginFields, ", "))
+
+		if !secret.CreatedAt.IsZero() {
+			field("created", secret.CreatedAt.UTC().Format(time.RFC3339))
+		}
+
+		if !secret.UpdatedAt.IsZero() {
+			field("updated", secret.UpdatedAt.UTC().Format(time.RFC3339))
+		}
+
+		buf.WriteByte('\n')
+	}
+
+	// Grow once so the password is not left behind in a smaller buffer.
+	buf.Grow(len(secret.Password) + 1)
+	buf.Write(secret.Password)
+	buf.WriteByte('\n')
+
+	return buf.Bytes()
+}
@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+)
+
+func TestExportFileBody(t *testing.T) {
+	t.Run("should hold only the password without --with-meta", func(t *testing.T) {
+		body := exportFileBody(domain.Secret{Name: "github", Username: "octocat", Password: []byte("hunter2")}, false)
+
+		if string(body) != "hunter2\n" {
+			t.Fatalf("expected only the password, got %q", body)
+		}
+	})
+
+	t.Run("should start with a header of the set fields", func(t *testing.T) {
+		secret := domain.Secret{
+			Name:      "github",
+			Password:  []byte("hunter2"),
+			Username:  "octocat",
+			Notes:     "first\nsecond",
+			Tags:      []string{"work", "git"},
+			CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		}
+
+		want := "username: octocat\nnotes: first\n  second\ntags: work, git\ncreated: 2024-01-02T03:04:05Z\n\nhunter2\n"
+		if body := exportFileBody(secret, true); string(body) != want {
+			t.Fatalf("expected %q, got %q", want, body)
+		}
+	})
+}
+
+func TestCheckExportDir(t *testing.T) {
+	t.Run("should create a missing directory", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "out")
+
+		if err := checkExportDir(dir, false); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		info, err := os.Stat(dir)
+		if err != nil || !info.IsDir() {
+			t.Fatalf("expected %s to be created, got %v", dir, err)
+		}
+	})
+
+	t.Run("should refuse a non-empty directory without overwrite", func(t *testing.T) {
+		dir := t.TempDir()
+		if err := os.WriteFile(filepath.Join(dir, "other"), nil, 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+
+		if err := checkExportDir(dir, false); err == nil || !strings.Contains(err.Error(), "--overwrite") {
+			t.Fatalf("expected a hint to use --overwrite, got %v", err)
+		}
+
+		if err := checkExportDir(dir, true); err != nil {
+			t.Fatalf("expected overwrite to allow it, got %v", err)
+		}
+	})
+}
+
+func TestWriteExportFile(t *testing.T) {
+	t.Run("should write <name>.txt with mode 0600, creating folders", func(t *testing.T) {
+		dir := t.TempDir()
+
+		err := writeExportFile(dir, domain.Secret{Name: "work/github", Password: []byte("hunter2")}, false, false)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		path := filepath.Join(dir, "work", "github.txt")
+		data, err := os.ReadFile(path)
+		if err != nil || string(data) != "hunter2\n" {
+			t.Fatalf("expected the password in %s, got %q and %v", path, data, err)
+		}
+
+		info, err := os.Stat(path)
+		if err != nil || info.Mode().Perm() != 0o600 {
+			t.Fatalf("expected mode 0600, got %v and %v", info.Mode().Perm(), err)
+		}
+	})
+}
@@ -1,31 +1,71 @@
 package cli
 
 import (
+	"bufio"
+	"context"
 	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 
 	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/files"
 	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
 	"github.com/amauribechtoldjr/msk/internal/validator"
 	"github.com/amauribechtoldjr/msk/internal/wipe"
 	"github.com/spf13/cobra"
 )
 
 func NewGetCmd(holder *ServiceHolder) *cobra.Command {
-	var copyToClipboard bool
+	var (
+		copyToClipboard bool
+		noClipClear     bool
+		outPath         string
+		overwrite       bool
+		outMode         string
+		mkdir           bool
+		printPairs      bool
+		newline         bool
+	)
 
 	getCmd := &cobra.Command{
-		Use:     "get <name>",
+		Use:     "get <name>...",
 		Aliases: []string{"g"},
 		Short:   "Used to get passwords from the vault.",
+		Long: `Used to get passwords from the vault.
+
+Pass "-" as the name to read it from the first line of stdin, e.g.
+  msk list --plain | fzf | msk get -
+Since stdin is then taken, unlock a session first with 'msk unlock'.
+
+A single password is printed as is, without a trailing newline, so it can be
+captured exactly; pass --newline to end it with one. With several names,
+passwords are printed as name=value lines, or with --copy copied one after
+another, waiting for Enter in between.`,
+		Args: cobra.MinimumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
-				return errors.New("password name is required")
+			if len(args) > 1 || printPairs {
+				if outPath != "" {
+					return errors.New("--out only supports a single password name")
+				}
+
+				return getMany(cmd.Context(), cmd.OutOrStdout(), holder, args, copyToClipboard, noClipClear)
 			}
 
 			name := args[0]
 
-			if err := validator.Validate(name); err != nil {
+			if name == "-" {
+				var err error
+				if name, err = readNameFromStdin(); err != nil {
+					return err
+				}
+			}
+
+			if err := validator.ValidatePath(name); err != nil {
 				return fmt.Errorf("invalid password name: %w", err)
 			}
 
@@ -35,17 +75,22 @@ func NewGetCmd(holder *ServiceHolder) *cobra.Command {
 			}
 			defer wipe.Bytes(password)
 
-			if copyToClipboard {
-				err = clip.CopyText(password)
-				if err != nil {
-					return fmt.Errorf("failed to copy password to your clipboard: %w", err)
+			if outPath != "" {
+				if err := writeOutFile(outPath, password, outMode, overwrite, mkdir); err != nil {
+					return err
 				}
 
-				logger.PrintSuccess("Password copied to clipboard (press Ctrl+V to paste)\n\n")
+				logger.PrintSuccessf("Password written to %s\n", outPath)
+				return nil
+			}
 
-				clip.Clear()
-			} else {
-				fmt.Printf("%s\n", password)
+			if copyToClipboard {
+				return copyPassword(cmd.Context(), cmd.OutOrStdout(), password, "Password copied to clipboard (press Ctrl+V to paste)\n\n", noClipClear)
+			}
+
+			cmd.OutOrStdout().Write(password)
+			if newline {
+				fmt.Fprintln(cmd.OutOrStdout())
 			}
 
 			return nil
@@ -53,6 +98,145 @@ func NewGetCmd(holder *ServiceHolder) *cobra.Command {
 	}
 
 	getCmd.Flags().BoolVarP(&copyToClipboard, "copy", "c", false, "Copy password to clipboard instead of printing to stdout")
+	getCmd.Flags().BoolVar(&noClipClear, "no-clip-clear", false, "Keep the copied password on the clipboard instead of clearing it")
+	getCmd.Flags().BoolVarP(&printPairs, "print", "p", false, "Print each password as a name=value line")
+	getCmd.Flags().BoolVar(&newline, "newline", false, "End a printed password with a newline")
+	getCmd.Flags().StringVarP(&outPath, "out", "o", "", "Write the password, without a trailing newline, to this file")
+	getCmd.Flags().BoolVar(&overwrite, "overwrite", false, "With --out, replace the file if it already exists")
+	getCmd.Flags().StringVar(&outMode, "mode", "0600", "With --out, permissions of the written file")
+	getCmd.Flags().BoolVar(&mkdir, "mkdir", false, "With --out, create missing parent directories with mode 0700")
 
 	return getCmd
 }
+
+// getMany prints or copies several passwords, validating and fetching each
+// one independently so a bad name does not stop the rest.
+func getMany(ctx context.Context, out io.Writer, holder *ServiceHolder, names []string, copyToClipboard, noClipClear bool) error {
+	failed := 0
+	pendingClear := false
+
+	for _, name := range names {
+		if err := validator.ValidatePath(name); err != nil {
+			failed++
+			logger.PrintError("%s: invalid password name: %v\n", name, err)
+			continue
+		}
+
+		password, err := holder.Service.GetSecret(name)
+		if err != nil {
+			failed++
+			logger.PrintError("%s: failed to get password: %v\n", name, err)
+			continue
+		}
+
+		if !copyToClipboard {
+			fmt.Fprintf(out, "%s=%s\n", name, password)
+			wipe.Bytes(password)
+			continue
+		}
+
+		if pendingClear {
+			if _, err := prompt.ReadString("Press Enter for the next password..."); err != nil {
+				wipe.Bytes(password)
+				return err
+			}
+		}
+
+		copied, err := copyOrPrint(out, password, fmt.Sprintf("%s copied to clipboard\n", name))
+		wipe.Bytes(password)
+		if err != nil {
+			return err
+		}
+
+		// From the first copy on, every way out clears the clipboard. After
+		// the countdown below there is nothing left to clear.
+		if copied && !pendingClear && !noClipClear {
+			defer clearIfCopied()
+		}
+
+		pendingClear = pendingClear || copied
+	}
+
+	if pendingClear {
+		if noClipClear {
+			logger.PrintError("Warning: the clipboard will not be cleared automatically\n")
+		} else {
+			clip.Clear(ctx, clip.ClearDelay)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d passwords could not be read", failed, len(names))
+	}
+
+	return nil
+}
+
+func readNameFromStdin() (string, error) {
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("failed to read password name from stdin: %w", err)
+	}
+
+	name := strings.TrimSpace(line)
+	if name == "" {
+		return "", errors.New("password name is required")
+	}
+
+	return name, nil
+}
+
+// writeOutFile writes data to path with the given octal mode. An existing file
+// is only replaced when overwrite is set, and then atomically.
+func writeOutFile(path string, data []byte, mode string, overwrite, mkdir bool) error {
+	perm, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || perm > 0o777 {
+		return fmt.Errorf("invalid --mode %q, expected an octal mode such as 0600", mode)
+	}
+
+	if mkdir {
+		if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+			return fmt.Errorf("failed to create %s: %w", filepath.Dir(path), err)
+		}
+	}
+
+	if overwrite {
+		return files.WriteAtomicFile(path, data, os.FileMode(perm))
+	}
+
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, os.FileMode(perm))
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("%s already exists, use --overwrite to replace it", path)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// The file did not exist before, so a failed write removes it rather
+	// than leave a partial file that blocks the next attempt.
+	if err := file.Chmod(os.FileMode(perm)); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	if err := file.Sync(); err != nil {
+		file.Close()
+		os.Remove(path)
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		os.Remove(path)
+		return err
+	}
+
+	return nil
+}
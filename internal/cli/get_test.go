@@ -0,0 +1,117 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// getService returns passwords from a fixed map. GetSecret hands out a copy,
+// since callers wipe it.
+type getService struct {
+	app.Service
+	passwords map[string]string
+}
+
+func (s getService) GetSecret(name string) ([]byte, error) {
+	password, ok := s.passwords[name]
+	if !ok {
+		return nil, app.ErrSecretNotFound
+	}
+
+	return []byte(password), nil
+}
+
+func TestGetCmdOutput(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name    string
+		args    []string
+		wantOut string
+	}{
+		{name: "should print a single password without a newline", args: []string{"github"}, wantOut: "hunter2"},
+		{name: "should end a single password with --newline", args: []string{"github", "--newline"}, wantOut: "hunter2\n"},
+		{name: "should end each --print line with a newline", args: []string{"github", "--print"}, wantOut: "github=hunter2\n"},
+		{name: "should end each line for several names", args: []string{"github", "gitlab"}, wantOut: "github=hunter2\ngitlab=s3cret\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			holder := &ServiceHolder{Service: getService{passwords: map[string]string{
+				"github": "hunter2",
+				"gitlab": "s3cret",
+			}}}
+
+			var out bytes.Buffer
+			cmd := NewGetCmd(holder)
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(tt.args)
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if out.String() != tt.wantOut {
+				t.Fatalf("expected output %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+}
+
+func TestGetManyCopy(t *testing.T) {
+	t.Run("should clear the clipboard when the prompt for the next password fails", func(t *testing.T) {
+		logger.SetOutput(io.Discard)
+		t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+		var copies []string
+		cleared := 0
+		previousCopy, previousClear := copyText, clearIfCopied
+		copyText = func(text []byte) error {
+			copies = append(copies, string(text))
+			return nil
+		}
+		clearIfCopied = func() { cleared++ }
+		t.Cleanup(func() { copyText, clearIfCopied = previousCopy, previousClear })
+
+		// An empty stdin ends the "Press Enter" prompt with io.EOF.
+		in, err := os.Open(os.DevNull)
+		if err != nil {
+			t.Fatalf("failed to open %s: %v", os.DevNull, err)
+		}
+		t.Cleanup(func() { in.Close() })
+
+		original := os.Stdin
+		os.Stdin = in
+		t.Cleanup(func() { os.Stdin = original })
+
+		holder := &ServiceHolder{Service: getService{passwords: map[string]string{
+			"github": "hunter2",
+			"gitlab": "s3cret",
+		}}}
+
+		cmd := NewGetCmd(holder)
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"github", "gitlab", "--copy"})
+
+		if err := cmd.Execute(); !errors.Is(err, io.EOF) {
+			t.Fatalf("expected io.EOF, got %v", err)
+		}
+
+		if len(copies) != 1 || copies[0] != "hunter2" {
+			t.Fatalf("expected only the first password to be copied, got %q", copies)
+		}
+
+		if cleared != 1 {
+			t.Fatalf("expected the clipboard to be cleared once, got %d", cleared)
+		}
+	})
+}
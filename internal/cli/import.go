@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/jsonexport"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewImportCmd(holder *ServiceHolder) *cobra.Command {
+	var overwrite bool
+
+	importCmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import passwords from a JSON document written by 'msk export'.",
+		Long: `Import passwords from a JSON document written by 'msk export'.
+
+Documents of an unknown version are rejected as a whole. Secrets with
+invalid names, empty passwords, unknown login fields and, unless --overwrite
+is given, existing names are skipped and reported. --overwrite replaces only the password of an
+existing secret and keeps its stored metadata.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", args[0], err)
+			}
+			defer file.Close()
+
+			doc, err := jsonexport.Read(file)
+			if err != nil {
+				return err
+			}
+
+			imported := 0
+			for _, exported := range doc.Secrets {
+				secret, err := exported.Secret()
+				if err == nil {
+					err = importSecret(cmd.Context(), holder.Service, secret, overwrite)
+				}
+
+				if err != nil {
+					logger.PrintError("skipped %s: %v\n", exported.Name, err)
+					continue
+				}
+
+				imported++
+			}
+
+			logger.PrintSuccessf("Imported %d of %d passwords\n", imported, len(doc.Secrets))
+			return nil
+		},
+	}
+
+	importCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace passwords that already exist")
+
+	return importCmd
+}
+
+// importSecret adds one exported secret and wipes its password.
+func importSecret(ctx context.Context, service app.Service, secret domain.Secret, overwrite bool) error {
+	defer wipe.Bytes(secret.Password)
+
+	if err := validator.ValidatePath(secret.Name); err != nil {
+		return err
+	}
+
+	if len(secret.Password) == 0 {
+		return errors.New("empty password")
+	}
+
+	for _, field := range secret.LoginFields {
+		if !domain.IsLoginField(field) {
+			return fmt.Errorf("%w: %q, expected username, password, url or notes", app.ErrInvalidLoginField, field)
+		}
+	}
+
+	policy := app.OnConflictError
+	if overwrite {
+		policy = app.OnConflictOverwrite
+	}
+
+	_, err := service.AddSecretWithPolicy(ctx, secret, policy)
+	if errors.Is(err, app.ErrSecretExists) {
+		return errors.New("already exists, use --overwrite to replace it")
+	}
+
+	return err
+}
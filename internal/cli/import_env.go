@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/dotenv"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewImportEnvCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		overwrite bool
+		createdAt string
+	)
+
+	importCmd := &cobra.Command{
+		Use:   "import-env <file>",
+		Short: "Import KEY=VALUE pairs from a .env file as passwords.",
+		Long: `Import KEY=VALUE pairs from a .env file as passwords.
+
+Keys are lowercased to become password names, so DB_PASSWORD is stored as
+db_password. Keys that are not valid names, empty values and, unless
+--overwrite is given, existing passwords are skipped and reported.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			created, err := parseTimestamp(createdAt)
+			if err != nil {
+				return fmt.Errorf("invalid --created-at value: %w", err)
+			}
+
+			file, err := os.Open(args[0])
+			if err != nil {
+				return fmt.Errorf("failed to open %s: %w", args[0], err)
+			}
+			defer file.Close()
+
+			entries, err := dotenv.Parse(file)
+			if err != nil {
+				return err
+			}
+
+			imported := 0
+			for _, entry := range entries {
+				err := importEntry(cmd.Context(), holder.Service, entry, created, overwrite)
+				wipe.Bytes(entry.Value)
+
+				if err != nil {
+					logger.PrintError("skipped %s (line %d): %v\n", entry.Key, entry.Line, err)
+					continue
+				}
+
+				imported++
+			}
+
+			logger.PrintSuccessf("Imported %d of %d entries\n", imported, len(entries))
+			return nil
+		},
+	}
+
+	importCmd.Flags().BoolVar(&overwrite, "overwrite", false, "Replace passwords that already exist")
+	importCmd.Flags().StringVar(&createdAt, "created-at", "", "Creation time to record for new passwords (RFC 3339 or YYYY-MM-DD, defaults to now)")
+
+	return importCmd
+}
+
+func importEntry(ctx context.Context, service app.Service, entry dotenv.Entry, createdAt time.Time, overwrite bool) error {
+	name := strings.ToLower(entry.Key)
+
+	if err := validator.ValidatePath(name); err != nil {
+		return err
+	}
+
+	if len(entry.Value) == 0 {
+		return errors.New("empty value")
+	}
+
+	// The service wipes the password once stored, so hand it a copy.
+	password := bytes.Clone(entry.Value)
+	defer wipe.Bytes(password)
+
+	policy := app.OnConflictError
+	if overwrite {
+		policy = app.OnConflictOverwrite
+	}
+
+	_, err := service.AddSecretWithPolicy(ctx, domain.Secret{
+		Name:      name,
+		Password:  password,
+		CreatedAt: createdAt,
+	}, policy)
+	if errors.Is(err, app.ErrSecretExists) {
+		return errors.New("already exists, use --overwrite to replace it")
+	}
+
+	return err
+}
+
+// parseTimestamp accepts RFC 3339 timestamps or plain dates. An empty value
+// yields the zero time.
+func parseTimestamp(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t.UTC(), nil
+	}
+
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("expected RFC 3339 or YYYY-MM-DD, got %q", value)
+	}
+
+	return t.UTC(), nil
+}
@@ -0,0 +1,49 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+)
+
+type importService struct {
+	app.Service
+	added *[]string
+}
+
+func (s importService) AddSecretWithPolicy(ctx context.Context, secret domain.Secret, policy app.ConflictPolicy) (app.AddResult, error) {
+	*s.added = append(*s.added, secret.Name)
+	return app.AddResultAdded, nil
+}
+
+func TestImportSecret(t *testing.T) {
+	t.Run("should import a secret with a login order", func(t *testing.T) {
+		var added []string
+		secret := domain.Secret{Name: "github", Password: []byte("hunter2"), LoginFields: []string{"url", "username", "password"}}
+
+		if err := importSecret(context.Background(), importService{added: &added}, secret, false); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(added) != 1 {
+			t.Fatalf("expected the secret to be added, got %v", added)
+		}
+	})
+
+	t.Run("should reject an unknown login field", func(t *testing.T) {
+		var added []string
+		secret := domain.Secret{Name: "github", Password: []byte("hunter2"), LoginFields: []string{"username", "otp"}}
+
+		err := importSecret(context.Background(), importService{added: &added}, secret, false)
+		if !errors.Is(err, app.ErrInvalidLoginField) {
+			t.Fatalf("expected ErrInvalidLoginField, got %v", err)
+		}
+
+		if len(added) != 0 {
+			t.Fatalf("expected nothing to be added, got %v", added)
+		}
+	})
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+func TestInspectCmd(t *testing.T) {
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	run := func(t *testing.T, data []byte) (string, error) {
+		path := filepath.Join(t.TempDir(), "github.msk")
+		if err := os.WriteFile(path, data, 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+
+		var out bytes.Buffer
+		cmd := NewMSKCmd()
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"inspect", path})
+
+		err := cmd.Execute()
+		return out.String(), err
+	}
+
+	t.Run("should print the header without a master password", func(t *testing.T) {
+		salt := bytes.Repeat([]byte{0xAB}, meta.MSK_SALT_SIZE)
+		nonce := bytes.Repeat([]byte{0xCD}, meta.MSK_NONCE_SIZE)
+
+		data, err := format.MarshalFile(salt, nonce, make([]byte, 40))
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+
+		out, err := run(t, data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for _, want := range []string{"magic:      MSK", "version:    1", "salt:       abab", "nonce:      cdcd", "ciphertext: 40 bytes"} {
+			if !strings.Contains(out, want) {
+				t.Fatalf("expected output to contain %q, got:\n%s", want, out)
+			}
+		}
+	})
+
+	t.Run("should report an unsupported version with the raw header bytes", func(t *testing.T) {
+		data := make([]byte, meta.MSK_HEADER_SIZE+16)
+		copy(data, meta.MSK_MAGIC_VALUE)
+		data[meta.MSK_MAGIC_SIZE] = 9
+
+		out, err := run(t, data)
+		if !errors.Is(err, format.ErrUnsupportedFileVersion) {
+			t.Fatalf("expected ErrUnsupportedFileVersion, got %v", err)
+		}
+
+		if !strings.Contains(out, "version:    9") {
+			t.Fatalf("expected the raw version in the output, got:\n%s", out)
+		}
+	})
+}
@@ -2,18 +2,43 @@ package cli
 
 import (
 	"cmp"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
 
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/durationx"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
 	"github.com/spf13/cobra"
+	"golang.org/x/term"
 )
 
 func NewListCmd(holder *ServiceHolder) *cobra.Command {
 	var (
-		jsonOutput bool
-		sortOrder  string
+		sortOrder   string
+		recursive   bool
+		tree        bool
+		olderThan   string
+		newerThan   string
+		selectMode  bool
+		noClipClear bool
+		plain       bool
+		long        bool
+		reverse     bool
+		limit       int
+		offset      int
+		parallel    int
 	)
 
 	listCmd := &cobra.Command{
@@ -21,36 +46,343 @@ func NewListCmd(holder *ServiceHolder) *cobra.Command {
 		Aliases: []string{"l"},
 		Short:   "Used to list passwords from the vault.",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			secretNames, err := holder.Service.GetSecrets()
+			var (
+				secretNames []string
+				err         error
+			)
+
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			if recursive || tree || plain {
+				secretNames, err = holder.Service.GetSecretsRecursive()
+			} else {
+				secretNames, err = holder.Service.GetSecrets()
+			}
+
 			if err != nil {
 				return fmt.Errorf("failed to get password: %w", err)
 			}
 
-			if sortOrder != "" {
-				slices.SortFunc(secretNames, func(a, b string) int {
-					if sortOrder == "asc" {
-						return cmp.Compare(a, b)
-					}
-					return cmp.Compare(b, a)
-				})
+			// Age filters and timestamp sorting decrypt every entry; the
+			// decrypted names then replace the lowercase file names on display.
+			var metadata map[string]domain.Secret
+			if long || olderThan != "" || newerThan != "" || sortOrder == "created" || sortOrder == "updated" {
+				metadata, err = loadMetadata(cmd.Context(), holder.Service, secretNames)
+				if err != nil {
+					return err
+				}
+			}
+
+			if olderThan != "" || newerThan != "" {
+				secretNames, err = filterByAge(secretNames, metadata, olderThan, newerThan)
+				if err != nil {
+					return err
+				}
+			}
+
+			if plain && sortOrder == "" {
+				slices.Sort(secretNames)
+			}
+
+			if sortOrder != "" || reverse {
+				secretNames, err = sortSecrets(secretNames, metadata, sortOrder, reverse)
+				if err != nil {
+					return err
+				}
+			}
+
+			if limit > 0 || offset > 0 {
+				total := len(secretNames)
+				secretNames, err = window(secretNames, offset, limit)
+				if err != nil {
+					return err
+				}
+
+				defer printWindowFooter(offset, len(secretNames), total)
+			}
+
+			if long && !plain && !selectMode && !holder.JSON && !tree {
+				printLong(cmd.OutOrStdout(), holder, secretNames, metadata)
+				return nil
 			}
 
-			if jsonOutput {
-				enc := json.NewEncoder(os.Stdout)
+			if metadata != nil {
+				secretNames = displayNames(secretNames, metadata)
+			}
+
+			if plain {
+				for _, name := range secretNames {
+					fmt.Fprintln(cmd.OutOrStdout(), name)
+				}
+
+				return nil
+			}
+
+			if selectMode && term.IsTerminal(int(os.Stdin.Fd())) {
+				return selectAndCopy(cmd.Context(), cmd.OutOrStdout(), holder.Service, secretNames, noClipClear)
+			}
+
+			if holder.JSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
 				enc.SetIndent("", "  ")
 				return enc.Encode(secretNames)
 			}
 
+			if tree {
+				printTree(cmd.OutOrStdout(), secretNames)
+				return nil
+			}
+
 			for _, name := range secretNames {
-				fmt.Println(name)
+				fmt.Fprintln(cmd.OutOrStdout(), name)
 			}
 
 			return nil
 		},
 	}
 
-	listCmd.Flags().BoolVarP(&jsonOutput, "json", "j", false, "Output in JSON format")
-	listCmd.Flags().StringVarP(&sortOrder, "sort", "s", "", "Sort secrets by name (asc or desc)")
+	listCmd.Flags().StringVarP(&sortOrder, "sort", "s", "", "Sort secrets by name, created or updated (created and updated decrypt every entry)")
+	listCmd.Flags().BoolVar(&reverse, "reverse", false, "Reverse the listing order")
+	listCmd.Flags().IntVar(&limit, "limit", 0, "Show at most this many secrets (0 for no limit)")
+	listCmd.Flags().IntVar(&offset, "offset", 0, "Skip this many secrets before showing results")
+	listCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "Include secrets stored in folders")
+	listCmd.Flags().BoolVar(&tree, "tree", false, "Show secrets grouped by folder (implies --recursive)")
+	listCmd.Flags().StringVar(&olderThan, "older-than", "", "Only show secrets last changed longer ago than this (e.g. 90d, 2w, 12h)")
+	listCmd.Flags().StringVar(&newerThan, "newer-than", "", "Only show secrets last changed within this duration (e.g. 7d, 36h)")
+	listCmd.Flags().BoolVar(&plain, "plain", false, "Print every secret name, including folders, one per line and sorted (for piping into tools like fzf)")
+	listCmd.Flags().BoolVarP(&long, "long", "l", false, "Show when each secret was created and last updated (decrypts every entry)")
+	listCmd.Flags().BoolVar(&selectMode, "select", false, "Pick a secret by number and copy its password (plain list when stdin is not a terminal)")
+	listCmd.Flags().BoolVar(&noClipClear, "no-clip-clear", false, "With --select, keep the copied password on the clipboard instead of clearing it")
+	registerParallel(listCmd, &parallel)
 
 	return listCmd
 }
+
+// selectAndCopy prints a numbered list on stderr, reads the chosen number and
+// copies that secret's password, like "get --copy" would.
+func selectAndCopy(ctx context.Context, out io.Writer, service app.Service, names []string, noClipClear bool) error {
+	if len(names) == 0 {
+		return errors.New("no passwords to select from")
+	}
+
+	for i, name := range names {
+		logger.PrintInfo(fmt.Sprintf("%3d) %s\n", i+1, name))
+	}
+
+	answer, err := prompt.ReadString(fmt.Sprintf("Select a password [1-%d]: ", len(names)))
+	if err != nil {
+		return err
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(names) {
+		return fmt.Errorf("invalid selection %q", strings.TrimSpace(answer))
+	}
+
+	password, err := service.GetSecret(names[choice-1])
+	if err != nil {
+		return fmt.Errorf("failed to get password: %w", err)
+	}
+	defer wipe.Bytes(password)
+
+	return copyPassword(ctx, out, password, "Password copied to clipboard (press Ctrl+V to paste)\n\n", noClipClear)
+}
+
+// loadMetadata decrypts the named secrets and indexes them by name.
+func loadMetadata(ctx context.Context, service app.Service, names []string) (map[string]domain.Secret, error) {
+	progress := logger.NewProgress("Decrypting")
+	service.OnProgress(progress.Update)
+
+	secrets, err := service.GetSecretsMetadata(ctx, names)
+	progress.Done()
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %w", err)
+	}
+
+	metadata := make(map[string]domain.Secret, len(names))
+	for i, secret := range secrets {
+		metadata[names[i]] = secret
+	}
+
+	return metadata, nil
+}
+
+// displayNames swaps file names for the names as they were typed, which are
+// kept inside each decrypted secret.
+func displayNames(names []string, metadata map[string]domain.Secret) []string {
+	display := make([]string, len(names))
+	for i, name := range names {
+		display[i] = name
+		if secret, ok := metadata[name]; ok && secret.Name != "" {
+			display[i] = secret.Name
+		}
+	}
+
+	return display
+}
+
+// sortSecrets orders names by the given key. Name sorting is case-insensitive;
+// created and updated use the decrypted metadata, and secrets without a known
+// timestamp sort first. The legacy "asc" and "desc" values sort by name.
+// Sorting is stable, so ties keep directory order.
+func sortSecrets(names []string, metadata map[string]domain.Secret, sortBy string, reverse bool) ([]string, error) {
+	if sortBy == "desc" {
+		sortBy = "name"
+		reverse = !reverse
+	}
+
+	switch sortBy {
+	case "":
+	case "name", "asc":
+		slices.SortStableFunc(names, func(a, b string) int {
+			return cmp.Compare(strings.ToLower(a), strings.ToLower(b))
+		})
+	case "created":
+		slices.SortStableFunc(names, func(a, b string) int {
+			return metadata[a].CreatedAt.Compare(metadata[b].CreatedAt)
+		})
+	case "updated":
+		slices.SortStableFunc(names, func(a, b string) int {
+			return metadata[a].UpdatedAt.Compare(metadata[b].UpdatedAt)
+		})
+	default:
+		return nil, fmt.Errorf("invalid --sort value %q, expected name, created or updated", sortBy)
+	}
+
+	if reverse {
+		slices.Reverse(names)
+	}
+
+	return names, nil
+}
+
+// window returns at most limit names starting at offset. A zero limit keeps
+// everything after offset.
+func window(names []string, offset, limit int) ([]string, error) {
+	if offset < 0 || limit < 0 {
+		return nil, errors.New("--limit and --offset must not be negative")
+	}
+
+	if offset >= len(names) {
+		return []string{}, nil
+	}
+
+	names = names[offset:]
+	if limit > 0 && limit < len(names) {
+		names = names[:limit]
+	}
+
+	return names, nil
+}
+
+// printWindowFooter reports the shown range on stderr so piped output stays
+// parseable.
+func printWindowFooter(offset, shown, total int) {
+	if shown == 0 {
+		logger.PrintInfo(fmt.Sprintf("showing 0 of %d\n", total))
+		return
+	}
+
+	logger.PrintInfo(fmt.Sprintf("showing %d-%d of %d\n", offset+1, offset+shown, total))
+}
+
+// filterByAge keeps the secrets whose last change (update, or creation when
+// never updated) falls within the requested bounds. Secrets written before
+// timestamps were tracked have no known age and are left out.
+func filterByAge(names []string, metadata map[string]domain.Secret, olderThan, newerThan string) ([]string, error) {
+	var minAge, maxAge time.Duration
+	var err error
+
+	if olderThan != "" {
+		if minAge, err = durationx.Parse(olderThan); err != nil {
+			return nil, fmt.Errorf("invalid --older-than value: %w", err)
+		}
+	}
+
+	if newerThan != "" {
+		if maxAge, err = durationx.Parse(newerThan); err != nil {
+			return nil, fmt.Errorf("invalid --newer-than value: %w", err)
+		}
+	}
+
+	now := time.Now()
+	filtered := []string{}
+
+	for _, name := range names {
+		secret := metadata[name]
+		changed := secret.UpdatedAt
+		if changed.IsZero() {
+			changed = secret.CreatedAt
+		}
+
+		if changed.IsZero() {
+			continue
+		}
+
+		age := now.Sub(changed)
+
+		if olderThan != "" && age < minAge {
+			continue
+		}
+
+		if newerThan != "" && age > maxAge {
+			continue
+		}
+
+		filtered = append(filtered, name)
+	}
+
+	return filtered, nil
+}
+
+// printLong prints one secret per line with its creation and update times,
+// in columns. Secrets that were never updated show "-".
+func printLong(out io.Writer, holder *ServiceHolder, names []string, metadata map[string]domain.Secret) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tCREATED\tUPDATED")
+
+	for _, name := range names {
+		secret := metadata[name]
+
+		display := name
+		if secret.Name != "" {
+			display = secret.Name
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\n", display, holder.formatTime(secret.CreatedAt), holder.formatTime(secret.UpdatedAt))
+	}
+
+	w.Flush()
+}
+
+// printTree renders folder-aware names as an indented tree, printing each
+// folder once before the secrets it contains.
+func printTree(out io.Writer, names []string) {
+	paths := make([][]string, len(names))
+	for i, name := range names {
+		paths[i] = strings.Split(name, "/")
+	}
+
+	slices.SortFunc(paths, slices.Compare)
+
+	var previous []string
+	for _, path := range paths {
+		folders := path[:len(path)-1]
+
+		shared := 0
+		for shared < len(folders) && shared < len(previous) && folders[shared] == previous[shared] {
+			shared++
+		}
+
+		for depth := shared; depth < len(folders); depth++ {
+			fmt.Fprintf(out, "%s%s/\n", strings.Repeat("  ", depth), folders[depth])
+		}
+
+		fmt.Fprintf(out, "%s%s\n", strings.Repeat("  ", len(folders)), path[len(path)-1])
+
+		previous = folders
+	}
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+)
+
+func TestPrintTree(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+		want  string
+	}{
+		{
+			name:  "should print flat names in order",
+			names: []string{"gitlab", "github"},
+			want:  "github\ngitlab\n",
+		},
+		{
+			name:  "should print each folder once before its secrets",
+			names: []string{"work/github", "personal", "work/aws/prod", "work/aws/dev"},
+			want:  "personal\nwork/\n  aws/\n    dev\n    prod\n  github\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printTree(&out, tt.names)
+
+			if out.String() != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, out.String())
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	names := []string{"a", "b", "c", "d"}
+
+	tests := []struct {
+		name          string
+		offset, limit int
+		want          []string
+	}{
+		{name: "should keep everything without limit or offset", want: names},
+		{name: "should apply the limit", limit: 2, want: []string{"a", "b"}},
+		{name: "should skip the offset", offset: 3, want: []string{"d"}},
+		{name: "should return nothing past the end", offset: 9, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := window(append([]string(nil), names...), tt.offset, tt.limit)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("should reject a negative offset", func(t *testing.T) {
+		if _, err := window(names, -1, 0); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestPrintLong(t *testing.T) {
+	t.Run("should print times in columns and - for unknown ones", func(t *testing.T) {
+		holder := &ServiceHolder{UTC: true, TimeFormat: time.DateOnly}
+		metadata := map[string]domain.Secret{
+			"github": {Name: "GitHub", CreatedAt: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)},
+		}
+
+		var out bytes.Buffer
+		printLong(&out, holder, []string{"github", "legacy"}, metadata)
+
+		want := "NAME    CREATED     UPDATED\n" +
+			"GitHub  2024-03-01  -\n" +
+			"legacy  -           -\n"
+		if out.String() != want {
+			t.Fatalf("expected %q, got %q", want, out.String())
+		}
+	})
+}
@@ -21,7 +21,7 @@ func NewLockCmd() *cobra.Command {
 				return fmt.Errorf("failed to destroy session: %w", err)
 			}
 
-			fmt.Println("unset MSK_SESSION")
+			fmt.Fprintln(cmd.OutOrStdout(), "unset MSK_SESSION")
 			return nil
 		},
 	}
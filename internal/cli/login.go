@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+// loginLabels name the login fields in messages.
+var loginLabels = map[string]string{
+	domain.LoginFieldUsername: "Username",
+	domain.LoginFieldPassword: "Password",
+	domain.LoginFieldURL:      "URL",
+	domain.LoginFieldNotes:    "Notes",
+}
+
+func NewLoginCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		noClipClear bool
+		setOrder    string
+	)
+
+	loginCmd := &cobra.Command{
+		Use:   "login <name>",
+		Short: "Copy a username, then its password, to the clipboard.",
+		Long: `Copy a username, then its password, to the clipboard.
+
+Each field is copied in turn; press Enter once it is pasted to copy the
+next one. The last field is cleared like "get --copy" would. Fields that are
+not set on the secret are skipped.
+
+Sites that want another order, or the URL or notes as well, can have one
+stored per secret:
+
+  msk login github --set-order url,username,password
+
+The fields are username, password, url and notes. An empty --set-order
+restores the default of username, then password.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return fmt.Errorf("invalid password name: %w", err)
+			}
+
+			if cmd.Flags().Changed("set-order") {
+				return setLoginOrder(holder, name, setOrder)
+			}
+
+			secret, err := holder.Service.GetSecretWithMeta(name)
+			if err != nil {
+				return fmt.Errorf("failed to get password: %w", err)
+			}
+			defer wipe.Bytes(secret.Password)
+
+			var steps []string
+			for _, field := range secret.LoginOrder() {
+				if len(secret.LoginValue(field)) > 0 {
+					steps = append(steps, field)
+				}
+			}
+
+			if len(steps) == 0 {
+				return fmt.Errorf("%s has none of the login fields %s set", name, strings.Join(secret.LoginOrder(), ", "))
+			}
+
+			last := len(steps) - 1
+			for i, field := range steps[:last] {
+				copied, err := copyOrPrint(cmd.OutOrStdout(), secret.LoginValue(field), loginLabels[field]+" copied to clipboard\n")
+				if err != nil {
+					return err
+				}
+
+				if copied {
+					if _, err := prompt.ReadString(fmt.Sprintf("Press Enter for the %s...", steps[i+1])); err != nil {
+						return err
+					}
+				}
+			}
+
+			return copyPassword(cmd.Context(), cmd.OutOrStdout(), secret.LoginValue(steps[last]), loginLabels[steps[last]]+" copied to clipboard (press Ctrl+V to paste)\n\n", noClipClear)
+		},
+	}
+
+	loginCmd.Flags().BoolVar(&noClipClear, "no-clip-clear", false, "Keep the last copied field on the clipboard instead of clearing it")
+	loginCmd.Flags().StringVar(&setOrder, "set-order", "", "Store the fields to copy, comma-separated (e.g. url,username,password), instead of copying")
+
+	return loginCmd
+}
+
+// setLoginOrder stores a comma-separated login order on the secret.
+func setLoginOrder(holder *ServiceHolder, name, order string) error {
+	var fields []string
+	for field := range strings.SplitSeq(order, ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			fields = append(fields, strings.ToLower(field))
+		}
+	}
+
+	changed, err := holder.Service.SetLoginFields(name, fields)
+	if err != nil {
+		return fmt.Errorf("failed to set the login order: %w", err)
+	}
+
+	if !changed {
+		logger.PrintInfo(fmt.Sprintf("%s login order unchanged\n", name))
+		return nil
+	}
+
+	if len(fields) == 0 {
+		fields = domain.DefaultLoginFields
+	}
+
+	logger.PrintSuccessf("%s login order set to %s\n", name, strings.Join(fields, ", "))
+	return nil
+}
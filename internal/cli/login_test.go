@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// loginService serves one secret and records the login order set on it.
+type loginService struct {
+	app.Service
+	secret *domain.Secret
+}
+
+func (s loginService) GetSecretWithMeta(name string) (domain.Secret, error) {
+	if name != s.secret.Name {
+		return domain.Secret{}, app.ErrSecretNotFound
+	}
+
+	secret := *s.secret
+	secret.Password = bytes.Clone(s.secret.Password)
+	return secret, nil
+}
+
+func (s loginService) SetLoginFields(name string, fields []string) (bool, error) {
+	if name != s.secret.Name {
+		return false, app.ErrSecretNotFound
+	}
+
+	changed := !reflect.DeepEqual(s.secret.LoginFields, fields)
+	s.secret.LoginFields = fields
+	return changed, nil
+}
+
+func TestLoginCmd(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	clip.SetDisabled(true)
+	t.Cleanup(func() {
+		logger.SetOutput(os.Stderr)
+		clip.SetDisabled(false)
+	})
+
+	tests := []struct {
+		name    string
+		secret  domain.Secret
+		wantOut string
+	}{
+		{
+			name:    "should give the username, then the password by default",
+			secret:  domain.Secret{Name: "github", Username: "octocat", URL: "https://github.com", Password: []byte("hunter2")},
+			wantOut: "octocat\nhunter2\n",
+		},
+		{
+			name:    "should skip fields that are not set",
+			secret:  domain.Secret{Name: "github", Password: []byte("hunter2")},
+			wantOut: "hunter2\n",
+		},
+		{
+			name: "should follow the stored order",
+			secret: domain.Secret{
+				Name:        "github",
+				Username:    "octocat",
+				URL:         "https://github.com",
+				Password:    []byte("hunter2"),
+				LoginFields: []string{"url", "password", "username"},
+			},
+			wantOut: "https://github.com\nhunter2\noctocat\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			cmd := NewLoginCmd(&ServiceHolder{Service: loginService{secret: &tt.secret}})
+			cmd.SetOut(&out)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"github", "--no-clip-clear"})
+
+			if err := cmd.Execute(); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if out.String() != tt.wantOut {
+				t.Fatalf("expected %q, got %q", tt.wantOut, out.String())
+			}
+		})
+	}
+
+	t.Run("should store a trimmed, lowercased order with --set-order", func(t *testing.T) {
+		secret := domain.Secret{Name: "github", Password: []byte("hunter2")}
+
+		cmd := NewLoginCmd(&ServiceHolder{Service: loginService{secret: &secret}})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"github", "--set-order", " URL, username ,password,"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		want := []string{"url", "username", "password"}
+		if !reflect.DeepEqual(secret.LoginFields, want) {
+			t.Fatalf("expected %v, got %v", want, secret.LoginFields)
+		}
+	})
+}
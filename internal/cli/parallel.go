@@ -0,0 +1,23 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/spf13/cobra"
+)
+
+// registerParallel adds --parallel to a command that decrypts many secrets.
+func registerParallel(cmd *cobra.Command, n *int) {
+	cmd.Flags().IntVar(n, "parallel", app.DefaultParallel(), fmt.Sprintf("Number of passwords to process at once, up to %d", app.MAX_PARALLEL))
+}
+
+// setParallel validates --parallel and applies it to the service.
+func setParallel(holder *ServiceHolder, n int) error {
+	if n < 1 || n > app.MAX_PARALLEL {
+		return fmt.Errorf("invalid --parallel value %d, expected 1 to %d", n, app.MAX_PARALLEL)
+	}
+
+	holder.Service.SetParallel(n)
+	return nil
+}
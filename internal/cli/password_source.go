@@ -0,0 +1,157 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/generator"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+// passwordSource holds the flags shared by commands that prompt for a
+// password, read it from stdin or the clipboard, or generate one.
+type passwordSource struct {
+	stdinRaw      bool
+	fromClipboard bool
+	trim          bool
+	generate      bool
+	length        int
+	noSymbols     bool
+	symbolSet     string
+	alphabet      string
+	showStrength  bool
+}
+
+func (p *passwordSource) register(cmd *cobra.Command) {
+	cmd.Flags().BoolVar(&p.stdinRaw, "stdin-raw", false, "Read the password verbatim from stdin, newlines included, e.g. a PEM key (unlock a session first)")
+	cmd.Flags().BoolVar(&p.fromClipboard, "from-clipboard", false, "Use the text on the clipboard as the password and clear the clipboard once stored")
+	cmd.Flags().BoolVar(&p.trim, "trim", false, "With --stdin-raw or --from-clipboard, strip trailing whitespace and newlines from the password")
+	cmd.Flags().BoolVarP(&p.generate, "generate", "g", false, "Generate a random password instead of prompting")
+	cmd.Flags().IntVarP(&p.length, "length", "l", generator.DefaultLength, "Length of the generated password")
+	cmd.Flags().BoolVar(&p.noSymbols, "no-symbols", false, "Exclude symbols from the generated password")
+	cmd.Flags().StringVar(&p.symbolSet, "symbols", "", "Symbols to use in the generated password instead of the default set")
+	cmd.Flags().StringVar(&p.alphabet, "alphabet", "", "Characters to generate the password from, replacing letters, digits and symbols")
+	cmd.Flags().BoolVar(&p.showStrength, "show-strength", false, "Print the estimated entropy of the generated password to stderr")
+}
+
+// password generates a password or prompts for one. The caller must wipe it.
+func (p *passwordSource) password() ([]byte, error) {
+	if p.trim && !p.stdinRaw && !p.fromClipboard {
+		return nil, errors.New("--trim only applies to --stdin-raw and --from-clipboard")
+	}
+
+	if p.showStrength && !p.generate {
+		return nil, errors.New("--show-strength only applies to --generate")
+	}
+
+	if p.fromClipboard {
+		if p.stdinRaw || p.generate {
+			return nil, errors.New("--from-clipboard cannot be used with --stdin-raw or --generate")
+		}
+
+		return readClipboardPassword(p.trim)
+	}
+
+	if p.stdinRaw {
+		if p.generate {
+			return nil, errors.New("--stdin-raw and --generate cannot be used together")
+		}
+
+		password, err := prompt.ReadRaw(meta.SECRET_MAX_FIELD_LENGTH)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read password from stdin: %w", err)
+		}
+
+		return trimPassword(password, p.trim)
+	}
+
+	if !p.generate {
+		return prompt.ReadSafeValue("Enter password:")
+	}
+
+	opts := generator.Options{
+		Length:    p.length,
+		NoSymbols: p.noSymbols,
+		Symbols:   p.symbolSet,
+		Alphabet:  p.alphabet,
+	}
+
+	charset, err := opts.Charset()
+	if err != nil {
+		return nil, err
+	}
+
+	password, err := generator.Generate(opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	if p.showStrength {
+		logger.PrintInfo(fmt.Sprintf("Estimated strength: %.0f bits of entropy (%d characters from a set of %d)\n",
+			generator.Entropy(len(password), charset), len(password), len(charset)))
+	}
+
+	return password, nil
+}
+
+// stored clears the clipboard after a password taken from it was saved, so
+// it does not linger there.
+func (p *passwordSource) stored() {
+	if !p.fromClipboard {
+		return
+	}
+
+	if err := clip.Empty(); err != nil {
+		logger.PrintError("Warning: failed to clear the clipboard: %v\n", err)
+	}
+}
+
+// readClipboardPassword reads the password from the clipboard, rejecting an
+// empty or oversized value. The caller must wipe it.
+func readClipboardPassword(trim bool) ([]byte, error) {
+	password, err := clip.ReadText()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(password) == 0 {
+		return nil, errors.New("the clipboard holds no text")
+	}
+
+	if len(password) > meta.SECRET_MAX_FIELD_LENGTH {
+		wipe.Bytes(password)
+		return nil, fmt.Errorf("clipboard text: %w", prompt.ErrInputTooLarge)
+	}
+
+	return trimPassword(password, trim)
+}
+
+// trimPassword strips trailing whitespace when trim is set. Otherwise it keeps
+// the value as is but warns about trailing whitespace, a common leftover of
+// pasting that later fails logins. The result shares the input buffer, so
+// wiping it wipes every byte that is not whitespace.
+func trimPassword(password []byte, trim bool) ([]byte, error) {
+	trimmed := bytes.TrimRight(password, " \t\r\n")
+
+	if len(trimmed) == len(password) {
+		return password, nil
+	}
+
+	if !trim {
+		logger.PrintError("Warning: the password ends with whitespace or a newline, pass --trim to strip it\n")
+		return password, nil
+	}
+
+	if len(trimmed) == 0 {
+		wipe.Bytes(password)
+		return nil, prompt.ErrEmptyInput
+	}
+
+	return trimmed, nil
+}
@@ -0,0 +1,70 @@
+package cli
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+)
+
+func TestTrimPassword(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name     string
+		password string
+		trim     bool
+		want     string
+	}{
+		{name: "should keep trailing whitespace without --trim", password: "s3cret \n", want: "s3cret \n"},
+		{name: "should strip trailing whitespace and newlines with --trim", password: "s3cret \r\n", trim: true, want: "s3cret"},
+		{name: "should keep leading whitespace", password: "  s3cret\n", trim: true, want: "  s3cret"},
+		{name: "should keep a multi-line value intact inside", password: "line1\nline2\n", trim: true, want: "line1\nline2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := trimPassword([]byte(tt.password), tt.trim)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if string(got) != tt.want {
+				t.Fatalf("expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+
+	t.Run("should reject a value that is only whitespace", func(t *testing.T) {
+		_, err := trimPassword([]byte(" \n"), true)
+		if !errors.Is(err, prompt.ErrEmptyInput) {
+			t.Fatalf("expected ErrEmptyInput, got %v", err)
+		}
+	})
+}
+
+func TestPasswordSourceConflicts(t *testing.T) {
+	tests := []struct {
+		name   string
+		source passwordSource
+	}{
+		{name: "should reject --trim without a raw source", source: passwordSource{trim: true}},
+		{name: "should reject --stdin-raw with --generate", source: passwordSource{stdinRaw: true, generate: true}},
+		{name: "should reject --from-clipboard with --stdin-raw", source: passwordSource{fromClipboard: true, stdinRaw: true}},
+		{name: "should reject --from-clipboard with --generate", source: passwordSource{fromClipboard: true, generate: true}},
+		{name: "should reject --show-strength without --generate", source: passwordSource{showStrength: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			password, err := tt.source.password()
+			if err == nil {
+				t.Fatalf("expected an error, got password %q", password)
+			}
+		})
+	}
+}
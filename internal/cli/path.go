@@ -0,0 +1,35 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+func NewPathCmd(holder *ServiceHolder) *cobra.Command {
+	return &cobra.Command{
+		Use:   "path <name>",
+		Short: "Print the absolute path of a password's file without decrypting it.",
+		Long: `Print the absolute path of a password's file without decrypting it.
+
+Useful for scripting backups, e.g.
+  cp "$(msk path github)" /backup/`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return fmt.Errorf("invalid password name: %w", err)
+			}
+
+			path, err := holder.Service.SecretPath(name)
+			if err != nil {
+				return err
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), path)
+			return nil
+		},
+	}
+}
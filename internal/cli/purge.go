@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/files"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/session"
+	"github.com/awnumar/memguard"
+	"github.com/spf13/cobra"
+)
+
+var (
+	ErrPurgeNotForced    = errors.New("purge deletes the whole vault and config, run it again with --force")
+	ErrPurgeNotConfirmed = errors.New("vault path did not match, nothing was deleted")
+)
+
+func NewPurgeCmd(holder *ServiceHolder) *cobra.Command {
+	var force bool
+
+	purgeCmd := &cobra.Command{
+		Use:   "purge",
+		Short: "Securely delete every secret, the config and the session.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !force {
+				return ErrPurgeNotForced
+			}
+
+			vaultPath := holder.Service.VaultPath()
+
+			typed, err := prompt.ReadString(fmt.Sprintf("This permanently deletes every secret in %s.\nType the vault path to confirm: ", vaultPath))
+			if err != nil {
+				return err
+			}
+
+			if strings.TrimSpace(typed) != vaultPath {
+				return ErrPurgeNotConfirmed
+			}
+
+			failed, err := holder.Service.Purge()
+			if err != nil {
+				return err
+			}
+
+			conf, err := config.NewConfig(holder.ConfigPath)
+			if err != nil {
+				return err
+			}
+
+			for _, path := range []string{
+				conf.Path,
+				conf.VerifierPath(),
+				conf.VaultKeyPath(),
+				conf.RecoveryPath(),
+				conf.FingerprintPath(),
+				conf.SettingsPath(),
+			} {
+				if err := files.ShredFile(path); err != nil && !os.IsNotExist(err) {
+					failed = append(failed, path)
+				}
+			}
+
+			sess, err := session.New()
+			if err != nil {
+				return err
+			}
+
+			if err := sess.Destroy(); err != nil {
+				failed = append(failed, "session")
+			}
+
+			memguard.Purge()
+
+			if len(failed) > 0 {
+				return fmt.Errorf("failed to remove:\n  %s", strings.Join(failed, "\n  "))
+			}
+
+			logger.PrintSuccess("Vault and config purged\n")
+			return nil
+		},
+	}
+
+	purgeCmd.Flags().BoolVar(&force, "force", false, "Confirm that the vault and config should be deleted")
+	// The root command checks required flags before unlocking the vault, so a
+	// bare "msk purge" never asks for the master password.
+	_ = purgeCmd.MarkFlagRequired("force")
+
+	return purgeCmd
+}
@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+)
+
+type purgeService struct {
+	app.Service
+	vaultPath string
+}
+
+func (s purgeService) VaultPath() string {
+	return s.vaultPath
+}
+
+func (s purgeService) Purge() ([]string, error) {
+	return nil, nil
+}
+
+func TestPurgeCmd(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	t.Run("should shred the config and every file beside it", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", home)
+		t.Setenv("HOME", home)
+		t.Setenv("AppData", home)
+
+		vaultPath := t.TempDir()
+		conf, err := config.NewConfig(filepath.Join(home, "config.msk"))
+		if err != nil {
+			t.Fatalf("failed to create config: %v", err)
+		}
+
+		v := vault.NewVaultWithMK([]byte("master"))
+		if err := conf.Save(v, vaultPath); err != nil {
+			t.Fatalf("failed to save config: %v", err)
+		}
+
+		code, _ := vault.GenerateRecoveryCode()
+		if err := conf.SaveRecovery(v, code, vaultPath); err != nil {
+			t.Fatalf("failed to save recovery: %v", err)
+		}
+
+		paths := []string{conf.Path, conf.VerifierPath(), conf.VaultKeyPath(), conf.RecoveryPath(), conf.FingerprintPath()}
+		for _, path := range paths {
+			if _, err := os.Stat(path); err != nil {
+				t.Fatalf("expected %s to exist before purging, got %v", path, err)
+			}
+		}
+
+		// The confirmation prompt reads the vault path from stdin.
+		stdin := filepath.Join(t.TempDir(), "stdin")
+		if err := os.WriteFile(stdin, []byte(vaultPath+"\n"), 0o600); err != nil {
+			t.Fatalf("failed to write stdin: %v", err)
+		}
+
+		in, err := os.Open(stdin)
+		if err != nil {
+			t.Fatalf("failed to open stdin: %v", err)
+		}
+		defer in.Close()
+
+		original := os.Stdin
+		os.Stdin = in
+		t.Cleanup(func() { os.Stdin = original })
+
+		holder := &ServiceHolder{Service: purgeService{vaultPath: vaultPath}, ConfigPath: conf.Path}
+		cmd := NewPurgeCmd(holder)
+		cmd.SetArgs([]string{"--force"})
+		cmd.SetOut(io.Discard)
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for _, path := range paths {
+			if _, err := os.Stat(path); !os.IsNotExist(err) {
+				t.Fatalf("expected %s to be removed, got %v", path, err)
+			}
+		}
+	})
+}
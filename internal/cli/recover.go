@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewRecoverCmd(holder *ServiceHolder, v vault.Vault) *cobra.Command {
+	return &cobra.Command{
+		Use:   "recover",
+		Short: "Set a new master password using the recovery code.",
+		Long: `Set a new master password using the recovery code.
+
+Only works if a recovery code was created with 'msk config --recovery-code'.
+The code unwraps the vault key, never the old master password. The vault
+key is re-wrapped under the new master password, and the used
+recovery code is replaced by a new one, printed once. Backups taken before
+vault keys existed keep the old master password and can no longer be
+restored.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			conf, err := config.NewConfig(holder.ConfigPath)
+			if err != nil {
+				return err
+			}
+
+			exists, err := conf.Exists()
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				return config.ErrConfigNotFound
+			}
+
+			code, err := prompt.ReadSafeValue("Enter recovery code:")
+			if err != nil {
+				return err
+			}
+			defer wipe.Bytes(code)
+
+			// Only the vault key comes back; the old master password is never
+			// rebuilt.
+			vaultPath, err := conf.LoadRecovery(v, code)
+			if err != nil {
+				return memoryLockHint(err)
+			}
+
+			next := vault.NewVault()
+			if allowsUnlockedMemory(cmd) {
+				next.AllowUnlockedMemory()
+			}
+
+			logger.PrintInfo("Choose a new master password.\n")
+			if err := next.LoadNewMK(); err != nil {
+				return memoryLockHint(err)
+			}
+			defer next.DestroyMK()
+
+			key, err := v.VaultKey()
+			if err != nil {
+				return err
+			}
+
+			if err := next.SetVaultKey(key); err != nil {
+				return memoryLockHint(err)
+			}
+
+			if err := conf.Save(next, vaultPath); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			logger.PrintSuccess("Master password changed\n")
+
+			return printRecoveryCode(cmd.OutOrStdout(), conf, next, vaultPath)
+		},
+	}
+}
+
+// printRecoveryCode creates a recovery code for the vault key loaded in v and
+// prints it once. Only the wrapped vault key and vault path are stored.
+func printRecoveryCode(out io.Writer, conf *config.Config, v vault.Vault, vaultPath string) error {
+	code, err := vault.GenerateRecoveryCode()
+	if err != nil {
+		return err
+	}
+	defer wipe.Bytes(code)
+
+	if err := conf.SaveRecovery(v, code, vaultPath); err != nil {
+		return fmt.Errorf("failed to save recovery code: %w", err)
+	}
+
+	logger.PrintError("Write down this recovery code and keep it somewhere safe. It is shown only once\nand unlocks the vault just like the master password:\n")
+	fmt.Fprintf(out, "%s\n", code)
+	return nil
+}
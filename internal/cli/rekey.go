@@ -0,0 +1,39 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/spf13/cobra"
+)
+
+func NewRekeyCmd(holder *ServiceHolder) *cobra.Command {
+	var parallel int
+
+	rekeyCmd := &cobra.Command{
+		Use:   "rekey",
+		Short: "Re-encrypt every password with a fresh salt and nonce, keeping the master password.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			progress := logger.NewProgress("Rekeying")
+			holder.Service.OnProgress(progress.Update)
+
+			count, err := holder.Service.RekeySecrets(cmd.Context())
+			progress.Done()
+
+			if err != nil {
+				return fmt.Errorf("rekeyed %d passwords before failing: %w", count, err)
+			}
+
+			logger.PrintSuccessf("Rekeyed %d passwords\n", count)
+			return nil
+		},
+	}
+
+	registerParallel(rekeyCmd, &parallel)
+
+	return rekeyCmd
+}
@@ -0,0 +1,72 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+)
+
+// cancelOnProgress cancels the command's context once the first secret is
+// done, like a Ctrl-C in the middle of a bulk operation.
+type cancelOnProgress struct {
+	app.Service
+	cancel context.CancelFunc
+}
+
+func (s cancelOnProgress) OnProgress(fn app.ProgressFunc) {
+	s.Service.OnProgress(func(done, total int) {
+		fn(done, total)
+		s.cancel()
+	})
+}
+
+func TestRekeyCmdCancel(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	t.Run("should stop rekeying when the context is cancelled", func(t *testing.T) {
+		store, err := storage.NewStore(t.TempDir())
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		v := vault.NewVaultWithMK([]byte("master"))
+		key, _ := vault.NewVaultKey()
+		if err := v.SetVaultKey(key); err != nil {
+			t.Fatalf("failed to set vault key: %v", err)
+		}
+
+		service := app.NewMSKService(store, v)
+		for _, name := range []string{"a", "b", "c", "d"} {
+			if err := service.AddSecret(name, []byte("pass-"+name)); err != nil {
+				t.Fatalf("add failed: %v", err)
+			}
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		holder := &ServiceHolder{Service: cancelOnProgress{Service: service, cancel: cancel}}
+		cmd := NewRekeyCmd(holder)
+		cmd.SetArgs([]string{"--parallel", "1"})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+
+		err = cmd.ExecuteContext(ctx)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got %v", err)
+		}
+
+		var partial *app.PartialError
+		if !errors.As(err, &partial) || partial.Done != 1 || partial.Total != 4 {
+			t.Fatalf("expected 1 of 4 passwords rekeyed, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+func NewRestoreCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		listOnly bool
+		latest   bool
+	)
+
+	restoreCmd := &cobra.Command{
+		Use:   "restore <name>",
+		Short: "Restore a password from a backup taken by update or del --backup.",
+		Long: `Restore a password from a backup taken by update or del --backup.
+
+The available backups are listed newest first and you pick one. The current
+version, if any, is backed up before it is replaced, so a restore can be
+undone the same way. Backups stay encrypted and are never decrypted here.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return fmt.Errorf("invalid password name: %w", err)
+			}
+
+			backups, err := holder.Service.SecretBackups(name)
+			if err != nil {
+				return err
+			}
+
+			if len(backups) == 0 {
+				return fmt.Errorf("no backups of %s", name)
+			}
+
+			if listOnly {
+				printBackups(cmd.OutOrStdout(), holder, backups)
+				return nil
+			}
+
+			backup := backups[0]
+			if !latest {
+				if backup, err = selectBackup(holder, backups); err != nil {
+					return err
+				}
+			}
+
+			if err := holder.Service.RestoreSecret(name, backup); err != nil {
+				return fmt.Errorf("failed to restore %s: %w", name, err)
+			}
+
+			logger.PrintSuccessf("Restored %s from the backup of %s\n", name, holder.formatTime(backup.CreatedAt))
+			return nil
+		},
+	}
+
+	restoreCmd.Flags().BoolVarP(&listOnly, "list", "l", false, "Only list the available backups")
+	restoreCmd.Flags().BoolVar(&latest, "latest", false, "Restore the newest backup without asking")
+	restoreCmd.MarkFlagsMutuallyExclusive("list", "latest")
+
+	return restoreCmd
+}
+
+func printBackups(out io.Writer, holder *ServiceHolder, backups []storage.Backup) {
+	for i, backup := range backups {
+		fmt.Fprintf(out, "%3d) %s\n", i+1, holder.formatTime(backup.CreatedAt))
+	}
+}
+
+func selectBackup(holder *ServiceHolder, backups []storage.Backup) (storage.Backup, error) {
+	for i, backup := range backups {
+		logger.PrintInfo(fmt.Sprintf("%3d) %s\n", i+1, holder.formatTime(backup.CreatedAt)))
+	}
+
+	answer, err := prompt.ReadString(fmt.Sprintf("Select a backup [1-%d]: ", len(backups)))
+	if err != nil {
+		return storage.Backup{}, err
+	}
+
+	choice, err := strconv.Atoi(strings.TrimSpace(answer))
+	if err != nil || choice < 1 || choice > len(backups) {
+		return storage.Backup{}, fmt.Errorf("invalid selection %q", strings.TrimSpace(answer))
+	}
+
+	return backups[choice-1], nil
+}
@@ -1,42 +1,118 @@
 package cli
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"slices"
+	"strconv"
 
 	"github.com/amauribechtoldjr/msk/internal/app"
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/config"
 	"github.com/amauribechtoldjr/msk/internal/logger"
 	"github.com/amauribechtoldjr/msk/internal/meta"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/storage"
 	"github.com/amauribechtoldjr/msk/internal/vault"
 	"github.com/spf13/cobra"
 )
 
 type ServiceHolder struct {
-	Service app.Service
+	Service    app.Service
+	ConfigPath string
+	// VaultPath is set by --vault; see vaultPath.
+	VaultPath string
+	// JSON is set by the global --json flag: data commands print JSON and
+	// errors are reported as JSON objects.
+	JSON bool
+	// UTC and TimeFormat are set by --utc and --time-format; see formatTime.
+	UTC        bool
+	TimeFormat string
 }
 
-var ignored_commands = []string{"msk", "version", "v", "help", "unlock", "lock", "config"}
+var ignored_commands = []string{"msk", "version", "v", "help", "unlock", "lock", "config", "selftest", "inspect", "recover", "change-master"}
+
+// keyless_commands only touch the filesystem. Given the vault path through
+// --vault or MSK_VAULT they run without the master password; otherwise they
+// unlock the vault like any other command to read the path from the config.
+var keyless_commands = []string{"exists", "path", "restore"}
+
+// key_cache_commands read many secrets in one go and keep their derived keys
+// for the rest of the command; see vault.EnableKeyCache.
+var key_cache_commands = []string{"list", "export", "export-env", "export-files", "check", "rekey"}
 
 func NewMSKCmd() *cobra.Command {
 	holder := &ServiceHolder{}
 	v := vault.NewVault()
 
-	var isVersionCommand bool
+	var (
+		isVersionCommand bool
+		quiet            bool
+		noPrompt         bool
+		strictPerms      bool
+	)
 
 	cmd := &cobra.Command{
 		Use:   "msk",
 		Short: "MSK is a lightweight, offline password manager that securely encrypts your credentials using a master password.",
+		// main reports errors through RenderError, honoring --json.
+		SilenceErrors: true,
 		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
-			if slices.Contains(ignored_commands, cmd.Name()) {
+			logger.SetQuiet(quiet)
+			logger.SetOutput(cmd.ErrOrStderr())
+			prompt.SetNoPrompt(noPrompt)
+
+			// Usage text would break the JSON error on stderr.
+			if holder.JSON {
+				cmd.SilenceUsage = true
+			}
+
+			if allowsUnlockedMemory(cmd) {
+				v.AllowUnlockedMemory()
+			}
+
+			// Cobra checks required flags only after this hook, which would ask
+			// for the master password before reporting a missing --force.
+			if err := cmd.ValidateRequiredFlags(); err != nil {
+				return err
+			}
+
+			if skipsBootstrap(cmd) {
 				return nil
 			}
 
+			// The config files are checked before unlocking, so --strict-perms
+			// refuses before the master password is asked for.
+			if err := checkPermissions(holder.ConfigPath, strictPerms); err != nil {
+				return err
+			}
+
 			var err error
-			holder.Service, err = app.BootstrapWithAuth(v)
+			if vaultPath := holder.vaultPath(); vaultPath != "" && slices.Contains(keyless_commands, cmd.Name()) {
+				holder.Service, err = app.BootstrapWithoutKey(v, holder.ConfigPath, vaultPath)
+				if err != nil {
+					return err
+				}
+
+				return checkVaultPermissions(holder, strictPerms)
+			}
+
+			holder.Service, err = app.BootstrapWithAuth(v, holder.ConfigPath)
 			if err != nil {
+				return memoryLockHint(err)
+			}
+
+			if slices.Contains(key_cache_commands, cmd.Name()) {
+				v.EnableKeyCache()
+			}
+
+			// The vault directory is only known once the config is unlocked.
+			if err := checkVaultPermissions(holder, strictPerms); err != nil {
 				return err
 			}
 
-			return nil
+			return applyClipboardSettings(cmd, holder.ConfigPath)
 		},
 		PersistentPostRunE: func(cmd *cobra.Command, args []string) error {
 			v.DestroyMK()
@@ -54,7 +130,7 @@ func NewMSKCmd() *cobra.Command {
 			}
 
 			if isVersionCommand {
-				logger.PrintInfo(meta.Version)
+				logger.PrintInfo(meta.Version + "\n")
 				return nil
 			}
 
@@ -68,28 +144,214 @@ func NewMSKCmd() *cobra.Command {
 	getCmd := NewGetCmd(holder)
 	cmd.AddCommand(getCmd)
 
+	loginCmd := NewLoginCmd(holder)
+	cmd.AddCommand(loginCmd)
+
+	existsCmd := NewExistsCmd(holder)
+	cmd.AddCommand(existsCmd)
+
+	pathCmd := NewPathCmd(holder)
+	cmd.AddCommand(pathCmd)
+
 	delCmd := NewDeleteCmd(holder)
 	cmd.AddCommand(delCmd)
 
+	restoreCmd := NewRestoreCmd(holder)
+	cmd.AddCommand(restoreCmd)
+
 	listCmd := NewListCmd(holder)
 	cmd.AddCommand(listCmd)
 
 	updateCmd := NewUpdateCmd(holder)
 	cmd.AddCommand(updateCmd)
 
-	configCmd := NewConfigCmd(v)
+	rotateCmd := NewRotateCmd(holder)
+	cmd.AddCommand(rotateCmd)
+
+	setCmd := NewSetCmd(holder)
+	cmd.AddCommand(setCmd)
+
+	tagCmd := NewTagCmd(holder)
+	cmd.AddCommand(tagCmd)
+
+	importEnvCmd := NewImportEnvCmd(holder)
+	cmd.AddCommand(importEnvCmd)
+
+	exportEnvCmd := NewExportEnvCmd(holder, v)
+	cmd.AddCommand(exportEnvCmd)
+
+	importCmd := NewImportCmd(holder)
+	cmd.AddCommand(importCmd)
+
+	exportCmd := NewExportCmd(holder, v)
+	cmd.AddCommand(exportCmd)
+
+	exportFilesCmd := NewExportFilesCmd(holder, v)
+	cmd.AddCommand(exportFilesCmd)
+
+	verifyPasswordCmd := NewVerifyPasswordCmd(holder)
+	cmd.AddCommand(verifyPasswordCmd)
+
+	checkCmd := NewCheckCmd(holder)
+	cmd.AddCommand(checkCmd)
+
+	rekeyCmd := NewRekeyCmd(holder)
+	cmd.AddCommand(rekeyCmd)
+
+	purgeCmd := NewPurgeCmd(holder)
+	cmd.AddCommand(purgeCmd)
+
+	configCmd := NewConfigCmd(holder, v)
 	cmd.AddCommand(configCmd)
 
-	versionCmd := NewVersionCmd()
+	inspectCmd := NewInspectCmd(holder)
+	cmd.AddCommand(inspectCmd)
+
+	changeMasterCmd := NewChangeMasterCmd(holder, v)
+	cmd.AddCommand(changeMasterCmd)
+
+	recoverCmd := NewRecoverCmd(holder, v)
+	cmd.AddCommand(recoverCmd)
+
+	selftestCmd := NewSelftestCmd(holder)
+	cmd.AddCommand(selftestCmd)
+
+	versionCmd := NewVersionCmd(holder)
 	cmd.AddCommand(versionCmd)
 
-	unlockCmd := NewUnlockCmd(v)
+	unlockCmd := NewUnlockCmd(holder, v)
 	cmd.AddCommand(unlockCmd)
 
 	lockCmd := NewLockCmd()
 	cmd.AddCommand(lockCmd)
 
+	cmd.PersistentFlags().BoolVarP(&quiet, "quiet", "q", false, "Hide progress output for long operations")
+	cmd.PersistentFlags().BoolVarP(&holder.JSON, "json", "j", false, "Print data and errors as JSON")
+	cmd.PersistentFlags().BoolVar(&holder.UTC, "utc", false, "Show timestamps in UTC instead of local time")
+	cmd.PersistentFlags().StringVar(&holder.TimeFormat, "time-format", "", "Go layout for timestamps, e.g. \"2006-01-02 15:04\" (defaults to RFC 3339)")
+	cmd.PersistentFlags().StringVar(&holder.VaultPath, "vault", "", "Vault directory for commands that need no master password, such as exists (defaults to $MSK_VAULT)")
+	cmd.PersistentFlags().StringVar(&holder.ConfigPath, "config", "", "Path to the config file (defaults to $MSK_CONFIG, then the user config directory)")
+	cmd.PersistentFlags().BoolVar(&noPrompt, "no-prompt", false, "Fail instead of prompting for a password, e.g. in cron jobs (use an unlocked session instead)")
+	cmd.PersistentFlags().BoolVar(&strictPerms, "strict-perms", false, "Refuse to run when the vault directory or config file is more accessible than configured, instead of warning")
+	cmd.PersistentFlags().Bool("allow-unlocked-memory", false, "Keep the master key in unlocked memory when memory locking fails (also $MSK_ALLOW_UNLOCKED=1); it may then be swapped to disk")
 	cmd.Flags().BoolVarP(&isVersionCommand, "version", "v", false, "Show MSK current version")
 
 	return cmd
 }
+
+// skipsBootstrap reports whether cmd, or any command it is nested under, runs
+// without unlocking the vault (e.g. "config set").
+func skipsBootstrap(cmd *cobra.Command) bool {
+	if slices.Contains(ignored_commands, cmd.Name()) {
+		return true
+	}
+
+	for parent := cmd.Parent(); parent != nil && parent.HasParent(); parent = parent.Parent() {
+		if slices.Contains(ignored_commands, parent.Name()) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// vaultPath returns the vault directory given by --vault or MSK_VAULT, or ""
+// when neither is set.
+func (h *ServiceHolder) vaultPath() string {
+	if h.VaultPath != "" {
+		return h.VaultPath
+	}
+
+	return os.Getenv(config.MSK_VAULT_ENV)
+}
+
+// checkPermissions warns when the config file or the files beside it grant
+// other users more access than the configured file mode, or refuses to go on
+// with --strict-perms.
+func checkPermissions(configPath string, strict bool) error {
+	conf, err := config.NewConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	return loosePermissions(conf.CheckPermissions(), strict)
+}
+
+// checkVaultPermissions is checkPermissions for the vault directory and the
+// configured directory mode.
+func checkVaultPermissions(holder *ServiceHolder, strict bool) error {
+	conf, err := config.NewConfig(holder.ConfigPath)
+	if err != nil {
+		return err
+	}
+
+	settings, err := conf.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	return loosePermissions(storage.CheckPermissions(holder.Service.VaultPath(), settings.DirMode), strict)
+}
+
+// loosePermissions turns a permission check error into a warning, unless
+// strict is set or the check failed for another reason.
+func loosePermissions(err error, strict bool) error {
+	if err == nil {
+		return nil
+	}
+
+	if strict || !errors.Is(err, storage.ErrLoosePermissions) {
+		return err
+	}
+
+	logger.PrintError("Warning: %v, other users may be able to read your secrets\n", err)
+	return nil
+}
+
+// applyClipboardSettings configures the clipboard from the settings file. A
+// disabled clipboard stays usable for commands given --copy or
+// --from-clipboard, which ask for it explicitly.
+func applyClipboardSettings(cmd *cobra.Command, configPath string) error {
+	conf, err := config.NewConfig(configPath)
+	if err != nil {
+		return err
+	}
+
+	settings, err := conf.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	clip.SetRestore(settings.ClipboardRestore)
+	clip.SetDisabled(settings.ClipboardDisabled && !asksForClipboard(cmd))
+	return nil
+}
+
+// asksForClipboard reports whether a flag that needs the clipboard was given.
+func asksForClipboard(cmd *cobra.Command) bool {
+	return cmd.Flags().Changed("copy") || cmd.Flags().Changed("from-clipboard")
+}
+
+// allowsUnlockedMemory reports whether --allow-unlocked-memory or
+// MSK_ALLOW_UNLOCKED opted into running without memory locking.
+func allowsUnlockedMemory(cmd *cobra.Command) bool {
+	allowed, _ := cmd.Flags().GetBool("allow-unlocked-memory")
+	return allowed || envEnabled(vault.MSK_ALLOW_UNLOCKED_ENV)
+}
+
+// envEnabled reports whether the environment variable holds a true value such
+// as "1" or "true".
+func envEnabled(name string) bool {
+	enabled, err := strconv.ParseBool(os.Getenv(name))
+	return err == nil && enabled
+}
+
+// memoryLockHint explains how to get past a failed memory lock, which usually
+// comes from a low RLIMIT_MEMLOCK in containers and CI.
+func memoryLockHint(err error) error {
+	if !errors.Is(err, vault.ErrMemoryLock) {
+		return err
+	}
+
+	return fmt.Errorf("%w: raise the limit with 'ulimit -l', or pass --allow-unlocked-memory (or set %s=1) to run without it", err, vault.MSK_ALLOW_UNLOCKED_ENV)
+}
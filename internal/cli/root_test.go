@@ -0,0 +1,118 @@
+package cli
+
+import (
+	"cmp"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+)
+
+func TestCommandArgs(t *testing.T) {
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "should require a name for add", args: []string{"add"}},
+		{name: "should reject extra names for add", args: []string{"add", "a", "b"}},
+		{name: "should require a name for update", args: []string{"update"}},
+		{name: "should require a name for set", args: []string{"set"}},
+		{name: "should require a name for login", args: []string{"login"}},
+		{name: "should require a name for rotate", args: []string{"rotate"}},
+		{name: "should require a name for get", args: []string{"get"}},
+		{name: "should require a name for del", args: []string{"del"}},
+		{name: "should require a name for path", args: []string{"path"}},
+		{name: "should require a name for restore", args: []string{"restore"}},
+		{name: "should require a file for import", args: []string{"import"}},
+		{name: "should require a name for verify-password", args: []string{"verify-password"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewMSKCmd()
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(tt.args)
+
+			// Arguments are checked before the vault is unlocked, so this never
+			// prompts for a master password.
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), "arg") {
+				t.Fatalf("expected an argument error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestRequiredFlags(t *testing.T) {
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "should require --force for purge", args: []string{"purge"}},
+		{name: "should require --dir for export-files", args: []string{"export-files"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cmd := NewMSKCmd()
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs(append(tt.args, "--config", t.TempDir()+"/config"))
+
+			// Required flags are checked before the vault is unlocked, so this
+			// never prompts for a master password.
+			err := cmd.Execute()
+			if err == nil || !strings.Contains(err.Error(), "required flag") {
+				t.Fatalf("expected a required flag error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestStrictPerms(t *testing.T) {
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	if runtime.GOOS == "windows" {
+		t.Skip("unix permissions do not apply on Windows")
+	}
+
+	for _, suffix := range []string{"", config.VERIFIER_SUFFIX, config.VAULT_KEY_SUFFIX, config.RECOVERY_SUFFIX} {
+		t.Run("should refuse a loose "+cmp.Or(suffix, "config")+" file before unlocking", func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "config.msk")
+			if err := os.WriteFile(configPath, []byte("config"), 0o600); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			if err := os.WriteFile(configPath+suffix, []byte("config"), 0o600); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+
+			if err := os.Chmod(configPath+suffix, 0o644); err != nil {
+				t.Fatalf("failed to loosen file: %v", err)
+			}
+
+			cmd := NewMSKCmd()
+			cmd.SetOut(io.Discard)
+			cmd.SetErr(io.Discard)
+			cmd.SetArgs([]string{"list", "--strict-perms", "--config", configPath})
+
+			// A prompt would fail with another error, as stdin is no terminal.
+			err := cmd.Execute()
+			if !errors.Is(err, storage.ErrLoosePermissions) {
+				t.Fatalf("expected ErrLoosePermissions, got %v", err)
+			}
+		})
+	}
+}
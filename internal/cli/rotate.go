@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"fmt"
+
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewRotateCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		source      passwordSource
+		backup      bool
+		noClipClear bool
+	)
+
+	rotateCmd := &cobra.Command{
+		Use:   "rotate <name>",
+		Short: "Change a password, copying the current one first.",
+		Long: `Change a password, copying the current one first.
+
+Sites usually ask for the current password before accepting a new one. The
+current password is copied to the clipboard; press Enter once it is pasted to
+enter or generate the new one. The password is then updated and the new one
+copied, and cleared like "get --copy" would.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return fmt.Errorf("invalid password name: %w", err)
+			}
+
+			current, err := holder.Service.GetSecret(name)
+			if err != nil {
+				return fmt.Errorf("failed to get password: %w", err)
+			}
+
+			copied, err := copyOrPrint(cmd.OutOrStdout(), current, "Current password copied to clipboard\n")
+			wipe.Bytes(current)
+			if err != nil {
+				return err
+			}
+
+			// Until the new password takes its place, every way out clears the
+			// current one from the clipboard, or restores what it held before.
+			replaced := false
+			if copied {
+				defer func() {
+					if !replaced {
+						clip.ClearIfCopied()
+					}
+				}()
+
+				if _, err := prompt.ReadString("Press Enter for the new password..."); err != nil {
+					return err
+				}
+			}
+
+			password, err := source.password()
+			if err != nil {
+				return err
+			}
+			defer wipe.Bytes(password)
+
+			if backup {
+				if err := holder.Service.BackupSecret(name); err != nil {
+					return fmt.Errorf("failed to back up secret: %w", err)
+				}
+			}
+
+			if err := holder.Service.UpdateSecret(name, password); err != nil {
+				return fmt.Errorf("failed to update secret: %w", err)
+			}
+			source.stored()
+
+			// The service wiped password once stored, so read the new one back.
+			secret, err := holder.Service.GetSecret(name)
+			if err != nil {
+				return fmt.Errorf("failed to get password: %w", err)
+			}
+			defer wipe.Bytes(secret)
+
+			if err := copyPassword(cmd.Context(), cmd.OutOrStdout(), secret, "Password updated and new password copied to clipboard (press Ctrl+V to paste)\n\n", noClipClear); err != nil {
+				return err
+			}
+			replaced = true
+
+			return nil
+		},
+	}
+
+	source.register(rotateCmd)
+	rotateCmd.Flags().BoolVar(&backup, "backup", false, "Keep an encrypted copy of the current password, see 'msk restore'")
+	rotateCmd.Flags().BoolVar(&noClipClear, "no-clip-clear", false, "Keep the new password on the clipboard instead of clearing it")
+
+	return rotateCmd
+}
@@ -0,0 +1,137 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	clip "github.com/amauribechtoldjr/msk/internal/clip"
+	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/vault"
+	"github.com/spf13/cobra"
+)
+
+// NewSelftestCmd checks that this build works on the current machine without
+// touching the real vault.
+func NewSelftestCmd(holder *ServiceHolder) *cobra.Command {
+	var clipboardOnly bool
+
+	selftestCmd := &cobra.Command{
+		Use:   "selftest",
+		Short: "Check encryption, clipboard and config resolution on this machine.",
+		Long: `Check encryption, clipboard and config resolution on this machine.
+
+With --clipboard, only check the clipboard: write a test value to it, read
+it back and put back what was there before. Run it when copying does not
+seem to work, e.g. on Wayland or over SSH.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if clipboardOnly {
+				return selftestClipboard()
+			}
+
+			failed := false
+
+			if vault.MemoryLockAvailable() {
+				logger.PrintSuccess("ok   memory locking\n")
+			} else {
+				logger.PrintError("warn memory locking unavailable, unlocking needs --allow-unlocked-memory\n")
+			}
+
+			if err := selftestRoundTrip(); err != nil {
+				failed = true
+				logger.PrintError("FAIL encrypt/decrypt round-trip: %v\n", err)
+			} else {
+				logger.PrintSuccess("ok   encrypt/decrypt round-trip\n")
+			}
+
+			if err := clip.Init(); err != nil {
+				logger.PrintError("warn clipboard unavailable, passwords will be printed instead: %v\n", err)
+			} else {
+				logger.PrintSuccess("ok   clipboard\n")
+			}
+
+			conf, err := config.NewConfig(holder.ConfigPath)
+			if err != nil {
+				failed = true
+				logger.PrintError("FAIL config path: %v\n", err)
+			} else {
+				logger.PrintSuccessf("ok   config path %s\n", conf.Path)
+			}
+
+			if failed {
+				return errors.New("selftest failed")
+			}
+
+			return nil
+		},
+	}
+
+	selftestCmd.Flags().BoolVar(&clipboardOnly, "clipboard", false, "Only check that copying to the clipboard works")
+
+	return selftestCmd
+}
+
+// selftestClipboard reports the clipboard backend and whether a value written
+// to the clipboard can be read back.
+func selftestClipboard() error {
+	logger.PrintInfo(fmt.Sprintf("     clipboard backend: %s\n", clip.Backend()))
+
+	if err := clip.Check(); err != nil {
+		logger.PrintError("FAIL clipboard round-trip: %v\n", err)
+		return errors.New("selftest failed")
+	}
+
+	logger.PrintSuccess("ok   clipboard round-trip\n")
+	return nil
+}
+
+// selftestRoundTrip encrypts a sample secret under a random master key and
+// checks it decrypts back unchanged.
+func selftestRoundTrip() error {
+	mk, err := format.RandomBytes(32)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	v := vault.NewVaultWithMK(mk)
+	defer v.DestroyMK()
+
+	sample := domain.Secret{Name: "selftest", Password: []byte("selftest-password")}
+
+	payload, err := format.MarshalSecret(sample)
+	if err != nil {
+		return err
+	}
+
+	// Encrypt wipes its input, so keep a copy to compare against.
+	expected := bytes.Clone(payload)
+
+	sealed, err := v.Encrypt(payload)
+	if err != nil {
+		return fmt.Errorf("encrypt: %w", err)
+	}
+
+	fileBytes, err := format.MarshalFile(sealed.Salt, sealed.Nonce, sealed.CipherData)
+	if err != nil {
+		return err
+	}
+
+	salt, nonce, data, err := format.UnmarshalFile(fileBytes)
+	if err != nil {
+		return err
+	}
+
+	decrypted, err := v.Decrypt(salt, nonce, data)
+	if err != nil {
+		return fmt.Errorf("decrypt: %w", err)
+	}
+
+	if !bytes.Equal(decrypted, expected) {
+		return errors.New("decrypted data does not match")
+	}
+
+	return nil
+}
@@ -0,0 +1,59 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewSetCmd(holder *ServiceHolder) *cobra.Command {
+	var (
+		source      passwordSource
+		noClipClear bool
+	)
+
+	setCmd := &cobra.Command{
+		Use:   "set <name>",
+		Short: "Add a password, or update it if it already exists.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return fmt.Errorf("invalid password name: %w", err)
+			}
+
+			password, err := source.password()
+			if err != nil {
+				return err
+			}
+			defer wipe.Bytes(password)
+
+			if err := holder.Service.SetSecret(cmd.Context(), name, password); err != nil {
+				return fmt.Errorf("failed to set secret: %w", err)
+			}
+			source.stored()
+
+			if source.generate {
+				secret, err := holder.Service.GetSecret(name)
+				if err != nil {
+					return fmt.Errorf("failed to set secret: %w", err)
+				}
+				defer wipe.Bytes(secret)
+
+				return copyPassword(cmd.Context(), cmd.OutOrStdout(), secret, "Password generated and copied to clipboard (press Ctrl+V to paste)\n\n", noClipClear)
+			}
+
+			logger.PrintSuccess("Password set successfully\n")
+			return nil
+		},
+	}
+
+	source.register(setCmd)
+	setCmd.Flags().BoolVar(&noClipClear, "no-clip-clear", false, "Keep the generated password on the clipboard instead of clearing it")
+
+	return setCmd
+}
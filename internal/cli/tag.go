@@ -0,0 +1,144 @@
+package cli
+
+import (
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/spf13/cobra"
+)
+
+func NewTagCmd(holder *ServiceHolder) *cobra.Command {
+	tagCmd := &cobra.Command{
+		Use:   "tag",
+		Short: "Add, remove and list tags on several passwords at once.",
+	}
+
+	tagCmd.AddCommand(newTagEditCmd(holder, "add", "Add a tag to passwords.", false))
+	tagCmd.AddCommand(newTagEditCmd(holder, "remove", "Remove a tag from passwords.", true))
+	tagCmd.AddCommand(newTagListCmd(holder))
+
+	return tagCmd
+}
+
+// newTagEditCmd builds "tag add" and "tag remove", which only differ in the
+// service call.
+func newTagEditCmd(holder *ServiceHolder, use, short string, remove bool) *cobra.Command {
+	return &cobra.Command{
+		Use:   use + " <tag> <name>...",
+		Short: short,
+		Args:  cobra.MinimumNArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			tag, names := args[0], args[1:]
+
+			if err := validator.ValidateName(tag); err != nil {
+				return fmt.Errorf("invalid tag: %w", err)
+			}
+
+			edit := holder.Service.AddTag
+			if remove {
+				edit = holder.Service.RemoveTag
+			}
+
+			failed := 0
+			for _, name := range names {
+				err := validator.ValidatePath(name)
+
+				changed := false
+				if err == nil {
+					changed, err = edit(name, tag)
+				}
+
+				switch {
+				case err != nil:
+					failed++
+					logger.PrintError("%s: %v\n", name, err)
+				case changed:
+					logger.PrintSuccessf("%s changed\n", name)
+				default:
+					logger.PrintInfo(fmt.Sprintf("%s unchanged\n", name))
+				}
+			}
+
+			if failed > 0 {
+				return fmt.Errorf("%d of %d passwords could not be changed", failed, len(names))
+			}
+
+			return nil
+		},
+	}
+}
+
+// tagCount is one line of "tag list".
+type tagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+func newTagListCmd(holder *ServiceHolder) *cobra.Command {
+	var parallel int
+
+	listCmd := &cobra.Command{
+		Use:   "list",
+		Short: "List every tag with the number of passwords that have it (decrypts every entry).",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if err := setParallel(holder, parallel); err != nil {
+				return err
+			}
+
+			names, err := holder.Service.GetSecretsRecursive()
+			if err != nil {
+				return fmt.Errorf("failed to list passwords: %w", err)
+			}
+
+			metadata, err := loadMetadata(cmd.Context(), holder.Service, names)
+			if err != nil {
+				return err
+			}
+
+			counts := countTags(metadata)
+
+			if holder.JSON {
+				enc := json.NewEncoder(cmd.OutOrStdout())
+				enc.SetIndent("", "  ")
+				return enc.Encode(counts)
+			}
+
+			for _, c := range counts {
+				fmt.Fprintf(cmd.OutOrStdout(), "%s\t%d\n", c.Tag, c.Count)
+			}
+
+			return nil
+		},
+	}
+
+	registerParallel(listCmd, &parallel)
+
+	return listCmd
+}
+
+// countTags counts the secrets carrying each tag, sorted by tag.
+func countTags(metadata map[string]domain.Secret) []tagCount {
+	byTag := map[string]int{}
+	for _, secret := range metadata {
+		for _, tag := range secret.Tags {
+			byTag[tag]++
+		}
+	}
+
+	counts := make([]tagCount, 0, len(byTag))
+	for tag, count := range byTag {
+		counts = append(counts, tagCount{Tag: tag, Count: count})
+	}
+
+	slices.SortFunc(counts, func(a, b tagCount) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	return counts
+}
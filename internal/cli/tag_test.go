@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// tagService keeps tags per name in memory.
+type tagService struct {
+	app.Service
+	tags map[string][]string
+}
+
+func (s tagService) AddTag(name, tag string) (bool, error) {
+	tags, ok := s.tags[name]
+	if !ok {
+		return false, app.ErrSecretNotFound
+	}
+
+	if slices.Contains(tags, tag) {
+		return false, nil
+	}
+
+	s.tags[name] = append(tags, tag)
+	return true, nil
+}
+
+func TestTagAddCmd(t *testing.T) {
+	t.Run("should report changed, unchanged and failed secrets", func(t *testing.T) {
+		var messages bytes.Buffer
+		logger.SetOutput(&messages)
+		t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+		service := tagService{tags: map[string][]string{"github": nil, "gitlab": {"work"}}}
+
+		cmd := NewTagCmd(&ServiceHolder{Service: service})
+		cmd.SetOut(io.Discard)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"add", "work", "github", "gitlab", "missing"})
+
+		if err := cmd.Execute(); err == nil || !strings.Contains(err.Error(), "1 of 3") {
+			t.Fatalf("expected one failure, got %v", err)
+		}
+
+		want := "github changed\ngitlab unchanged\nmissing: secret not found\n"
+		if messages.String() != want {
+			t.Fatalf("expected %q, got %q", want, messages.String())
+		}
+
+		if !reflect.DeepEqual(service.tags["github"], []string{"work"}) {
+			t.Fatalf("expected github to be tagged, got %v", service.tags["github"])
+		}
+	})
+}
+
+func TestCountTags(t *testing.T) {
+	t.Run("should count each tag once per secret, sorted by tag", func(t *testing.T) {
+		counts := countTags(map[string]domain.Secret{
+			"github": {Tags: []string{"work", "git"}},
+			"gitlab": {Tags: []string{"git"}},
+			"bank":   {},
+		})
+
+		want := []tagCount{{Tag: "git", Count: 2}, {Tag: "work", Count: 1}}
+		if !reflect.DeepEqual(counts, want) {
+			t.Fatalf("expected %v, got %v", want, counts)
+		}
+	})
+}
@@ -0,0 +1,27 @@
+package cli
+
+import "time"
+
+// formatTime renders a stored timestamp for display, in local time unless
+// --utc is given and as RFC 3339 unless --time-format names another Go
+// layout. Timestamps are stored in UTC, so the conversion only happens here.
+// Unknown times, such as secrets written before timestamps were tracked,
+// print as "-".
+func (h *ServiceHolder) formatTime(t time.Time) string {
+	if t.IsZero() {
+		return "-"
+	}
+
+	if h.UTC {
+		t = t.UTC()
+	} else {
+		t = t.Local()
+	}
+
+	layout := h.TimeFormat
+	if layout == "" {
+		layout = time.RFC3339
+	}
+
+	return t.Format(layout)
+}
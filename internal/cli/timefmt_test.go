@@ -0,0 +1,44 @@
+package cli
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFormatTime(t *testing.T) {
+	stamp := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	t.Run("should print - for the zero time", func(t *testing.T) {
+		holder := &ServiceHolder{}
+
+		if got := holder.formatTime(time.Time{}); got != "-" {
+			t.Fatalf("expected -, got %q", got)
+		}
+	})
+
+	t.Run("should default to RFC 3339 in local time", func(t *testing.T) {
+		holder := &ServiceHolder{}
+
+		want := stamp.Local().Format(time.RFC3339)
+		if got := holder.formatTime(stamp); got != want {
+			t.Fatalf("expected %q, got %q", want, got)
+		}
+	})
+
+	t.Run("should convert to UTC with --utc", func(t *testing.T) {
+		holder := &ServiceHolder{UTC: true}
+
+		local := stamp.In(time.FixedZone("UTC+3", 3*60*60))
+		if got := holder.formatTime(local); got != "2024-03-01T12:30:00Z" {
+			t.Fatalf("expected 2024-03-01T12:30:00Z, got %q", got)
+		}
+	})
+
+	t.Run("should use the --time-format layout", func(t *testing.T) {
+		holder := &ServiceHolder{UTC: true, TimeFormat: time.DateOnly}
+
+		if got := holder.formatTime(stamp); got != "2024-03-01" {
+			t.Fatalf("expected 2024-03-01, got %q", got)
+		}
+	})
+}
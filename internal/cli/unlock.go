@@ -5,18 +5,19 @@ import (
 	"fmt"
 
 	"github.com/amauribechtoldjr/msk/internal/config"
+	"github.com/amauribechtoldjr/msk/internal/logger"
 	"github.com/amauribechtoldjr/msk/internal/session"
 	"github.com/amauribechtoldjr/msk/internal/vault"
 	"github.com/amauribechtoldjr/msk/internal/wipe"
 	"github.com/spf13/cobra"
 )
 
-func NewUnlockCmd(vault vault.Vault) *cobra.Command {
+func NewUnlockCmd(holder *ServiceHolder, vault vault.Vault) *cobra.Command {
 	return &cobra.Command{
 		Use:   "unlock",
 		Short: "Unlock the vault for the current shell session",
 		RunE: func(cmd *cobra.Command, args []string) error {
-			conf, err := config.NewConfig()
+			conf, err := config.NewConfig(holder.ConfigPath)
 			if err != nil {
 				return err
 			}
@@ -32,13 +33,20 @@ func NewUnlockCmd(vault vault.Vault) *cobra.Command {
 
 			err = vault.LoadMK()
 			if err != nil {
-				return err
+				return memoryLockHint(err)
 			}
 
+			// The verifier and the config share a salt, so derive the key once.
+			vault.EnableKeyCache()
+
 			if _, err := conf.Load(vault); err != nil {
 				return fmt.Errorf("invalid master password: %w", err)
 			}
 
+			if fingerprint, err := vault.Fingerprint(); err == nil {
+				logger.PrintSuccessf("Unlocked vault: %s\n", fingerprint)
+			}
+
 			s, err := session.New()
 			if err != nil {
 				return fmt.Errorf("failed to initialize session: %w", err)
@@ -58,7 +66,7 @@ func NewUnlockCmd(vault vault.Vault) *cobra.Command {
 				return fmt.Errorf("failed to store session: %w", err)
 			}
 
-			fmt.Print(encodedToken)
+			fmt.Fprint(cmd.OutOrStdout(), encodedToken)
 			return nil
 		},
 	}
@@ -1,7 +1,6 @@
 package cli
 
 import (
-	"errors"
 	"fmt"
 
 	"github.com/amauribechtoldjr/msk/internal/logger"
@@ -12,18 +11,17 @@ import (
 )
 
 func NewUpdateCmd(holder *ServiceHolder) *cobra.Command {
+	var backup bool
+
 	updateCmd := &cobra.Command{
 		Use:     "update <name>",
 		Aliases: []string{"u"},
 		Short:   "Used to update passwords of the vault.",
+		Args:    cobra.ExactArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			if len(args) < 1 {
-				return errors.New("password name is required")
-			}
-
 			name := args[0]
 
-			err := validator.Validate(name)
+			err := validator.ValidatePath(name)
 			if err != nil {
 				return fmt.Errorf("invalid password name: %v", err)
 			}
@@ -34,6 +32,12 @@ func NewUpdateCmd(holder *ServiceHolder) *cobra.Command {
 			}
 			defer wipe.Bytes(password)
 
+			if backup {
+				if err := holder.Service.BackupSecret(name); err != nil {
+					return fmt.Errorf("failed to back up secret: %w", err)
+				}
+			}
+
 			err = holder.Service.UpdateSecret(name, password)
 			if err != nil {
 				return fmt.Errorf("failed to update secret: %w", err)
@@ -44,5 +48,7 @@ func NewUpdateCmd(holder *ServiceHolder) *cobra.Command {
 		},
 	}
 
+	updateCmd.Flags().BoolVar(&backup, "backup", false, "Keep an encrypted copy of the current password, see 'msk restore'")
+
 	return updateCmd
 }
@@ -0,0 +1,78 @@
+package cli
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/spf13/cobra"
+)
+
+func NewVerifyPasswordCmd(holder *ServiceHolder) *cobra.Command {
+	verifyCmd := &cobra.Command{
+		Use:   "verify-password <name>",
+		Short: "Check whether a password you remember matches the stored one.",
+		Long: `Check whether a password you remember matches the stored one.
+
+Prompts for the password and prints "match" and exits with 0 when it is
+the stored one, or prints "no match" and exits with 1 otherwise. The stored
+password is never shown.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			name := args[0]
+
+			if err := validator.ValidatePath(name); err != nil {
+				return fmt.Errorf("invalid password name: %w", err)
+			}
+
+			// Check first so nobody types a password for a missing secret.
+			exists, err := holder.Service.SecretExists(name)
+			if err != nil {
+				return err
+			}
+
+			if !exists {
+				return app.ErrSecretNotFound
+			}
+
+			candidate, err := prompt.ReadSafeValue("Enter password:")
+			if err != nil {
+				return err
+			}
+
+			match, err := verifyPassword(holder.Service, name, candidate)
+			if err != nil {
+				return err
+			}
+
+			if !match {
+				fmt.Fprintln(cmd.OutOrStdout(), "no match")
+				// A mismatch is an answer, not misuse.
+				cmd.SilenceUsage = true
+				return &ExitError{Code: 1}
+			}
+
+			fmt.Fprintln(cmd.OutOrStdout(), "match")
+			return nil
+		},
+	}
+
+	return verifyCmd
+}
+
+// verifyPassword reports whether candidate is the stored password of name,
+// comparing in constant time. Both passwords are wiped.
+func verifyPassword(service app.Service, name string, candidate []byte) (bool, error) {
+	defer wipe.Bytes(candidate)
+
+	password, err := service.GetSecret(name)
+	if err != nil {
+		return false, fmt.Errorf("failed to get password: %w", err)
+	}
+	defer wipe.Bytes(password)
+
+	return subtle.ConstantTimeCompare(candidate, password) == 1, nil
+}
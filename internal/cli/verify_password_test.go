@@ -0,0 +1,42 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/app"
+)
+
+func TestVerifyPassword(t *testing.T) {
+	service := getService{passwords: map[string]string{"github": "hunter2"}}
+
+	t.Run("should match the stored password and wipe the candidate", func(t *testing.T) {
+		candidate := []byte("hunter2")
+
+		match, err := verifyPassword(service, "github", candidate)
+		if err != nil || !match {
+			t.Fatalf("expected a match, got %v and %v", match, err)
+		}
+
+		if !bytes.Equal(candidate, make([]byte, len(candidate))) {
+			t.Fatalf("expected the candidate to be wiped, got %q", candidate)
+		}
+	})
+
+	t.Run("should not match another password", func(t *testing.T) {
+		for _, candidate := range []string{"hunter3", "hunter", "hunter22", "HUNTER2"} {
+			match, err := verifyPassword(service, "github", []byte(candidate))
+			if err != nil || match {
+				t.Fatalf("expected %q not to match, got %v and %v", candidate, match, err)
+			}
+		}
+	})
+
+	t.Run("should return the error of a missing secret", func(t *testing.T) {
+		_, err := verifyPassword(service, "missing", []byte("hunter2"))
+		if !errors.Is(err, app.ErrSecretNotFound) {
+			t.Fatalf("expected ErrSecretNotFound, got %v", err)
+		}
+	})
+}
@@ -1,18 +1,43 @@
 package cli
 
 import (
-	"github.com/amauribechtoldjr/msk/internal/logger"
+	"encoding/json"
+	"fmt"
+
 	"github.com/amauribechtoldjr/msk/internal/meta"
 	"github.com/spf13/cobra"
 )
 
-func NewVersionCmd() *cobra.Command {
+func NewVersionCmd(holder *ServiceHolder) *cobra.Command {
 	return &cobra.Command{
 		Use:     "version",
 		Aliases: []string{"v"},
 		Short:   "Print the version information.",
-		Run: func(cmd *cobra.Command, args []string) {
-			logger.PrintInfo(meta.Version)
+		RunE: func(cmd *cobra.Command, args []string) error {
+			info := meta.Build()
+			out := cmd.OutOrStdout()
+
+			if holder.JSON {
+				enc := json.NewEncoder(out)
+				enc.SetIndent("", "  ")
+				return enc.Encode(info)
+			}
+
+			fmt.Fprintf(out, "msk %s\n", info.Version)
+			fmt.Fprintf(out, "commit:   %s\n", valueOrUnknown(info.Commit))
+			fmt.Fprintf(out, "built:    %s\n", valueOrUnknown(info.BuildDate))
+			fmt.Fprintf(out, "go:       %s\n", info.GoVersion)
+			fmt.Fprintf(out, "platform: %s\n", info.Platform)
+
+			return nil
 		},
 	}
 }
+
+func valueOrUnknown(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+
+	return value
+}
@@ -0,0 +1,38 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"runtime"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+func TestVersionCmd(t *testing.T) {
+	t.Run("should write build info as JSON to the command output", func(t *testing.T) {
+		t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+		var out bytes.Buffer
+		cmd := NewMSKCmd()
+		cmd.SetOut(&out)
+		cmd.SetErr(io.Discard)
+		cmd.SetArgs([]string{"version", "--json"})
+
+		if err := cmd.Execute(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var info meta.BuildInfo
+		if err := json.Unmarshal(out.Bytes(), &info); err != nil {
+			t.Fatalf("expected JSON output, got %q: %v", out.String(), err)
+		}
+
+		if info.GoVersion != runtime.Version() || info.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+			t.Fatalf("unexpected build info %+v", info)
+		}
+	})
+}
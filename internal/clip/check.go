@@ -0,0 +1,67 @@
+package clip
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+)
+
+var ErrRoundTrip = errors.New("clipboard did not return the text written to it")
+
+// Backend describes the clipboard msk would use on this system. On Linux
+// and BSD it is X11 only, so a Wayland session needs XWayland and an SSH
+// session needs X forwarding.
+func Backend() string {
+	switch runtime.GOOS {
+	case "darwin":
+		return "macOS pasteboard"
+	case "windows":
+		return "Windows clipboard"
+	}
+
+	display := os.Getenv("DISPLAY")
+	wayland := os.Getenv("WAYLAND_DISPLAY")
+
+	switch {
+	case display != "" && wayland != "":
+		return fmt.Sprintf("X11 through XWayland (DISPLAY=%s)", display)
+	case display != "":
+		return fmt.Sprintf("X11 (DISPLAY=%s)", display)
+	case wayland != "":
+		return "none, Wayland without XWayland (DISPLAY is not set)"
+	default:
+		return "none, no display (DISPLAY is not set)"
+	}
+}
+
+// Check initializes the clipboard, writes a random sentinel, reads it back
+// and then puts back the text that was there before. It returns
+// ErrClipboardInit or ErrRoundTrip when the clipboard does not work.
+func Check() error {
+	if err := Init(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	previous := bytes.Clone(readText())
+	defer wipe.Bytes(previous)
+
+	sentinel := []byte("msk clipboard check " + rand.Text())
+
+	writeText(sentinel)
+	got := bytes.Clone(readText())
+	writeText(previous)
+
+	if !bytes.Equal(got, sentinel) {
+		return ErrRoundTrip
+	}
+
+	return nil
+}
@@ -0,0 +1,43 @@
+package clip
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCheck(t *testing.T) {
+	t.Run("should pass a round-trip and put back the previous text", func(t *testing.T) {
+		writes := fakeClipboard(t, []byte("previous"))
+		readText = func() []byte {
+			if len(*writes) == 0 {
+				return []byte("previous")
+			}
+			return (*writes)[len(*writes)-1]
+		}
+
+		if err := Check(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(*writes) != 2 || string((*writes)[1]) != "previous" {
+			t.Fatalf("expected the sentinel and then the previous text, got %q", *writes)
+		}
+	})
+
+	t.Run("should return ErrRoundTrip when the text does not come back", func(t *testing.T) {
+		fakeClipboard(t, nil)
+
+		if err := Check(); !errors.Is(err, ErrRoundTrip) {
+			t.Fatalf("expected ErrRoundTrip, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrClipboardInit without a clipboard", func(t *testing.T) {
+		fakeClipboard(t, nil)
+		initErr = ErrClipboardInit
+
+		if err := Check(); !errors.Is(err, ErrClipboardInit) {
+			t.Fatalf("expected ErrClipboardInit, got %v", err)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package clip
 
 import (
+	"bytes"
+	"context"
 	"errors"
-	"fmt"
-	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/amauribechtoldjr/msk/internal/logger"
@@ -12,32 +14,189 @@ import (
 
 var (
 	ErrClipboardInit = errors.New("failed to initialize clipboard")
+	// ErrClipboardDisabled is returned instead of touching the clipboard
+	// after SetDisabled(true).
+	ErrClipboardDisabled = errors.New("clipboard disabled by the clipboard setting")
 )
 
+// ClearDelay is how long a copied password stays on the clipboard.
+const ClearDelay = 15 * time.Second
+
+var (
+	initOnce sync.Once
+	initErr  error
+
+	// disabled keeps Init from ever initializing the system clipboard.
+	disabled atomic.Bool
+
+	// dirty is set while the clipboard may hold something msk copied, so an
+	// interrupt knows whether there is anything to clear.
+	dirty atomic.Bool
+
+	// restore, original and saved implement SetRestore: original holds the
+	// text found on the clipboard before the first copy.
+	mu       sync.Mutex
+	restore  bool
+	original []byte
+	saved    bool
+
+	// writeText and readText access the system clipboard; tests swap them out
+	// since they run without one.
+	writeText = func(text []byte) {
+		_ = clipboard.Write(clipboard.FmtText, text)
+	}
+	readText = func() []byte {
+		return clipboard.Read(clipboard.FmtText)
+	}
+)
+
+// SetRestore makes Clear put back whatever text was on the clipboard before
+// msk first copied to it, instead of emptying it. Non-text content cannot be
+// restored, so the clipboard is emptied then.
+func SetRestore(enabled bool) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	restore = enabled
+}
+
+// SetDisabled makes every clipboard operation fail with ErrClipboardDisabled
+// without initializing the system clipboard, for headless machines where msk
+// should never touch it.
+func SetDisabled(d bool) {
+	disabled.Store(d)
+}
+
+// Disabled reports whether SetDisabled turned the clipboard off.
+func Disabled() bool {
+	return disabled.Load()
+}
+
+// Init initializes the system clipboard once per process. It is called lazily
+// by CopyText so commands that never copy anything work without a clipboard.
 func Init() error {
-	err := clipboard.Init()
-	if err != nil {
-		return ErrClipboardInit
+	if disabled.Load() {
+		return ErrClipboardDisabled
 	}
-	return nil
+
+	initOnce.Do(func() {
+		if err := clipboard.Init(); err != nil {
+			initErr = ErrClipboardInit
+		}
+	})
+
+	return initErr
 }
 
 func CopyText(text []byte) error {
-	_ = clipboard.Write(clipboard.FmtText, text)
+	if err := Init(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	if restore && !saved && len(text) > 0 {
+		original = readText()
+		saved = true
+	}
+
+	writeText(text)
+	dirty.Store(len(text) > 0)
 	return nil
 }
 
-func Clear() {
-	timer := 15
-	logger.PrintSuccessf("Password will be cleared from clipboard in %v seconds: ", timer)
+// ReadText returns a copy of the text on the clipboard, or nil when it holds
+// no text. The caller owns the returned bytes and should wipe them.
+func ReadText() ([]byte, error) {
+	if err := Init(); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(readText()), nil
+}
+
+// Empty clears the clipboard right away, e.g. after a password was read from
+// it, without the countdown of Clear.
+func Empty() error {
+	if err := Init(); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	writeText([]byte{})
+	dirty.Store(false)
+	return nil
+}
+
+// reset empties the clipboard, or restores the saved text when SetRestore is
+// enabled, and reports whether it restored anything.
+func reset() bool {
+	mu.Lock()
+	defer mu.Unlock()
+
+	restored := len(original) > 0
+	if restored {
+		writeText(original)
+	} else {
+		writeText([]byte{})
+	}
 
-	for timer > 0 {
-		logger.PrintSuccess(".")
-		time.Sleep(1 * time.Second)
-		timer -= 1
+	original = nil
+	saved = false
+	dirty.Store(false)
+
+	return restored
+}
+
+// ClearOnInterrupt empties the clipboard if it may still hold a copied
+// password. It is meant to run from the interrupt handler, so a Ctrl-C during
+// the countdown in Clear does not leave the password behind.
+func ClearOnInterrupt() {
+	ClearIfCopied()
+}
+
+// ClearIfCopied empties the clipboard right away, or restores its previous
+// text when SetRestore is enabled, if it may still hold something msk copied.
+// Commands defer it so that failing after a copy does not leave a password
+// behind.
+func ClearIfCopied() {
+	if dirty.Load() {
+		reset()
+	}
+}
+
+// Clear counts down d and then empties the clipboard, or restores its
+// previous text when SetRestore is enabled. Cancelling ctx ends the countdown
+// early, but the clipboard is still cleared before Clear returns.
+func Clear(ctx context.Context, d time.Duration) {
+	logger.PrintSuccessf("Password will be cleared from clipboard in %v seconds: ", int(d.Seconds()))
+
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	deadline := time.NewTimer(d)
+	defer deadline.Stop()
+
+countdown:
+	for {
+		select {
+		case <-ctx.Done():
+			break countdown
+		case <-deadline.C:
+			break countdown
+		case <-ticker.C:
+			logger.PrintSuccess(".")
+		}
 	}
-	CopyText([]byte{})
+	restored := reset()
 
-	fmt.Fprintln(os.Stderr)
-	logger.PrintSuccess("Clipboard cleared.\n")
+	logger.Lb()
+	if restored {
+		logger.PrintSuccess("Clipboard restored.\n")
+	} else {
+		logger.PrintSuccess("Clipboard cleared.\n")
+	}
 }
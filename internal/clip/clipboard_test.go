@@ -0,0 +1,247 @@
+package clip
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/logger"
+)
+
+// fakeClipboard replaces the system clipboard with a slice of every write.
+// Reads return current, the text on the clipboard before the test.
+func fakeClipboard(t *testing.T, current []byte) *[][]byte {
+	t.Helper()
+
+	var writes [][]byte
+
+	initOnce.Do(func() {})
+	previousErr, previousWrite, previousRead := initErr, writeText, readText
+	initErr = nil
+	writeText = func(text []byte) {
+		writes = append(writes, append([]byte(nil), text...))
+	}
+	readText = func() []byte {
+		return current
+	}
+
+	t.Cleanup(func() {
+		initErr, writeText, readText = previousErr, previousWrite, previousRead
+		SetRestore(false)
+		SetDisabled(false)
+		original, saved = nil, false
+		dirty.Store(false)
+	})
+
+	return &writes
+}
+
+func TestClearOnInterrupt(t *testing.T) {
+	t.Run("should clear a copied password when interrupted", func(t *testing.T) {
+		writes := fakeClipboard(t, nil)
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ClearOnInterrupt()
+
+		if len(*writes) != 2 || len((*writes)[1]) != 0 {
+			t.Fatalf("expected the password to be cleared, got writes %q", *writes)
+		}
+	})
+
+	t.Run("should leave the clipboard alone when nothing was copied", func(t *testing.T) {
+		writes := fakeClipboard(t, nil)
+
+		ClearOnInterrupt()
+
+		if len(*writes) != 0 {
+			t.Fatalf("expected no writes, got %q", *writes)
+		}
+	})
+
+	t.Run("should not clear again after the clipboard was cleared", func(t *testing.T) {
+		writes := fakeClipboard(t, nil)
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := CopyText([]byte{}); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ClearOnInterrupt()
+
+		if len(*writes) != 2 {
+			t.Fatalf("expected no extra clear, got writes %q", *writes)
+		}
+	})
+}
+
+func TestClearIfCopied(t *testing.T) {
+	t.Run("should restore the previous text when restoring is enabled", func(t *testing.T) {
+		writes := fakeClipboard(t, []byte("before"))
+		SetRestore(true)
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ClearIfCopied()
+
+		if len(*writes) != 2 || string((*writes)[1]) != "before" {
+			t.Fatalf("expected the previous text to be restored, got writes %q", *writes)
+		}
+	})
+}
+
+func TestRestore(t *testing.T) {
+	t.Run("should restore the previous text instead of clearing", func(t *testing.T) {
+		writes := fakeClipboard(t, []byte("previous"))
+		SetRestore(true)
+
+		if err := CopyText([]byte("first")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := CopyText([]byte("second")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !reset() {
+			t.Fatal("expected reset to report a restore")
+		}
+
+		last := (*writes)[len(*writes)-1]
+		if string(last) != "previous" {
+			t.Fatalf("expected previous to be restored, got %q", last)
+		}
+	})
+
+	t.Run("should clear when the previous content was not text", func(t *testing.T) {
+		writes := fakeClipboard(t, nil)
+		SetRestore(true)
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if reset() {
+			t.Fatal("expected reset to clear, not restore")
+		}
+
+		if last := (*writes)[len(*writes)-1]; len(last) != 0 {
+			t.Fatalf("expected the clipboard to be cleared, got %q", last)
+		}
+	})
+
+	t.Run("should clear when restore is disabled", func(t *testing.T) {
+		writes := fakeClipboard(t, []byte("previous"))
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ClearOnInterrupt()
+
+		if last := (*writes)[len(*writes)-1]; len(last) != 0 {
+			t.Fatalf("expected the clipboard to be cleared, got %q", last)
+		}
+	})
+}
+
+func TestDisabled(t *testing.T) {
+	t.Run("should never touch the clipboard when disabled", func(t *testing.T) {
+		writes := fakeClipboard(t, []byte("previous"))
+		SetDisabled(true)
+
+		if err := CopyText([]byte("s3cret")); !errors.Is(err, ErrClipboardDisabled) {
+			t.Fatalf("CopyText: expected ErrClipboardDisabled, got %v", err)
+		}
+
+		if _, err := ReadText(); !errors.Is(err, ErrClipboardDisabled) {
+			t.Fatalf("ReadText: expected ErrClipboardDisabled, got %v", err)
+		}
+
+		if len(*writes) != 0 {
+			t.Fatalf("expected no writes, got %q", *writes)
+		}
+	})
+}
+
+func TestReadText(t *testing.T) {
+	t.Run("should return a copy of the clipboard text", func(t *testing.T) {
+		current := []byte("from-site")
+		fakeClipboard(t, current)
+
+		text, err := ReadText()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		text[0] = 'X'
+		if string(current) != "from-site" {
+			t.Fatal("expected ReadText to return a copy")
+		}
+	})
+}
+
+func TestEmpty(t *testing.T) {
+	t.Run("should clear the clipboard immediately", func(t *testing.T) {
+		writes := fakeClipboard(t, []byte("from-site"))
+
+		if err := Empty(); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(*writes) != 1 || len((*writes)[0]) != 0 {
+			t.Fatalf("expected one empty write, got %q", *writes)
+		}
+	})
+}
+
+func TestClear(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	t.Run("should clear the clipboard right away when cancelled", func(t *testing.T) {
+		writes := fakeClipboard(t, nil)
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		start := time.Now()
+		Clear(ctx, time.Hour)
+
+		if elapsed := time.Since(start); elapsed > time.Second {
+			t.Fatalf("expected the countdown to stop on cancellation, took %v", elapsed)
+		}
+
+		if len(*writes) != 2 || len((*writes)[1]) != 0 {
+			t.Fatalf("expected the password to be cleared, got writes %q", *writes)
+		}
+	})
+
+	t.Run("should clear the clipboard once the delay passes", func(t *testing.T) {
+		writes := fakeClipboard(t, nil)
+
+		if err := CopyText([]byte("s3cret")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		Clear(context.Background(), 10*time.Millisecond)
+
+		if len(*writes) != 2 || len((*writes)[1]) != 0 {
+			t.Fatalf("expected the password to be cleared, got writes %q", *writes)
+		}
+	})
+}
@@ -1,35 +1,81 @@
 package config
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/amauribechtoldjr/msk/internal/domain"
 	"github.com/amauribechtoldjr/msk/internal/files"
 	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/gcm"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/meta"
 	"github.com/amauribechtoldjr/msk/internal/prompt"
+	"github.com/amauribechtoldjr/msk/internal/storage"
 	"github.com/amauribechtoldjr/msk/internal/vault"
 	"github.com/amauribechtoldjr/msk/internal/wipe"
 )
 
 var (
-	ErrConfigNotFound = errors.New("config file not found, run 'msk config' first")
-	ErrInvalidConfig  = errors.New("master key verification failed")
+	ErrConfigNotFound      = errors.New("config file not found, run 'msk config' first")
+	ErrInvalidConfig       = errors.New("master key verification failed")
+	ErrWrongMasterPassword = errors.New("wrong master password")
+	ErrInvalidVaultPath    = storage.ErrInvalidVaultPath
+	ErrNotConfigFile       = format.ErrNotConfigFile
+	ErrRecoveryNotFound    = errors.New("no recovery code was set up, run 'msk config --recovery-code' to create one")
+	ErrVaultKeyLost        = errors.New("the vault key is missing or unreadable but secrets depend on it, run 'msk recover' to restore it")
+	ErrRecoveryOutdated    = errors.New("the recovery code was created by an older version that wrapped the master password, run 'msk config --recovery-code' to replace it")
+
+	errVerifierNotFound = errors.New("verifier not found")
 )
 
-const MSK_CONFIG_NAME = "msk-config"
+const (
+	// MSK_CONFIG_NAME names the secret that legacy configs stored the vault
+	// path in.
+	MSK_CONFIG_NAME = "msk-config"
+	MSK_CONFIG_ENV  = "MSK_CONFIG"
+	// MSK_VAULT_ENV names the vault directory for commands that never need
+	// the master password, which otherwise only learn it from the config.
+	MSK_VAULT_ENV = "MSK_VAULT"
+
+	VERIFIER_SUFFIX = ".verifier"
+	VERIFIER_SIZE   = 32
+	RECOVERY_SUFFIX = ".recovery"
+	// VAULT_KEY_SUFFIX names the file holding the vault key wrapped by the
+	// master password.
+	VAULT_KEY_SUFFIX = ".key"
+	// FINGERPRINT_SUFFIX names the plain-text file holding the vault
+	// fingerprint, so it can be shown without unlocking.
+	FINGERPRINT_SUFFIX = ".fingerprint"
+)
 
 type Config struct {
 	Path string
 }
 
-func NewConfig() (*Config, error) {
-	path, err := files.MSKConfigPath("config.msk")
-	if err != nil {
-		return &Config{}, err
+// NewConfig returns the config stored at path. The config file is resolved in
+// this order:
+//
+//  1. path, usually from the --config flag
+//  2. the MSK_CONFIG environment variable
+//  3. $XDG_CONFIG_HOME/msk/config.msk on Unix-like systems
+//  4. msk/config.msk in the OS config directory (os.UserConfigDir)
+func NewConfig(path string) (*Config, error) {
+	if path == "" {
+		path = os.Getenv(MSK_CONFIG_ENV)
+	}
+
+	if path == "" {
+		defaultPath, err := files.MSKConfigPath("config.msk")
+		if err != nil {
+			return &Config{}, err
+		}
+		path = defaultPath
 	}
 
 	return &Config{Path: path}, nil
@@ -53,7 +99,43 @@ func (c *Config) PromptVaultPath() (string, error) {
 		vaultPath = defaultPath
 	}
 
-	return vaultPath, nil
+	return ResolveVaultPath(vaultPath)
+}
+
+// ResolveVaultPath turns an entered vault path into the absolute path stored
+// in the config. A leading "~" is expanded to the home directory. Other
+// relative paths are resolved against the working directory with a warning,
+// since msk may later run from anywhere. A path naming an existing file is
+// rejected.
+func ResolveVaultPath(path string) (string, error) {
+	if strings.TrimSpace(path) == "" {
+		return "", fmt.Errorf("%w: the path is empty", ErrInvalidVaultPath)
+	}
+
+	if path == "~" || strings.HasPrefix(path, "~/") || strings.HasPrefix(path, "~"+string(filepath.Separator)) {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to expand %s: %w", path, err)
+		}
+
+		path = filepath.Join(home, path[1:])
+	}
+
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidVaultPath, err)
+	}
+
+	if !filepath.IsAbs(path) {
+		logger.PrintError("Warning: %s is relative, using %s\n", path, absPath)
+	}
+
+	info, err := os.Stat(absPath)
+	if err == nil && !info.IsDir() {
+		return "", fmt.Errorf("%w: %s is a file", ErrInvalidVaultPath, absPath)
+	}
+
+	return absPath, nil
 }
 
 func (c *Config) CheckOverwrite() (bool, error) {
@@ -71,65 +153,590 @@ func (c *Config) CreateVault() (string, error) {
 		return "", err
 	}
 
-	if err := os.MkdirAll(vaultPath, 0o600); err != nil {
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(vaultPath, settings.DirMode); err != nil {
 		return "", fmt.Errorf("failed to create vault directory: %w", err)
 	}
 
 	return vaultPath, nil
 }
 
+// Load checks the master password against the verifier and returns the
+// configured vault path. A wrong password yields ErrWrongMasterPassword,
+// ErrNotConfigFile means the file at Path is not a config at all, and
+// ErrInvalidConfig means the config itself could not be read. Configs written
+// before verifiers existed get one on their first successful load, and
+// likewise a vault key. The vault key is loaded into vault.
 func (c *Config) Load(vault vault.Vault) (string, error) {
 	data, err := files.ReadFile(c.Path, ErrConfigNotFound)
 	if err != nil {
 		return "", err
 	}
 
-	salt, nonce, data, err := format.UnmarshalFile(data)
+	salt, nonce, data, legacy, err := unmarshalConfigFile(data)
+	if errors.Is(err, ErrNotConfigFile) {
+		return "", fmt.Errorf("%w: %s", ErrNotConfigFile, c.Path)
+	}
+
 	if err != nil {
 		return "", err
 	}
 
+	verifyErr := c.Verify(vault)
+	if verifyErr != nil && !errors.Is(verifyErr, errVerifierNotFound) {
+		return "", verifyErr
+	}
+
 	decryptedBytes, err := vault.Decrypt(salt, nonce, data)
 	if err != nil {
 		return "", ErrInvalidConfig
 	}
+	defer wipe.Bytes(decryptedBytes)
+
+	if errors.Is(verifyErr, errVerifierNotFound) {
+		if err := c.saveVerifier(vault, salt); err != nil {
+			return "", err
+		}
+	}
+
+	var vaultPath string
+	if legacy {
+		vaultPath, err = unmarshalLegacyConfig(decryptedBytes)
+	} else {
+		vaultPath, err = format.UnmarshalConfig(decryptedBytes)
+	}
+
+	if errors.Is(err, ErrNotConfigFile) {
+		return "", fmt.Errorf("%w: %s", ErrNotConfigFile, c.Path)
+	}
 
-	secret, err := format.UnmarshalSecret(decryptedBytes)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(vaultPath) == "" {
+		return "", fmt.Errorf("%w: the config holds no vault path", ErrInvalidVaultPath)
+	}
+
+	// Configs written before paths were resolved may hold a relative path.
+	vaultPath, err = filepath.Abs(vaultPath)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidVaultPath, err)
+	}
+
+	if legacy {
+		if err := c.upgradeFormat(vault, salt, vaultPath); err != nil {
+			return "", err
+		}
+	}
+
+	if err := c.loadVaultKey(vault, salt, vaultPath); err != nil {
+		return "", err
+	}
+
+	return vaultPath, nil
+}
+
+// unmarshalConfigFile splits a config file into its parts. legacy reports a
+// config written before the config magic existed, which is a secret file
+// sealed with the master password. Anything else yields ErrNotConfigFile.
+func unmarshalConfigFile(data []byte) (salt, nonce, sealed []byte, legacy bool, err error) {
+	if format.IsConfigFile(data) {
+		salt, nonce, sealed, err = format.UnmarshalConfigFile(data)
+		return salt, nonce, sealed, false, err
+	}
+
+	version, err := format.FileVersion(data)
+	if err != nil || version != meta.MSK_FILE_VERSION {
+		return nil, nil, nil, false, ErrNotConfigFile
+	}
+
+	salt, nonce, sealed, err = format.UnmarshalFile(data)
+	return salt, nonce, sealed, true, err
+}
+
+// unmarshalLegacyConfig reads the vault path from a legacy config payload, a
+// secret named MSK_CONFIG_NAME. A secret by any other name is not a config.
+//
+// Deprecated: legacy configs are upgraded on load, and reading them will be
+// dropped in the next release.
+func unmarshalLegacyConfig(data []byte) (string, error) {
+	secret, err := format.UnmarshalSecret(data)
 	if err != nil {
 		return "", err
 	}
 	defer wipe.Bytes(secret.Password)
 
 	if secret.Name != MSK_CONFIG_NAME {
-		return "", ErrInvalidConfig
+		return "", ErrNotConfigFile
 	}
 
 	return string(secret.Password), nil
 }
 
+// upgradeFormat rewrites a legacy config in the current format. It keeps the
+// salt, which the verifier and the wrapped vault key share.
+func (c *Config) upgradeFormat(vault vault.Vault, salt []byte, vaultPath string) error {
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	payload, err := format.MarshalConfig(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	saltedGCM, err := vault.EncryptWithSalt(salt, payload)
+	if err != nil {
+		return err
+	}
+
+	finalBytes, err := format.MarshalConfigFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+	if err != nil {
+		return err
+	}
+
+	if err := files.WriteAtomicFile(c.Path, finalBytes, settings.FileMode); err != nil {
+		return err
+	}
+
+	logger.PrintInfo(fmt.Sprintf("Upgraded %s to the current config format\n", c.Path))
+	return nil
+}
+
 func (c *Config) Save(vault vault.Vault, vaultPath string) error {
-	if err := os.MkdirAll(filepath.Dir(c.Path), 0o700); err != nil {
+	if err := storage.CheckVaultPath(vaultPath); err != nil {
+		return err
+	}
+
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(c.Path), settings.DirMode); err != nil {
+		return err
+	}
+
+	payload, err := format.MarshalConfig(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	saltedGCM, err := vault.Encrypt(payload)
+	if err != nil {
+		return err
+	}
+
+	finalBytes, err := format.MarshalConfigFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+	if err != nil {
+		return err
+	}
+
+	verifier, err := c.sealVerifier(vault, saltedGCM.Salt)
+	if err != nil {
+		return err
+	}
+
+	vaultKey, err := c.sealVaultKey(vault, saltedGCM.Salt, vaultPath)
+	if err != nil {
+		return err
+	}
+
+	// The verifier and vault key are wrapped by the same master password as
+	// the config, so they are replaced together and the config goes last: a
+	// failure puts back the files already replaced, and the old master
+	// password keeps opening the vault.
+	err = writeFiles([]stagedFile{
+		{path: c.VaultKeyPath(), data: vaultKey},
+		{path: c.VerifierPath(), data: verifier},
+		{path: c.Path, data: finalBytes},
+	}, settings.FileMode)
+	if err != nil {
+		return err
+	}
+
+	// A recovery file wraps the vault key and vault path the config was saved
+	// with before, which may no longer match, so callers print a new code.
+	if err := os.Remove(c.RecoveryPath()); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	return c.saveFingerprint(vault)
+}
+
+type stagedFile struct {
+	path     string
+	data     []byte
+	previous []byte
+	existed  bool
+}
+
+// writeFiles replaces several files in order. Each is written to a synced
+// temp file first, so a failure while staging changes nothing, and files
+// already replaced when a later one fails are put back as they were. Like
+// storage.Tx, a crash in between can still leave only some replaced.
+func writeFiles(staged []stagedFile, perm os.FileMode) error {
+	var temps []string
+	// Committed temp files are gone, so this only removes leftovers.
+	defer func() {
+		for _, tmp := range temps {
+			os.Remove(tmp)
+		}
+	}()
+
+	for i := range staged {
+		previous, err := os.ReadFile(staged[i].path)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+		staged[i].previous, staged[i].existed = previous, err == nil
+
+		tmp, err := files.WriteTempFile(staged[i].path, staged[i].data, perm)
+		if err != nil {
+			return err
+		}
+		temps = append(temps, tmp)
+	}
+
+	for i, file := range staged {
+		if err := files.CommitTempFile(temps[i], file.path); err != nil {
+			restoreFiles(staged[:i], perm)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// restoreFiles puts back files replaced by writeFiles on a best-effort basis.
+func restoreFiles(staged []stagedFile, perm os.FileMode) {
+	for _, file := range staged {
+		if file.existed {
+			files.WriteAtomicFile(file.path, file.previous, perm)
+		} else {
+			os.Remove(file.path)
+		}
+	}
+}
+
+func (c *Config) RecoveryPath() string {
+	return c.Path + RECOVERY_SUFFIX
+}
+
+// SaveRecovery stores the loaded vault key and the vault path wrapped under a
+// recovery code, replacing any previous recovery file. Neither the code nor
+// the master password is stored.
+func (c *Config) SaveRecovery(vault vault.Vault, code []byte, vaultPath string) error {
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	payload, err := format.MarshalConfig(vaultPath)
+	if err != nil {
+		return err
+	}
+
+	saltedGCM, err := vault.SealRecovery(code, payload)
+	if err != nil {
+		return err
+	}
+
+	// The envelope version tells it apart from recovery files that wrapped the
+	// master password.
+	finalBytes, err := format.MarshalFileVersion(meta.MSK_FILE_VERSION_ENVELOPE, saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+	if err != nil {
+		return err
+	}
+
+	return files.WriteAtomicFile(c.RecoveryPath(), finalBytes, settings.FileMode)
+}
+
+// LoadRecovery loads the vault key from the recovery file using a recovery
+// code and returns the vault path stored with it. It returns
+// ErrRecoveryNotFound when no recovery code was set up, ErrRecoveryOutdated
+// for a file that wrapped the master password and vault.ErrWrongRecoveryCode
+// when the code does not match.
+func (c *Config) LoadRecovery(v vault.Vault, code []byte) (string, error) {
+	data, err := files.ReadFile(c.RecoveryPath(), ErrRecoveryNotFound)
+	if err != nil {
+		return "", err
+	}
+
+	version, err := format.FileVersion(data)
+	if err != nil {
+		return "", err
+	}
+
+	if version != meta.MSK_FILE_VERSION_ENVELOPE {
+		return "", ErrRecoveryOutdated
+	}
+
+	salt, nonce, data, err := format.UnmarshalFile(data)
+	if err != nil {
+		return "", err
+	}
+
+	payload, err := v.LoadRecovery(code, &gcm.SaltedGCM{Salt: salt, Nonce: nonce, CipherData: data})
+	if err != nil {
+		return "", err
+	}
+	defer wipe.Bytes(payload)
+
+	vaultPath, err := format.UnmarshalConfig(payload)
+	if err != nil {
+		return "", err
+	}
+
+	if strings.TrimSpace(vaultPath) == "" {
+		return "", fmt.Errorf("%w: the recovery file holds no vault path", ErrInvalidVaultPath)
+	}
+
+	return vaultPath, nil
+}
+
+func (c *Config) VerifierPath() string {
+	return c.Path + VERIFIER_SUFFIX
+}
+
+// Verify checks the master password by opening the verifier, a random blob
+// sealed under the config's key. It shares the config salt, so with the key
+// cache enabled verifying costs no extra key derivation.
+func (c *Config) Verify(vault vault.Vault) error {
+	data, err := files.ReadFile(c.VerifierPath(), errVerifierNotFound)
+	if err != nil {
+		return err
+	}
+
+	salt, nonce, data, err := format.UnmarshalFile(data)
+	if err != nil {
+		return ErrInvalidConfig
+	}
+
+	verifier, err := vault.Decrypt(salt, nonce, data)
+	if err != nil {
+		return ErrWrongMasterPassword
+	}
+	wipe.Bytes(verifier)
+
+	return nil
+}
+
+func (c *Config) saveVerifier(vault vault.Vault, salt []byte) error {
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	finalBytes, err := c.sealVerifier(vault, salt)
+	if err != nil {
+		return err
+	}
+
+	return files.WriteAtomicFile(c.VerifierPath(), finalBytes, settings.FileMode)
+}
+
+// sealVerifier returns a new verifier file sealed under the master password.
+func (c *Config) sealVerifier(vault vault.Vault, salt []byte) ([]byte, error) {
+	verifier, err := format.RandomBytes(VERIFIER_SIZE)
+	if err != nil {
+		return nil, err
+	}
+
+	saltedGCM, err := vault.EncryptWithSalt(salt, verifier)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.MarshalFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+}
+
+func (c *Config) VaultKeyPath() string {
+	return c.Path + VAULT_KEY_SUFFIX
+}
+
+// loadVaultKey unwraps the vault key into vault. Configs written before
+// vault keys existed get a new one, so secrets written from then on use the
+// envelope format while older ones stay readable.
+func (c *Config) loadVaultKey(vault vault.Vault, salt []byte, vaultPath string) error {
+	err := c.unwrapVaultKey(vault)
+	if errors.Is(err, os.ErrNotExist) {
+		return c.saveVaultKey(vault, salt, vaultPath)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	// Configs written before fingerprints existed get one on first unlock.
+	if _, err := os.Stat(c.FingerprintPath()); errors.Is(err, os.ErrNotExist) {
+		return c.saveFingerprint(vault)
+	}
+
+	return nil
+}
+
+// unwrapVaultKey reads the vault key file and loads the key into vault. It
+// returns os.ErrNotExist when there is no vault key file.
+func (c *Config) unwrapVaultKey(vault vault.Vault) error {
+	data, err := files.ReadFile(c.VaultKeyPath(), os.ErrNotExist)
+	if err != nil {
 		return err
 	}
 
-	secret := domain.Secret{
-		Name:     MSK_CONFIG_NAME,
-		Password: []byte(vaultPath),
+	salt, nonce, data, err := format.UnmarshalFile(data)
+	if err != nil {
+		return err
+	}
+
+	key, err := vault.Decrypt(salt, nonce, data)
+	if err != nil {
+		return ErrInvalidConfig
+	}
+
+	return vault.SetVaultKey(key)
+}
+
+// saveVaultKey writes the vault key file sealed by sealVaultKey and the
+// vault fingerprint.
+func (c *Config) saveVaultKey(v vault.Vault, salt []byte, vaultPath string) error {
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return err
 	}
 
-	fileBytes := format.MarshalSecret(secret)
+	finalBytes, err := c.sealVaultKey(v, salt, vaultPath)
+	if err != nil {
+		return err
+	}
+
+	if err := files.WriteAtomicFile(c.VaultKeyPath(), finalBytes, settings.FileMode); err != nil {
+		return err
+	}
+
+	return c.saveFingerprint(v)
+}
+
+// sealVaultKey wraps the vault key loaded in v under the master password. It
+// shares the config salt, like the verifier, so loading it costs no extra key
+// derivation. Without a loaded key it keeps the one already on disk when the
+// master password opens it, since secrets depend on it. It only creates a new
+// one while vaultPath holds no secrets sealed by a vault key, which a new key
+// could never open, and returns ErrVaultKeyLost otherwise.
+func (c *Config) sealVaultKey(v vault.Vault, salt []byte, vaultPath string) ([]byte, error) {
+	key, err := v.VaultKey()
+	if errors.Is(err, vault.ErrNoVaultKey) {
+		if c.unwrapVaultKey(v) == nil {
+			key, err = v.VaultKey()
+		} else if key, err = c.newVaultKey(vaultPath); err == nil {
+			err = v.SetVaultKey(bytes.Clone(key))
+		}
+	}
+	defer wipe.Bytes(key)
+
+	if err != nil {
+		return nil, err
+	}
+
+	saltedGCM, err := v.EncryptWithSalt(salt, key)
+	if err != nil {
+		return nil, err
+	}
+
+	return format.MarshalFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+}
+
+// newVaultKey returns a fresh vault key, or ErrVaultKeyLost when vaultPath
+// already holds secrets sealed by another one.
+func (c *Config) newVaultKey(vaultPath string) ([]byte, error) {
+	sealed, err := hasEnvelopeSecrets(vaultPath)
+	if err != nil {
+		return nil, err
+	}
+
+	if sealed {
+		return nil, ErrVaultKeyLost
+	}
+
+	return vault.NewVaultKey()
+}
+
+// hasEnvelopeSecrets reports whether any secret file under vaultPath, in
+// folders too, is sealed by a vault key. Only file headers are read; a
+// missing vault holds none.
+func hasEnvelopeSecrets(vaultPath string) (bool, error) {
+	found := false
+
+	err := filepath.WalkDir(vaultPath, func(path string, entry fs.DirEntry, err error) error {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if entry.IsDir() || filepath.Ext(path) != ".msk" {
+			return nil
+		}
+
+		file, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		header := make([]byte, meta.MSK_HEADER_SIZE)
+		if _, err := io.ReadFull(file, header); err != nil {
+			// Too short to be a sealed secret.
+			return nil
+		}
+
+		if version, err := format.FileVersion(header); err == nil && version == meta.MSK_FILE_VERSION_ENVELOPE {
+			found = true
+			return fs.SkipAll
+		}
+
+		return nil
+	})
+
+	return found, err
+}
+
+func (c *Config) FingerprintPath() string {
+	return c.Path + FINGERPRINT_SUFFIX
+}
+
+// Fingerprint returns the fingerprint of the vault key stored next to the
+// config, or "" when there is none yet. It needs no master password.
+func (c *Config) Fingerprint() (string, error) {
+	data, err := os.ReadFile(c.FingerprintPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return "", nil
+	}
+
+	return strings.TrimSpace(string(data)), err
+}
 
-	saltedGCM, err := vault.Encrypt(fileBytes)
+// saveFingerprint stores the fingerprint of the vault key loaded in v.
+func (c *Config) saveFingerprint(v vault.Vault) error {
+	settings, err := c.LoadSettings()
 	if err != nil {
 		return err
 	}
 
-	finalBytes, err := format.MarshalFile(saltedGCM.Salt, saltedGCM.Nonce, saltedGCM.CipherData)
+	fingerprint, err := v.Fingerprint()
 	if err != nil {
 		return err
 	}
 
-	return files.WriteAtomicFile(c.Path, finalBytes, 0o600)
+	return files.WriteAtomicFile(c.FingerprintPath(), []byte(fingerprint+"\n"), settings.FileMode)
 }
 
 func (c *Config) DefaultVaultPath() (string, error) {
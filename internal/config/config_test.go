@@ -1,12 +1,20 @@
 package config
 
 import (
+	"bytes"
 	"errors"
+	"io"
 	"os"
 	"path/filepath"
+	"runtime"
 	"testing"
+	"time"
 
+	"github.com/amauribechtoldjr/msk/internal/domain"
 	"github.com/amauribechtoldjr/msk/internal/files"
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/logger"
+	"github.com/amauribechtoldjr/msk/internal/meta"
 	"github.com/amauribechtoldjr/msk/internal/vault"
 )
 
@@ -17,8 +25,9 @@ func newTestConfig(t *testing.T) *Config {
 	t.Setenv("AppData", tmpDir)         // windows
 	t.Setenv("XDG_CONFIG_HOME", tmpDir) // linux
 	t.Setenv("HOME", tmpDir)            // macos
+	t.Setenv(MSK_CONFIG_ENV, "")
 
-	cfg, err := NewConfig()
+	cfg, err := NewConfig("")
 	if err != nil {
 		t.Fatalf("NewConfig failed: %v", err)
 	}
@@ -46,10 +55,298 @@ func TestSaveAndLoad(t *testing.T) {
 			t.Fatalf("expected vault path %q, got %q", vaultPath, loaded)
 		}
 	})
+
+	t.Run("should reject an empty or relative vault path", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		for _, vaultPath := range []string{"", "   ", "vault"} {
+			err := cfg.Save(vault.NewVaultWithMK([]byte("test-master-key")), vaultPath)
+			if !errors.Is(err, ErrInvalidVaultPath) {
+				t.Fatalf("%q: expected ErrInvalidVaultPath, got %v", vaultPath, err)
+			}
+		}
+
+		if _, err := os.Stat(cfg.Path); !os.IsNotExist(err) {
+			t.Fatalf("expected no config to be written, got %v", err)
+		}
+	})
+}
+
+func TestFingerprint(t *testing.T) {
+	t.Run("should store the vault fingerprint and recreate it on load", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		v := vault.NewVaultWithMK([]byte("test-master-key"))
+		if err := cfg.Save(v, "/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		want, err := v.Fingerprint()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got, err := cfg.Fingerprint(); err != nil || got != want {
+			t.Fatalf("expected %q, got %q (%v)", want, got, err)
+		}
+
+		if err := os.Remove(cfg.FingerprintPath()); err != nil {
+			t.Fatalf("failed to remove fingerprint: %v", err)
+		}
+
+		if got, err := cfg.Fingerprint(); err != nil || got != "" {
+			t.Fatalf("expected no fingerprint, got %q (%v)", got, err)
+		}
+
+		if _, err := cfg.Load(vault.NewVaultWithMK([]byte("test-master-key"))); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		if got, err := cfg.Fingerprint(); err != nil || got != want {
+			t.Fatalf("expected %q, got %q (%v)", want, got, err)
+		}
+	})
+}
+
+func TestVaultKey(t *testing.T) {
+	t.Run("should create a vault key for configs without one", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.Save(vault.NewVaultWithMK([]byte("test-master-key")), "/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		if err := os.Remove(cfg.VaultKeyPath()); err != nil {
+			t.Fatalf("failed to remove vault key: %v", err)
+		}
+
+		v := vault.NewVaultWithMK([]byte("test-master-key"))
+		if _, err := cfg.Load(v); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		if _, err := v.VaultKey(); err != nil {
+			t.Fatalf("expected a vault key to be loaded, got %v", err)
+		}
+
+		if _, err := os.Stat(cfg.VaultKeyPath()); err != nil {
+			t.Fatalf("expected the vault key to be saved, got %v", err)
+		}
+	})
+
+	t.Run("should keep the vault key when the config is saved again", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		first := vault.NewVaultWithMK([]byte("test-master-key"))
+		if err := cfg.Save(first, "/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		if err := cfg.Save(vault.NewVaultWithMK([]byte("test-master-key")), "/other-vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		second := vault.NewVaultWithMK([]byte("test-master-key"))
+		if _, err := cfg.Load(second); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		want, _ := first.VaultKey()
+		got, _ := second.VaultKey()
+		if !bytes.Equal(want, got) {
+			t.Fatal("expected the vault key to survive saving the config again")
+		}
+	})
+
+	t.Run("should not replace a missing vault key that sealed secrets", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		vaultPath := t.TempDir()
+
+		if err := cfg.Save(vault.NewVaultWithMK([]byte("test-master-key")), vaultPath); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		secret, err := format.MarshalFileVersion(meta.MSK_FILE_VERSION_ENVELOPE, make([]byte, meta.MSK_SALT_SIZE), make([]byte, meta.MSK_NONCE_SIZE), []byte("sealed"))
+		if err != nil {
+			t.Fatalf("MarshalFileVersion failed: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(vaultPath, "github.msk"), secret, 0o600); err != nil {
+			t.Fatalf("failed to write secret: %v", err)
+		}
+
+		if err := os.Remove(cfg.VaultKeyPath()); err != nil {
+			t.Fatalf("failed to remove vault key: %v", err)
+		}
+
+		if _, err := cfg.Load(vault.NewVaultWithMK([]byte("test-master-key"))); !errors.Is(err, ErrVaultKeyLost) {
+			t.Fatalf("expected ErrVaultKeyLost, got %v", err)
+		}
+
+		if _, err := os.Stat(cfg.VaultKeyPath()); !errors.Is(err, os.ErrNotExist) {
+			t.Fatalf("expected no new vault key to be saved, got %v", err)
+		}
+	})
+
+	t.Run("should keep the old master password when the vault key cannot be saved", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		vaultPath := t.TempDir()
+
+		old := vault.NewVaultWithMK([]byte("old-master-key"))
+		if err := cfg.Save(old, vaultPath); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		code, err := vault.GenerateRecoveryCode()
+		if err != nil {
+			t.Fatalf("failed to generate code: %v", err)
+		}
+
+		if err := cfg.SaveRecovery(old, code, vaultPath); err != nil {
+			t.Fatalf("SaveRecovery failed: %v", err)
+		}
+
+		secret, err := format.MarshalFileVersion(meta.MSK_FILE_VERSION_ENVELOPE, make([]byte, meta.MSK_SALT_SIZE), make([]byte, meta.MSK_NONCE_SIZE), []byte("sealed"))
+		if err != nil {
+			t.Fatalf("MarshalFileVersion failed: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(vaultPath, "github.msk"), secret, 0o600); err != nil {
+			t.Fatalf("failed to write secret: %v", err)
+		}
+
+		// Without the vault key loaded, the new master password cannot unwrap
+		// the one on disk, so sealing the vault key fails.
+		if err := cfg.Save(vault.NewVaultWithMK([]byte("new-master-key")), vaultPath); !errors.Is(err, ErrVaultKeyLost) {
+			t.Fatalf("expected ErrVaultKeyLost, got %v", err)
+		}
+
+		reopened := vault.NewVaultWithMK([]byte("old-master-key"))
+		if _, err := cfg.Load(reopened); err != nil {
+			t.Fatalf("expected the old master password to still open the vault, got %v", err)
+		}
+
+		want, _ := old.VaultKey()
+		got, _ := reopened.VaultKey()
+		if !bytes.Equal(want, got) {
+			t.Fatal("expected the vault key to be unchanged")
+		}
+
+		if _, err := os.Stat(cfg.RecoveryPath()); err != nil {
+			t.Fatalf("expected the recovery file to be kept, got %v", err)
+		}
+	})
+}
+
+func TestRecovery(t *testing.T) {
+	t.Run("should load the vault key and vault path with the recovery code", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		mk := vault.NewVaultWithMK([]byte("test-master-key"))
+
+		if err := cfg.Save(mk, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		code, err := vault.GenerateRecoveryCode()
+		if err != nil {
+			t.Fatalf("failed to generate code: %v", err)
+		}
+
+		if err := cfg.SaveRecovery(mk, code, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("SaveRecovery failed: %v", err)
+		}
+
+		recovered := vault.NewVault()
+		vaultPath, err := cfg.LoadRecovery(recovered, code)
+		if err != nil {
+			t.Fatalf("LoadRecovery failed: %v", err)
+		}
+
+		if vaultPath != "/home/user/.msk/vault" {
+			t.Fatalf("expected the vault path back, got %q", vaultPath)
+		}
+
+		want, _ := mk.VaultKey()
+		got, _ := recovered.VaultKey()
+		if !bytes.Equal(want, got) {
+			t.Fatal("expected the recovered vault key to match")
+		}
+	})
+
+	t.Run("should not store the master password", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		mk := vault.NewVaultWithMK([]byte("test-master-key"))
+
+		if err := cfg.Save(mk, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		code, _ := vault.GenerateRecoveryCode()
+		if err := cfg.SaveRecovery(mk, code, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("SaveRecovery failed: %v", err)
+		}
+
+		recovered := vault.NewVault()
+		if _, err := cfg.LoadRecovery(recovered, code); err != nil {
+			t.Fatalf("LoadRecovery failed: %v", err)
+		}
+
+		if _, err := cfg.Load(recovered); err == nil {
+			t.Fatal("expected the recovered vault to be unable to open the config")
+		}
+	})
+
+	t.Run("should reject a recovery file that wrapped the master password", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		mk := vault.NewVaultWithMK([]byte("test-master-key"))
+
+		if err := cfg.Save(mk, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		code, _ := vault.GenerateRecoveryCode()
+		if err := cfg.SaveRecovery(mk, code, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("SaveRecovery failed: %v", err)
+		}
+
+		data, _ := os.ReadFile(cfg.RecoveryPath())
+		data[meta.MSK_MAGIC_SIZE] = meta.MSK_FILE_VERSION
+		if err := os.WriteFile(cfg.RecoveryPath(), data, 0o600); err != nil {
+			t.Fatalf("failed to rewrite recovery file: %v", err)
+		}
+
+		_, err := cfg.LoadRecovery(vault.NewVault(), code)
+		if !errors.Is(err, ErrRecoveryOutdated) {
+			t.Fatalf("expected ErrRecoveryOutdated, got %v", err)
+		}
+	})
+
+	t.Run("should drop the recovery file when the config is saved again", func(t *testing.T) {
+		cfg := newTestConfig(t)
+		mk := vault.NewVaultWithMK([]byte("test-master-key"))
+
+		if err := cfg.Save(mk, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		code, _ := vault.GenerateRecoveryCode()
+		if err := cfg.SaveRecovery(mk, code, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("SaveRecovery failed: %v", err)
+		}
+
+		if err := cfg.Save(mk, "/home/user/.msk/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		_, err := cfg.LoadRecovery(vault.NewVault(), code)
+		if !errors.Is(err, ErrRecoveryNotFound) {
+			t.Fatalf("expected ErrRecoveryNotFound, got %v", err)
+		}
+	})
 }
 
 func TestLoadWrongKey(t *testing.T) {
-	t.Run("should return ErrInvalidConfig with wrong key", func(t *testing.T) {
+	t.Run("should return ErrWrongMasterPassword with wrong key", func(t *testing.T) {
 		cfg := newTestConfig(t)
 
 		v := vault.NewVaultWithMK([]byte("correct-key"))
@@ -61,11 +358,58 @@ func TestLoadWrongKey(t *testing.T) {
 
 		v = vault.NewVaultWithMK([]byte("wrong-key"))
 
+		_, err = cfg.Load(v)
+		if !errors.Is(err, ErrWrongMasterPassword) {
+			t.Fatalf("expected ErrWrongMasterPassword, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrInvalidConfig for a corrupted config with the right key", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		v := vault.NewVaultWithMK([]byte("correct-key"))
+
+		if err := cfg.Save(v, "/some/path"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+
+		data[len(data)-1] ^= 0xFF
+		if err := os.WriteFile(cfg.Path, data, 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+
 		_, err = cfg.Load(v)
 		if !errors.Is(err, ErrInvalidConfig) {
 			t.Fatalf("expected ErrInvalidConfig, got %v", err)
 		}
 	})
+
+	t.Run("should add a verifier to configs saved without one", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		v := vault.NewVaultWithMK([]byte("correct-key"))
+
+		if err := cfg.Save(v, "/some/path"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		if err := os.Remove(cfg.VerifierPath()); err != nil {
+			t.Fatalf("failed to remove verifier: %v", err)
+		}
+
+		if _, err := cfg.Load(v); err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		if err := cfg.Verify(vault.NewVaultWithMK([]byte("wrong-key"))); !errors.Is(err, ErrWrongMasterPassword) {
+			t.Fatalf("expected ErrWrongMasterPassword, got %v", err)
+		}
+	})
 }
 
 func TestLoadNotFound(t *testing.T) {
@@ -81,6 +425,130 @@ func TestLoadNotFound(t *testing.T) {
 	})
 }
 
+// writeLegacyConfig replaces the config with one in the format used before
+// config files had their own magic, holding name and vaultPath.
+func writeLegacyConfig(t *testing.T, cfg *Config, v vault.Vault, name, vaultPath string) {
+	t.Helper()
+
+	data, err := os.ReadFile(cfg.Path)
+	if err != nil {
+		t.Fatalf("failed to read config: %v", err)
+	}
+
+	salt, _, _, err := format.UnmarshalConfigFile(data)
+	if err != nil {
+		t.Fatalf("failed to unmarshal config: %v", err)
+	}
+
+	payload, err := format.MarshalSecret(domain.Secret{Name: name, Password: []byte(vaultPath)})
+	if err != nil {
+		t.Fatalf("failed to marshal secret: %v", err)
+	}
+
+	sealed, err := v.EncryptWithSalt(salt, payload)
+	if err != nil {
+		t.Fatalf("failed to encrypt: %v", err)
+	}
+
+	legacy, err := format.MarshalFile(sealed.Salt, sealed.Nonce, sealed.CipherData)
+	if err != nil {
+		t.Fatalf("failed to marshal file: %v", err)
+	}
+
+	if err := os.WriteFile(cfg.Path, legacy, 0o600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+}
+
+func TestConfigFormat(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	t.Run("should write configs with their own magic", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.Save(vault.NewVaultWithMK([]byte("test-master-key")), "/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+
+		if !format.IsConfigFile(data) {
+			t.Fatalf("expected a config file, got %q", data[:meta.MSK_CONFIG_MAGIC_SIZE])
+		}
+	})
+
+	t.Run("should load a legacy config and upgrade it", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		v := vault.NewVaultWithMK([]byte("test-master-key"))
+		if err := cfg.Save(v, "/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		writeLegacyConfig(t, cfg, v, MSK_CONFIG_NAME, "/legacy/vault")
+
+		loaded, err := cfg.Load(vault.NewVaultWithMK([]byte("test-master-key")))
+		if err != nil {
+			t.Fatalf("Load failed: %v", err)
+		}
+
+		if loaded != "/legacy/vault" {
+			t.Fatalf("expected /legacy/vault, got %q", loaded)
+		}
+
+		data, err := os.ReadFile(cfg.Path)
+		if err != nil {
+			t.Fatalf("failed to read config: %v", err)
+		}
+
+		if !format.IsConfigFile(data) {
+			t.Fatal("expected the legacy config to be upgraded")
+		}
+
+		loaded, err = cfg.Load(vault.NewVaultWithMK([]byte("test-master-key")))
+		if err != nil || loaded != "/legacy/vault" {
+			t.Fatalf("expected /legacy/vault after the upgrade, got %q (%v)", loaded, err)
+		}
+	})
+
+	t.Run("should return ErrNotConfigFile for a secret file", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		v := vault.NewVaultWithMK([]byte("test-master-key"))
+		if err := cfg.Save(v, "/vault"); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+
+		writeLegacyConfig(t, cfg, v, "github", "hunter2")
+
+		_, err := cfg.Load(vault.NewVaultWithMK([]byte("test-master-key")))
+		if !errors.Is(err, ErrNotConfigFile) {
+			t.Fatalf("expected ErrNotConfigFile, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrNotConfigFile for an unrelated file", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := os.MkdirAll(filepath.Dir(cfg.Path), 0o700); err != nil {
+			t.Fatalf("failed to create config dir: %v", err)
+		}
+
+		if err := os.WriteFile(cfg.Path, []byte("vault = /home/user/vault\n"), 0o600); err != nil {
+			t.Fatalf("failed to write config: %v", err)
+		}
+
+		_, err := cfg.Load(vault.NewVaultWithMK([]byte("test-master-key")))
+		if !errors.Is(err, ErrNotConfigFile) {
+			t.Fatalf("expected ErrNotConfigFile, got %v", err)
+		}
+	})
+}
+
 func TestExists(t *testing.T) {
 	t.Run("should return false when config does not exist", func(t *testing.T) {
 		cfg := newTestConfig(t)
@@ -132,3 +600,323 @@ func TestDefaultVaultPath(t *testing.T) {
 		}
 	})
 }
+
+func TestResolveVaultPath(t *testing.T) {
+	logger.SetOutput(io.Discard)
+	t.Cleanup(func() { logger.SetOutput(os.Stderr) })
+
+	t.Run("should keep absolute paths", func(t *testing.T) {
+		dir := filepath.Join(t.TempDir(), "vault")
+
+		path, err := ResolveVaultPath(dir)
+		if err != nil || path != dir {
+			t.Fatalf("expected %q, got %q (%v)", dir, path, err)
+		}
+	})
+
+	t.Run("should resolve relative paths against the working directory", func(t *testing.T) {
+		dir := t.TempDir()
+		t.Chdir(dir)
+
+		path, err := ResolveVaultPath("vault")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		wd, _ := os.Getwd()
+		if path != filepath.Join(wd, "vault") {
+			t.Fatalf("expected %q, got %q", filepath.Join(wd, "vault"), path)
+		}
+	})
+
+	t.Run("should expand ~ to the home directory", func(t *testing.T) {
+		home := t.TempDir()
+		t.Setenv("HOME", home)
+		t.Setenv("USERPROFILE", home)
+
+		path, err := ResolveVaultPath("~/vault")
+		if err != nil || path != filepath.Join(home, "vault") {
+			t.Fatalf("expected %q, got %q (%v)", filepath.Join(home, "vault"), path, err)
+		}
+	})
+
+	t.Run("should reject an empty path", func(t *testing.T) {
+		for _, path := range []string{"", " \t"} {
+			_, err := ResolveVaultPath(path)
+			if !errors.Is(err, ErrInvalidVaultPath) {
+				t.Fatalf("%q: expected ErrInvalidVaultPath, got %v", path, err)
+			}
+		}
+	})
+
+	t.Run("should reject an existing file", func(t *testing.T) {
+		file := filepath.Join(t.TempDir(), "vault")
+		if err := os.WriteFile(file, nil, 0o600); err != nil {
+			t.Fatalf("failed to write file: %v", err)
+		}
+
+		_, err := ResolveVaultPath(file)
+		if !errors.Is(err, ErrInvalidVaultPath) {
+			t.Fatalf("expected ErrInvalidVaultPath, got %v", err)
+		}
+	})
+}
+
+func TestSettings(t *testing.T) {
+	t.Run("should return defaults when the settings file does not exist", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		settings, err := cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if settings != DefaultSettings() {
+			t.Fatalf("expected default settings, got %+v", settings)
+		}
+	})
+
+	t.Run("should persist a setting", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.SaveSetting("allow-loose-modes", "true"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		if err := cfg.SaveSetting("file-mode", "0640"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		settings, err := cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if settings.FileMode != 0o640 || !settings.AllowLooseModes {
+			t.Fatalf("expected file mode 0640 with loose modes allowed, got %+v", settings)
+		}
+	})
+
+	t.Run("should reject loose modes without the opt-in", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		err := cfg.SaveSetting("dir-mode", "0750")
+		if !errors.Is(err, ErrLooseModes) {
+			t.Fatalf("expected ErrLooseModes, got %v", err)
+		}
+	})
+
+	t.Run("should reject invalid modes even with the opt-in", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.SaveSetting("allow-loose-modes", "true"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		inputs := []string{"0666", "0400", "rw-r-----", "01777"}
+
+		for _, input := range inputs {
+			err := cfg.SaveSetting("file-mode", input)
+			if !errors.Is(err, ErrInvalidSetting) {
+				t.Fatalf("SaveSetting(file-mode, %q): expected ErrInvalidSetting, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should validate password retries", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.SaveSetting("password-retries", "5"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		settings, err := cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if settings.PasswordRetries != 5 {
+			t.Fatalf("expected 5 retries, got %d", settings.PasswordRetries)
+		}
+
+		for _, input := range []string{"0", "-1", "11", "many"} {
+			err := cfg.SaveSetting("password-retries", input)
+			if !errors.Is(err, ErrInvalidSetting) {
+				t.Fatalf("SaveSetting(password-retries, %q): expected ErrInvalidSetting, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should parse backup retention limits", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.SaveSetting("backup-keep", "0"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		if err := cfg.SaveSetting("backup-max-age", "30d"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		settings, err := cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if settings.BackupKeep != 0 || settings.BackupMaxAge != 30*24*time.Hour {
+			t.Fatalf("expected no count limit and 30 days, got %d and %v", settings.BackupKeep, settings.BackupMaxAge)
+		}
+
+		if value, _ := settings.Get("backup-max-age"); value != "30d" {
+			t.Fatalf("expected backup-max-age to read back as 30d, got %q", value)
+		}
+
+		for key, input := range map[string]string{"backup-keep": "-1", "backup-max-age": "soon"} {
+			err := cfg.SaveSetting(key, input)
+			if !errors.Is(err, ErrInvalidSetting) {
+				t.Fatalf("SaveSetting(%s, %q): expected ErrInvalidSetting, got %v", key, input, err)
+			}
+		}
+	})
+
+	t.Run("should enable and disable the clipboard", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		if err := cfg.SaveSetting("clipboard", "disabled"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		settings, err := cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if !settings.ClipboardDisabled {
+			t.Fatal("expected the clipboard to be disabled")
+		}
+
+		if value, _ := settings.Get("clipboard"); value != "disabled" {
+			t.Fatalf("expected clipboard to read back as disabled, got %q", value)
+		}
+
+		if err := cfg.SaveSetting("clipboard", "off"); !errors.Is(err, ErrInvalidSetting) {
+			t.Fatalf("expected ErrInvalidSetting, got %v", err)
+		}
+	})
+
+	t.Run("should parse the max file size with a unit", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		settings, err := cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if value, _ := settings.Get("max-file-size"); value != "16MB" {
+			t.Fatalf("expected the default to read as 16MB, got %q", value)
+		}
+
+		if err := cfg.SaveSetting("max-file-size", "64kb"); err != nil {
+			t.Fatalf("SaveSetting failed: %v", err)
+		}
+
+		settings, err = cfg.LoadSettings()
+		if err != nil {
+			t.Fatalf("LoadSettings failed: %v", err)
+		}
+
+		if settings.MaxFileSize != 64<<10 {
+			t.Fatalf("expected 65536 bytes, got %d", settings.MaxFileSize)
+		}
+
+		for _, input := range []string{"0", "1000", "2GB", "big", "-1MB"} {
+			err := cfg.SaveSetting("max-file-size", input)
+			if !errors.Is(err, ErrInvalidSetting) {
+				t.Fatalf("SaveSetting(max-file-size, %q): expected ErrInvalidSetting, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should return ErrUnknownSetting for unknown keys", func(t *testing.T) {
+		cfg := newTestConfig(t)
+
+		err := cfg.SaveSetting("colour", "blue")
+		if !errors.Is(err, ErrUnknownSetting) {
+			t.Fatalf("expected ErrUnknownSetting, got %v", err)
+		}
+	})
+}
+
+func TestNewConfigPath(t *testing.T) {
+	t.Run("should use the given path", func(t *testing.T) {
+		newTestConfig(t)
+		t.Setenv(MSK_CONFIG_ENV, filepath.Join(t.TempDir(), "env.msk"))
+
+		path := filepath.Join(t.TempDir(), "custom.msk")
+
+		cfg, err := NewConfig(path)
+		if err != nil {
+			t.Fatalf("NewConfig failed: %v", err)
+		}
+
+		if cfg.Path != path {
+			t.Fatalf("expected %q, got %q", path, cfg.Path)
+		}
+	})
+
+	t.Run("should fall back to MSK_CONFIG", func(t *testing.T) {
+		newTestConfig(t)
+
+		path := filepath.Join(t.TempDir(), "env.msk")
+		t.Setenv(MSK_CONFIG_ENV, path)
+
+		cfg, err := NewConfig("")
+		if err != nil {
+			t.Fatalf("NewConfig failed: %v", err)
+		}
+
+		if cfg.Path != path {
+			t.Fatalf("expected %q, got %q", path, cfg.Path)
+		}
+	})
+}
+
+func TestConfigPathResolution(t *testing.T) {
+	t.Run("should prefer XDG_CONFIG_HOME on unix", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("XDG_CONFIG_HOME is not used on windows")
+		}
+
+		newTestConfig(t)
+
+		xdg := t.TempDir()
+		t.Setenv("XDG_CONFIG_HOME", xdg)
+
+		cfg, err := NewConfig("")
+		if err != nil {
+			t.Fatalf("NewConfig failed: %v", err)
+		}
+
+		expected := filepath.Join(xdg, "msk", "config.msk")
+		if cfg.Path != expected {
+			t.Fatalf("expected %q, got %q", expected, cfg.Path)
+		}
+	})
+
+	t.Run("should prefer MSK_CONFIG over XDG_CONFIG_HOME", func(t *testing.T) {
+		newTestConfig(t)
+
+		path := filepath.Join(t.TempDir(), "env.msk")
+		t.Setenv(MSK_CONFIG_ENV, path)
+		t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+
+		cfg, err := NewConfig("")
+		if err != nil {
+			t.Fatalf("NewConfig failed: %v", err)
+		}
+
+		if cfg.Path != path {
+			t.Fatalf("expected %q, got %q", path, cfg.Path)
+		}
+	})
+}
@@ -0,0 +1,378 @@
+package config
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/durationx"
+	"github.com/amauribechtoldjr/msk/internal/files"
+	"github.com/amauribechtoldjr/msk/internal/storage"
+)
+
+var (
+	ErrUnknownSetting = errors.New("unknown setting")
+	ErrInvalidSetting = errors.New("invalid setting value")
+	ErrLooseModes     = errors.New("file or directory mode is more permissive than 0600/0700, set allow-loose-modes to true to allow it")
+)
+
+const (
+	SETTINGS_FILE_NAME = "settings"
+
+	DEFAULT_PASSWORD_RETRIES = 3
+	MAX_PASSWORD_RETRIES     = 10
+
+	DEFAULT_BACKUP_KEEP = 10
+	MAX_BACKUP_KEEP     = 1000
+
+	MIN_MAX_FILE_SIZE = int64(1 << 10)
+	MAX_MAX_FILE_SIZE = int64(1 << 30)
+)
+
+// Settings holds non-secret preferences. They live in a plain "key = value"
+// file next to the config so they can be read before the master password is
+// entered.
+type Settings struct {
+	FileMode        os.FileMode
+	DirMode         os.FileMode
+	AllowLooseModes bool
+	PasswordRetries int
+	// CaseSensitiveNames keeps the original case of secret names on disk.
+	// Secrets stored before enabling it keep their lowercase file names and
+	// must be looked up in lowercase.
+	CaseSensitiveNames bool
+	// ClipboardRestore puts back the clipboard's previous text after the
+	// clear countdown instead of leaving it empty.
+	ClipboardRestore bool
+	// ClipboardDisabled keeps msk from ever touching the clipboard: passwords
+	// that would be copied are printed instead.
+	ClipboardDisabled bool
+	// BackupKeep and BackupMaxAge cap the backups retained per secret by
+	// update --backup and del --backup. Zero means no limit.
+	BackupKeep   int
+	BackupMaxAge time.Duration
+	// MaxFileSize is the largest secret file, in bytes, msk reads.
+	MaxFileSize int64
+}
+
+type settingDef struct {
+	set func(s *Settings, value string) error
+	get func(s Settings) string
+}
+
+var settingDefs = map[string]settingDef{
+	"file-mode": {
+		set: func(s *Settings, value string) error { return parseMode(value, &s.FileMode) },
+		get: func(s Settings) string { return formatMode(s.FileMode) },
+	},
+	"dir-mode": {
+		set: func(s *Settings, value string) error { return parseMode(value, &s.DirMode) },
+		get: func(s Settings) string { return formatMode(s.DirMode) },
+	},
+	"allow-loose-modes": {
+		set: func(s *Settings, value string) error { return parseBool(value, &s.AllowLooseModes) },
+		get: func(s Settings) string { return strconv.FormatBool(s.AllowLooseModes) },
+	},
+	"case-sensitive-names": {
+		set: func(s *Settings, value string) error { return parseBool(value, &s.CaseSensitiveNames) },
+		get: func(s Settings) string { return strconv.FormatBool(s.CaseSensitiveNames) },
+	},
+	"clipboard": {
+		set: func(s *Settings, value string) error {
+			switch value {
+			case "enabled":
+				s.ClipboardDisabled = false
+			case "disabled":
+				s.ClipboardDisabled = true
+			default:
+				return fmt.Errorf("expected enabled or disabled, got %q", value)
+			}
+
+			return nil
+		},
+		get: func(s Settings) string {
+			if s.ClipboardDisabled {
+				return "disabled"
+			}
+
+			return "enabled"
+		},
+	},
+	"clipboard-restore": {
+		set: func(s *Settings, value string) error { return parseBool(value, &s.ClipboardRestore) },
+		get: func(s Settings) string { return strconv.FormatBool(s.ClipboardRestore) },
+	},
+	"backup-keep": {
+		set: func(s *Settings, value string) error {
+			return parseInt(value, 0, MAX_BACKUP_KEEP, &s.BackupKeep)
+		},
+		get: func(s Settings) string { return strconv.Itoa(s.BackupKeep) },
+	},
+	"backup-max-age": {
+		set: func(s *Settings, value string) error {
+			parsed, err := durationx.Parse(value)
+			if err != nil {
+				return err
+			}
+
+			s.BackupMaxAge = parsed
+			return nil
+		},
+		get: func(s Settings) string { return durationx.Format(s.BackupMaxAge) },
+	},
+	"max-file-size": {
+		set: func(s *Settings, value string) error {
+			return parseSize(value, MIN_MAX_FILE_SIZE, MAX_MAX_FILE_SIZE, &s.MaxFileSize)
+		},
+		get: func(s Settings) string { return formatSize(s.MaxFileSize) },
+	},
+	"password-retries": {
+		set: func(s *Settings, value string) error {
+			return parseInt(value, 1, MAX_PASSWORD_RETRIES, &s.PasswordRetries)
+		},
+		get: func(s Settings) string { return strconv.Itoa(s.PasswordRetries) },
+	},
+}
+
+func DefaultSettings() Settings {
+	return Settings{
+		FileMode:        storage.DefaultFileMode,
+		DirMode:         storage.DefaultDirMode,
+		PasswordRetries: DEFAULT_PASSWORD_RETRIES,
+		BackupKeep:      DEFAULT_BACKUP_KEEP,
+		MaxFileSize:     storage.DefaultMaxFileSize,
+	}
+}
+
+// SettingKeys returns every known setting name in a stable order.
+func SettingKeys() []string {
+	keys := make([]string, 0, len(settingDefs))
+	for key := range settingDefs {
+		keys = append(keys, key)
+	}
+
+	slices.Sort(keys)
+	return keys
+}
+
+// Get returns the textual value of a setting.
+func (s Settings) Get(key string) (string, error) {
+	def, ok := settingDefs[key]
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+
+	return def.get(s), nil
+}
+
+// Set parses and applies a setting value.
+func (s *Settings) Set(key, value string) error {
+	def, ok := settingDefs[key]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrUnknownSetting, key)
+	}
+
+	if err := def.set(s, value); err != nil {
+		return fmt.Errorf("%w for %s: %v", ErrInvalidSetting, key, err)
+	}
+
+	return nil
+}
+
+// Validate rejects modes msk cannot work with and, unless explicitly allowed,
+// modes that grant access beyond the owner. World-writable modes are never
+// accepted.
+func (s Settings) Validate() error {
+	if s.FileMode&0o600 != 0o600 || s.DirMode&0o700 != 0o700 {
+		return fmt.Errorf("%w: the owner needs read and write access", ErrInvalidSetting)
+	}
+
+	if s.FileMode&0o002 != 0 || s.DirMode&0o002 != 0 {
+		return fmt.Errorf("%w: world-writable modes are not allowed", ErrInvalidSetting)
+	}
+
+	if !s.AllowLooseModes && (s.FileMode&^storage.DefaultFileMode != 0 || s.DirMode&^storage.DefaultDirMode != 0) {
+		return ErrLooseModes
+	}
+
+	return nil
+}
+
+// CheckPermissions returns storage.ErrLoosePermissions when the config file,
+// or the verifier, vault key or recovery file beside it, grants more access
+// than the configured file mode. It needs no master password, so it can run
+// before the vault is unlocked.
+func (c *Config) CheckPermissions() error {
+	settings, err := c.LoadSettings()
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	for _, path := range []string{c.Path, c.VerifierPath(), c.VaultKeyPath(), c.RecoveryPath()} {
+		errs = append(errs, storage.CheckPermissions(path, settings.FileMode))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (c *Config) SettingsPath() string {
+	return filepath.Join(filepath.Dir(c.Path), SETTINGS_FILE_NAME)
+}
+
+// LoadSettings reads the settings file, falling back to defaults for missing
+// keys or a missing file. Unknown keys are ignored so settings written by a
+// newer version stay readable.
+func (c *Config) LoadSettings() (Settings, error) {
+	settings := DefaultSettings()
+
+	data, err := files.ReadFile(c.SettingsPath(), os.ErrNotExist)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+
+	if err != nil {
+		return settings, err
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return settings, fmt.Errorf("%w: malformed line %q in %s", ErrInvalidSetting, line, c.SettingsPath())
+		}
+
+		err := settings.Set(strings.TrimSpace(key), strings.TrimSpace(value))
+		if err != nil && !errors.Is(err, ErrUnknownSetting) {
+			return settings, err
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return settings, err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return settings, err
+	}
+
+	return settings, nil
+}
+
+// SaveSetting validates and persists a single setting, keeping the others.
+func (c *Config) SaveSetting(key, value string) error {
+	settings, err := c.LoadSettings()
+	if err != nil && !errors.Is(err, ErrLooseModes) {
+		return err
+	}
+
+	if err := settings.Set(key, value); err != nil {
+		return err
+	}
+
+	if err := settings.Validate(); err != nil {
+		return err
+	}
+
+	var buf bytes.Buffer
+	for _, key := range SettingKeys() {
+		value, _ := settings.Get(key)
+		fmt.Fprintf(&buf, "%s = %s\n", key, value)
+	}
+
+	// Unlike the other config files, the settings file does not follow
+	// file-mode and dir-mode: it is what defines them, so a loose value could
+	// otherwise open up the very file that would have to tighten it again.
+	if err := os.MkdirAll(filepath.Dir(c.SettingsPath()), 0o700); err != nil {
+		return err
+	}
+
+	return files.WriteAtomicFile(c.SettingsPath(), buf.Bytes(), 0o600)
+}
+
+func parseMode(value string, mode *os.FileMode) error {
+	parsed, err := strconv.ParseUint(value, 8, 32)
+	if err != nil || parsed > 0o777 {
+		return fmt.Errorf("expected an octal mode such as 0600, got %q", value)
+	}
+
+	*mode = os.FileMode(parsed)
+	return nil
+}
+
+func formatMode(mode os.FileMode) string {
+	return fmt.Sprintf("%04o", uint32(mode))
+}
+
+func parseBool(value string, target *bool) error {
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return fmt.Errorf("expected true or false, got %q", value)
+	}
+
+	*target = parsed
+	return nil
+}
+
+func parseInt(value string, minValue, maxValue int, target *int) error {
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed < minValue || parsed > maxValue {
+		return fmt.Errorf("expected a number from %d to %d, got %q", minValue, maxValue, value)
+	}
+
+	*target = parsed
+	return nil
+}
+
+// sizeUnits are the suffixes parseSize accepts, largest first so formatSize
+// picks the shortest form.
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30},
+	{"MB", 1 << 20},
+	{"KB", 1 << 10},
+}
+
+// parseSize accepts a byte count with an optional KB, MB or GB suffix, in
+// multiples of 1024, e.g. "16MB".
+func parseSize(value string, minValue, maxValue int64, target *int64) error {
+	number, unit := strings.ToUpper(value), int64(1)
+	for _, u := range sizeUnits {
+		if trimmed, ok := strings.CutSuffix(number, u.suffix); ok {
+			number, unit = trimmed, u.bytes
+			break
+		}
+	}
+
+	parsed, err := strconv.ParseInt(strings.TrimSpace(number), 10, 64)
+	if err != nil || parsed < minValue/unit || parsed > maxValue/unit || parsed*unit < minValue {
+		return fmt.Errorf("expected a size from %s to %s, got %q", formatSize(minValue), formatSize(maxValue), value)
+	}
+
+	*target = parsed * unit
+	return nil
+}
+
+func formatSize(size int64) string {
+	for _, u := range sizeUnits {
+		if size >= u.bytes && size%u.bytes == 0 {
+			return strconv.FormatInt(size/u.bytes, 10) + u.suffix
+		}
+	}
+
+	return strconv.FormatInt(size, 10)
+}
@@ -1,6 +1,71 @@
 package domain
 
+import "time"
+
+type SecretType uint8
+
+const (
+	SecretTypePassword SecretType = iota
+)
+
+// Login fields name the values `msk login` can copy.
+const (
+	LoginFieldUsername = "username"
+	LoginFieldPassword = "password"
+	LoginFieldURL      = "url"
+	LoginFieldNotes    = "notes"
+)
+
+// DefaultLoginFields is the login order of secrets without LoginFields.
+var DefaultLoginFields = []string{LoginFieldUsername, LoginFieldPassword}
+
 type Secret struct {
-	Name     string
-	Password []byte
+	Name      string
+	Password  []byte
+	Username  string
+	URL       string
+	Notes     string
+	Tags      []string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Type      SecretType
+	// LoginFields is the order `msk login` copies fields in, such as
+	// ["url", "username", "password"]. Empty means DefaultLoginFields.
+	LoginFields []string
+}
+
+// IsLoginField reports whether field can be listed in LoginFields.
+func IsLoginField(field string) bool {
+	switch field {
+	case LoginFieldUsername, LoginFieldPassword, LoginFieldURL, LoginFieldNotes:
+		return true
+	}
+
+	return false
+}
+
+// LoginOrder returns LoginFields, or DefaultLoginFields when it is empty.
+func (s Secret) LoginOrder() []string {
+	if len(s.LoginFields) == 0 {
+		return DefaultLoginFields
+	}
+
+	return s.LoginFields
+}
+
+// LoginValue returns the value of a login field, empty when it is unset or
+// unknown. The password is returned as is, not copied.
+func (s Secret) LoginValue(field string) []byte {
+	switch field {
+	case LoginFieldUsername:
+		return []byte(s.Username)
+	case LoginFieldPassword:
+		return s.Password
+	case LoginFieldURL:
+		return []byte(s.URL)
+	case LoginFieldNotes:
+		return []byte(s.Notes)
+	}
+
+	return nil
 }
@@ -0,0 +1,103 @@
+package domain_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/jsonexport"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+// roundTripSecrets cover every field set and every optional field empty. A
+// field added to domain.Secret should be set in "every field".
+func roundTripSecrets() map[string]domain.Secret {
+	created := time.Date(2024, 2, 29, 23, 59, 58, 123456789, time.UTC)
+
+	return map[string]domain.Secret{
+		"every field": {
+			Name:        "work/GitHub",
+			Password:    []byte("p@ss \"word\"\n\x00é"),
+			Username:    "octocat",
+			URL:         "https://github.com/login",
+			Notes:       "recovery codes\nin the safe",
+			Tags:        []string{"work", "git"},
+			CreatedAt:   created,
+			UpdatedAt:   created.Add(36 * time.Hour),
+			Type:        domain.SecretTypePassword,
+			LoginFields: []string{"url", "username", "password"},
+		},
+		"only name and password": {
+			Name:     "bank",
+			Password: []byte("hunter2"),
+		},
+		"longest fields": {
+			Name:     strings.Repeat("n", meta.SECRET_MAX_FIELD_LENGTH),
+			Password: bytes.Repeat([]byte("p"), meta.SECRET_MAX_FIELD_LENGTH),
+			Notes:    strings.Repeat("x", meta.SECRET_MAX_FIELD_LENGTH),
+		},
+	}
+}
+
+func TestSecretRoundTrip(t *testing.T) {
+	t.Run("should set every field in the every field case", func(t *testing.T) {
+		secret := reflect.ValueOf(roundTripSecrets()["every field"])
+
+		for i := range secret.NumField() {
+			field := secret.Type().Field(i)
+
+			// SecretTypePassword is the only type and the zero value.
+			if field.Name == "Type" {
+				continue
+			}
+
+			if secret.Field(i).IsZero() {
+				t.Fatalf("field %s is not set, add it to roundTripSecrets", field.Name)
+			}
+		}
+	})
+
+	for name, secret := range roundTripSecrets() {
+		t.Run("should round-trip through the binary format with "+name, func(t *testing.T) {
+			data, err := format.MarshalSecret(secret)
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+
+			got, err := format.UnmarshalSecret(data)
+			if err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(got, secret) {
+				t.Fatalf("expected %+v, got %+v", secret, got)
+			}
+		})
+
+		t.Run("should round-trip through the JSON export with "+name, func(t *testing.T) {
+			data, err := json.Marshal(jsonexport.FromSecret(secret))
+			if err != nil {
+				t.Fatalf("marshal failed: %v", err)
+			}
+
+			var exported jsonexport.Secret
+			if err := json.Unmarshal(data, &exported); err != nil {
+				t.Fatalf("unmarshal failed: %v", err)
+			}
+
+			got, err := exported.Secret()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, secret) {
+				t.Fatalf("expected %+v, got %+v", secret, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package dotenv
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrSyntax = errors.New("invalid dotenv line")
+
+// Entry is one KEY=VALUE pair. Value is kept as bytes so callers can wipe it.
+type Entry struct {
+	Line  int
+	Key   string
+	Value []byte
+}
+
+// Parse reads dotenv content. Blank lines and lines starting with "#" are
+// skipped, an optional "export " prefix is allowed, single-quoted values are
+// taken literally, double-quoted values support \n, \t, \", \\ and \$ escapes,
+// and unquoted values end at " #" comments and are trimmed.
+func Parse(r io.Reader) ([]Entry, error) {
+	var entries []Entry
+
+	scanner := bufio.NewScanner(r)
+	lineNumber := 0
+
+	for scanner.Scan() {
+		lineNumber++
+
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		line = bytes.TrimPrefix(line, []byte("export "))
+
+		key, rawValue, ok := bytes.Cut(line, []byte("="))
+		if !ok {
+			return nil, fmt.Errorf("%w on line %d: missing '='", ErrSyntax, lineNumber)
+		}
+
+		trimmedKey := strings.TrimSpace(string(key))
+		if trimmedKey == "" {
+			return nil, fmt.Errorf("%w on line %d: missing key", ErrSyntax, lineNumber)
+		}
+
+		value, err := parseValue(bytes.TrimSpace(rawValue))
+		if err != nil {
+			return nil, fmt.Errorf("%w on line %d: %v", ErrSyntax, lineNumber, err)
+		}
+
+		entries = append(entries, Entry{Line: lineNumber, Key: trimmedKey, Value: value})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return entries, nil
+}
+
+func parseValue(raw []byte) ([]byte, error) {
+	if len(raw) == 0 {
+		return []byte{}, nil
+	}
+
+	switch raw[0] {
+	case '\'':
+		end := bytes.IndexByte(raw[1:], '\'')
+		if end < 0 {
+			return nil, errors.New("unterminated single quote")
+		}
+		return bytes.Clone(raw[1 : end+1]), nil
+	case '"':
+		return parseDoubleQuoted(raw[1:])
+	}
+
+	if comment := bytes.Index(raw, []byte(" #")); comment >= 0 {
+		raw = raw[:comment]
+	}
+
+	return bytes.Clone(bytes.TrimSpace(raw)), nil
+}
+
+func parseDoubleQuoted(raw []byte) ([]byte, error) {
+	value := make([]byte, 0, len(raw))
+
+	for i := 0; i < len(raw); i++ {
+		switch c := raw[i]; c {
+		case '"':
+			return value, nil
+		case '\\':
+			if i+1 == len(raw) {
+				return nil, errors.New("unterminated double quote")
+			}
+			i++
+			switch raw[i] {
+			case 'n':
+				value = append(value, '\n')
+			case 't':
+				value = append(value, '\t')
+			case '"', '\\', '$':
+				value = append(value, raw[i])
+			default:
+				value = append(value, '\\', raw[i])
+			}
+		default:
+			value = append(value, c)
+		}
+	}
+
+	return nil, errors.New("unterminated double quote")
+}
@@ -0,0 +1,63 @@
+package dotenv
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("should parse keys, quotes and comments", func(t *testing.T) {
+		input := strings.Join([]string{
+			"# database",
+			"",
+			"DB_USER=admin",
+			"export DB_PASSWORD = 's3cr#t $HOME' ",
+			`API_TOKEN="line\nbreak \"quoted\""`,
+			"PLAIN=value # trailing comment",
+			"EMPTY=",
+		}, "\n")
+
+		entries, err := Parse(strings.NewReader(input))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expected := []struct {
+			key   string
+			value string
+		}{
+			{"DB_USER", "admin"},
+			{"DB_PASSWORD", "s3cr#t $HOME"},
+			{"API_TOKEN", "line\nbreak \"quoted\""},
+			{"PLAIN", "value"},
+			{"EMPTY", ""},
+		}
+
+		if len(entries) != len(expected) {
+			t.Fatalf("expected %d entries, got %d", len(expected), len(entries))
+		}
+
+		for i, e := range expected {
+			if entries[i].Key != e.key || string(entries[i].Value) != e.value {
+				t.Fatalf("entry %d: expected %s=%q, got %s=%q", i, e.key, e.value, entries[i].Key, entries[i].Value)
+			}
+		}
+	})
+
+	t.Run("should return ErrSyntax for malformed lines", func(t *testing.T) {
+		inputs := []string{
+			"NO_EQUALS",
+			"=value",
+			"KEY='unterminated",
+			`KEY="unterminated`,
+		}
+
+		for _, input := range inputs {
+			_, err := Parse(strings.NewReader(input))
+			if !errors.Is(err, ErrSyntax) {
+				t.Fatalf("Parse(%q): expected ErrSyntax, got %v", input, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package durationx
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrEmpty    = errors.New("duration cannot be empty")
+	ErrNegative = errors.New("duration cannot be negative")
+	ErrOverflow = errors.New("duration is too large")
+	ErrInvalid  = errors.New("invalid duration")
+)
+
+const (
+	Day  = 24 * time.Hour
+	Week = 7 * Day
+)
+
+var units = map[byte]time.Duration{
+	's': time.Second,
+	'm': time.Minute,
+	'h': time.Hour,
+	'd': Day,
+	'w': Week,
+}
+
+// Parse parses a non-negative duration made of whole numbers followed by one
+// of the units s, m, h, d or w, such as "90d", "2w" or "1d12h". Unlike
+// time.ParseDuration it understands days and weeks. A bare "0" is accepted;
+// any other number must carry a unit.
+func Parse(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, ErrEmpty
+	}
+
+	if value == "0" {
+		return 0, nil
+	}
+
+	if value[0] == '-' {
+		return 0, fmt.Errorf("%w: %q", ErrNegative, value)
+	}
+
+	var total time.Duration
+
+	for rest := value; rest != ""; {
+		digits := 0
+		for digits < len(rest) && rest[digits] >= '0' && rest[digits] <= '9' {
+			digits++
+		}
+
+		if digits == 0 || digits == len(rest) {
+			return 0, fmt.Errorf("%w: %q", ErrInvalid, value)
+		}
+
+		unit, ok := units[rest[digits]]
+		if !ok {
+			return 0, fmt.Errorf("%w: unknown unit %q in %q", ErrInvalid, rest[digits], value)
+		}
+
+		count, err := strconv.ParseInt(rest[:digits], 10, 64)
+		if err != nil || count > math.MaxInt64/int64(unit) {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, value)
+		}
+
+		part := time.Duration(count) * unit
+		if total > math.MaxInt64-part {
+			return 0, fmt.Errorf("%w: %q", ErrOverflow, value)
+		}
+
+		total += part
+		rest = rest[digits+1:]
+	}
+
+	return total, nil
+}
+
+// Format renders d in the form Parse accepts, using days, hours, minutes and
+// seconds, e.g. "90d" or "1d12h". Sub-second parts are dropped.
+func Format(d time.Duration) string {
+	if d < time.Second {
+		return "0"
+	}
+
+	var b strings.Builder
+
+	for _, unit := range []byte{'d', 'h', 'm', 's'} {
+		count := d / units[unit]
+		if count == 0 {
+			continue
+		}
+
+		b.WriteString(strconv.FormatInt(int64(count), 10))
+		b.WriteByte(unit)
+		d -= count * units[unit]
+	}
+
+	return b.String()
+}
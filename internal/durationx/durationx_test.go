@@ -0,0 +1,133 @@
+package durationx
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	t.Run("should parse single units", func(t *testing.T) {
+		cases := map[string]time.Duration{
+			"30s": 30 * time.Second,
+			"15m": 15 * time.Minute,
+			"12h": 12 * time.Hour,
+			"90d": 90 * 24 * time.Hour,
+			"2w":  14 * 24 * time.Hour,
+			"0s":  0,
+			"0":   0,
+		}
+
+		for input, expected := range cases {
+			got, err := Parse(input)
+			if err != nil {
+				t.Fatalf("Parse(%q): expected no error, got %v", input, err)
+			}
+
+			if got != expected {
+				t.Fatalf("Parse(%q): expected %v, got %v", input, expected, got)
+			}
+		}
+	})
+
+	t.Run("should parse combined units", func(t *testing.T) {
+		cases := map[string]time.Duration{
+			"1d12h":    36 * time.Hour,
+			"1w2d":     9 * 24 * time.Hour,
+			"1h30m15s": time.Hour + 30*time.Minute + 15*time.Second,
+			"2h2h":     4 * time.Hour,
+		}
+
+		for input, expected := range cases {
+			got, err := Parse(input)
+			if err != nil {
+				t.Fatalf("Parse(%q): expected no error, got %v", input, err)
+			}
+
+			if got != expected {
+				t.Fatalf("Parse(%q): expected %v, got %v", input, expected, got)
+			}
+		}
+	})
+
+	t.Run("should return ErrEmpty for empty input", func(t *testing.T) {
+		if _, err := Parse(""); !errors.Is(err, ErrEmpty) {
+			t.Fatalf("expected ErrEmpty, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrNegative for negative durations", func(t *testing.T) {
+		for _, input := range []string{"-1d", "-30s", "-0"} {
+			if _, err := Parse(input); !errors.Is(err, ErrNegative) {
+				t.Fatalf("Parse(%q): expected ErrNegative, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should return ErrOverflow for durations beyond the range", func(t *testing.T) {
+		inputs := []string{
+			"99999999999999999999s",
+			"15251w",
+			"106752d",
+			"106751d24h",
+		}
+
+		for _, input := range inputs {
+			if _, err := Parse(input); !errors.Is(err, ErrOverflow) {
+				t.Fatalf("Parse(%q): expected ErrOverflow, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should return ErrInvalid for malformed durations", func(t *testing.T) {
+		inputs := []string{
+			"10",
+			"d",
+			"1y",
+			"1.5h",
+			"1d12",
+			"1 d",
+			"+1d",
+			"1D",
+		}
+
+		for _, input := range inputs {
+			if _, err := Parse(input); !errors.Is(err, ErrInvalid) {
+				t.Fatalf("Parse(%q): expected ErrInvalid, got %v", input, err)
+			}
+		}
+	})
+}
+
+func TestFormat(t *testing.T) {
+	t.Run("should format durations with the largest units first", func(t *testing.T) {
+		cases := map[time.Duration]string{
+			0:                       "0",
+			90 * Day:                "90d",
+			Day + 12*time.Hour:      "1d12h",
+			90 * time.Minute:        "1h30m",
+			time.Second:             "1s",
+			2*Week + 5*time.Second:  "14d5s",
+			1500 * time.Millisecond: "1s",
+		}
+
+		for d, want := range cases {
+			if got := Format(d); got != want {
+				t.Fatalf("Format(%v): expected %q, got %q", d, want, got)
+			}
+		}
+	})
+
+	t.Run("should round-trip through Parse", func(t *testing.T) {
+		for _, d := range []time.Duration{0, 30 * Day, Day + 2*time.Hour + 3*time.Minute + 4*time.Second} {
+			got, err := Parse(Format(d))
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if got != d {
+				t.Fatalf("expected %v, got %v", d, got)
+			}
+		}
+	})
+}
@@ -3,8 +3,14 @@ package files
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
+// MAX_FILENAME_LENGTH is the longest file name, in bytes, that common
+// filesystems such as ext4, APFS and NTFS accept.
+const MAX_FILENAME_LENGTH = 255
+
 func FileExists(path string) (bool, error) {
 	_, err := os.Stat(path)
 	if err == nil {
@@ -30,48 +36,149 @@ func ReadFile(path string, notFoundErr error) ([]byte, error) {
 }
 
 func WriteAtomicFile(path string, data []byte, perm os.FileMode) error {
-	tmpPath := path + ".tmp"
-
-	tmpFile, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm)
+	tmpPath, err := WriteTempFile(path, data, perm)
 	if err != nil {
 		return err
 	}
+	// Only ever removes this call's own temp file, which is gone once it has
+	// been committed.
+	defer os.Remove(tmpPath)
+
+	return CommitTempFile(tmpPath, path)
+}
+
+// WriteTempFile writes data next to path and syncs it to disk, returning the
+// temp file path. The temp file gets a unique name, so concurrent writes to
+// the same path and files left behind by an interrupted run never collide.
+// The caller is responsible for committing or removing it.
+func WriteTempFile(path string, data []byte, perm os.FileMode) (string, error) {
+	tmpFile, err := os.CreateTemp(filepath.Dir(path), tempPattern(path))
+	if err != nil {
+		return "", err
+	}
+	tmpPath := tmpFile.Name()
 
-	defer func() {
+	// CreateTemp always uses mode 0600, so set the requested mode explicitly.
+	if err := tmpFile.Chmod(perm); err != nil {
 		tmpFile.Close()
 		os.Remove(tmpPath)
-	}()
+		return "", err
+	}
 
 	if _, err := tmpFile.Write(data); err != nil {
-		return err
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return "", err
 	}
 
 	if err := tmpFile.Sync(); err != nil {
-		return err
+		tmpFile.Close()
+		os.Remove(tmpPath)
+		return "", err
 	}
 
 	if err := tmpFile.Close(); err != nil {
-		return err
+		os.Remove(tmpPath)
+		return "", err
 	}
 
+	return tmpPath, nil
+}
+
+// maxTempSuffix is the longest suffix CreateTemp gives a tempPattern name: a
+// dot, up to ten random digits and ".tmp".
+const maxTempSuffix = len(".4294967295.tmp")
+
+// tempPattern returns the CreateTemp pattern for temp files of path, e.g.
+// "github.msk.*.tmp". When the result could make the file name too long, the
+// name before its extension is shortened to fit.
+func tempPattern(path string) string {
+	base := filepath.Base(path)
+	ext := filepath.Ext(base)
+	stem := strings.TrimSuffix(base, ext)
+
+	if over := len(base) + maxTempSuffix - MAX_FILENAME_LENGTH; over > 0 && over < len(stem) {
+		base = stem[:len(stem)-over] + ext
+	}
+
+	return base + ".*.tmp"
+}
+
+// IsTempFile reports whether name was created by WriteTempFile for a file
+// with extension ext, including the fixed "<name><ext>.tmp" names of older
+// versions.
+func IsTempFile(name, ext string) bool {
+	if strings.HasSuffix(name, ext+".tmp") {
+		return true
+	}
+
+	matched, _ := filepath.Match("*"+ext+".*.tmp", name)
+	return matched
+}
+
+// CommitTempFile atomically renames a temp file written by WriteTempFile over
+// path and syncs the parent directory so the rename is durable.
+func CommitTempFile(tmpPath, path string) error {
 	if err := os.Rename(tmpPath, path); err != nil {
 		return err
 	}
 
-	dir, err := os.Open(filepath.Dir(path))
+	SyncDir(filepath.Dir(path))
+
+	return nil
+}
+
+// SyncDir flushes directory entries to disk on a best-effort basis.
+func SyncDir(path string) {
+	dir, err := os.Open(path)
 	if err == nil {
 		defer dir.Close()
 		_ = dir.Sync()
 	}
+}
 
-	return nil
+// ShredFile overwrites the contents of path with zeros before removing it. The
+// overwrite is best-effort: copy-on-write and journaling filesystems or SSD
+// wear levelling may keep older copies of the data around.
+func ShredFile(path string) error {
+	if file, err := os.OpenFile(path, os.O_WRONLY, 0); err == nil {
+		if info, err := file.Stat(); err == nil {
+			zeros := make([]byte, 32*1024)
+			for remaining := info.Size(); remaining > 0; {
+				n := min(remaining, int64(len(zeros)))
+				if _, err := file.Write(zeros[:n]); err != nil {
+					break
+				}
+				remaining -= n
+			}
+			_ = file.Sync()
+		}
+		file.Close()
+	}
+
+	return os.Remove(path)
 }
 
+// MSKConfigPath returns the path of filename inside the msk config directory.
+// On Unix-like systems, including macOS, an absolute XDG_CONFIG_HOME takes
+// precedence; otherwise the OS default from os.UserConfigDir is used
+// (%AppData% on Windows, ~/Library/Application Support on macOS and
+// ~/.config on Linux).
 func MSKConfigPath(filename string) (string, error) {
-	configDir, err := os.UserConfigDir()
+	configDir, err := userConfigDir()
 	if err != nil {
 		return "", err
 	}
 
 	return filepath.Join(configDir, "msk", filename), nil
 }
+
+func userConfigDir() (string, error) {
+	if runtime.GOOS != "windows" {
+		if dir := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(dir) {
+			return dir, nil
+		}
+	}
+
+	return os.UserConfigDir()
+}
@@ -0,0 +1,98 @@
+package format
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+var ErrNotConfigFile = errors.New("not an msk config file")
+
+// IsConfigFile reports whether data starts with the config file magic.
+// Configs written before it existed look like any other secret file.
+func IsConfigFile(data []byte) bool {
+	return len(data) >= meta.MSK_CONFIG_MAGIC_SIZE &&
+		string(data[:meta.MSK_CONFIG_MAGIC_SIZE]) == meta.MSK_CONFIG_MAGIC_VALUE
+}
+
+// MarshalConfigFile encodes a config file: the config magic and version
+// followed by the same salt, nonce and ciphertext layout as secret files.
+func MarshalConfigFile(salt, nonce, data []byte) ([]byte, error) {
+	if len(salt) != meta.MSK_SALT_SIZE {
+		return nil, errors.New("invalid salt size")
+	}
+
+	if len(nonce) != meta.MSK_NONCE_SIZE {
+		return nil, errors.New("invalid nonce size")
+	}
+
+	file := make([]byte, 0, meta.MSK_CONFIG_HEADER_SIZE+len(data))
+	file = append(file, meta.MSK_CONFIG_MAGIC_VALUE...)
+	file = append(file, meta.MSK_CONFIG_VERSION)
+	file = append(file, salt...)
+	file = append(file, nonce...)
+	file = append(file, data...)
+
+	return file, nil
+}
+
+// UnmarshalConfigFile splits a file written by MarshalConfigFile into its
+// parts. Files without the config magic yield ErrNotConfigFile.
+func UnmarshalConfigFile(data []byte) (salt, nonce, sealed []byte, err error) {
+	if !IsConfigFile(data) {
+		return nil, nil, nil, ErrNotConfigFile
+	}
+
+	if len(data) < meta.MSK_CONFIG_HEADER_SIZE {
+		return nil, nil, nil, ErrCorruptedFile
+	}
+
+	offset := meta.MSK_CONFIG_MAGIC_SIZE
+	if data[offset] != meta.MSK_CONFIG_VERSION {
+		return nil, nil, nil, ErrUnsupportedFileVersion
+	}
+	offset += meta.MSK_VERSION_SIZE
+
+	salt = data[offset : offset+meta.MSK_SALT_SIZE]
+	offset += meta.MSK_SALT_SIZE
+
+	nonce = data[offset : offset+meta.MSK_NONCE_SIZE]
+	offset += meta.MSK_NONCE_SIZE
+
+	sealed = data[offset:]
+	if len(sealed) < meta.MSK_GCM_TAG_SIZE {
+		return nil, nil, nil, ErrCorruptedFile
+	}
+
+	return salt, nonce, sealed, nil
+}
+
+// MarshalConfig encodes the config payload, the length-prefixed vault path.
+func MarshalConfig(vaultPath string) ([]byte, error) {
+	if len(vaultPath) > meta.SECRET_MAX_FIELD_LENGTH {
+		return nil, ErrFieldTooLong
+	}
+
+	buf := make([]byte, 0, meta.CONFIG_VAULT_PATH_LENGTH_SIZE+len(vaultPath))
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(vaultPath)))
+	buf = append(buf, vaultPath...)
+
+	return buf, nil
+}
+
+// UnmarshalConfig decodes a payload written by MarshalConfig.
+func UnmarshalConfig(data []byte) (string, error) {
+	if len(data) < meta.CONFIG_VAULT_PATH_LENGTH_SIZE {
+		return "", ErrCorruptedFile
+	}
+
+	length := int(binary.BigEndian.Uint16(data))
+	data = data[meta.CONFIG_VAULT_PATH_LENGTH_SIZE:]
+
+	if len(data) != length {
+		return "", ErrCorruptedFile
+	}
+
+	return string(data), nil
+}
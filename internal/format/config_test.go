@@ -0,0 +1,93 @@
+package format
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+func TestConfigFile(t *testing.T) {
+	salt := bytes.Repeat([]byte{1}, meta.MSK_SALT_SIZE)
+	nonce := bytes.Repeat([]byte{2}, meta.MSK_NONCE_SIZE)
+	data := bytes.Repeat([]byte{3}, meta.MSK_GCM_TAG_SIZE+4)
+
+	t.Run("should round-trip with MarshalConfigFile", func(t *testing.T) {
+		file, err := MarshalConfigFile(salt, nonce, data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if string(file[:meta.MSK_CONFIG_MAGIC_SIZE]) != meta.MSK_CONFIG_MAGIC_VALUE {
+			t.Fatalf("expected magic %q, got %q", meta.MSK_CONFIG_MAGIC_VALUE, file[:meta.MSK_CONFIG_MAGIC_SIZE])
+		}
+
+		gotSalt, gotNonce, gotData, err := UnmarshalConfigFile(file)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !bytes.Equal(gotSalt, salt) || !bytes.Equal(gotNonce, nonce) || !bytes.Equal(gotData, data) {
+			t.Fatal("round-trip mismatch")
+		}
+	})
+
+	t.Run("should return ErrNotConfigFile for secret files", func(t *testing.T) {
+		file, err := MarshalFile(salt, nonce, data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if IsConfigFile(file) {
+			t.Fatal("expected a secret file not to be a config file")
+		}
+
+		if _, _, _, err := UnmarshalConfigFile(file); !errors.Is(err, ErrNotConfigFile) {
+			t.Fatalf("expected ErrNotConfigFile, got %v", err)
+		}
+	})
+
+	t.Run("should reject truncated and unknown versions", func(t *testing.T) {
+		file, err := MarshalConfigFile(salt, nonce, data)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if _, _, _, err := UnmarshalConfigFile(file[:meta.MSK_CONFIG_HEADER_SIZE+1]); !errors.Is(err, ErrCorruptedFile) {
+			t.Fatalf("expected ErrCorruptedFile, got %v", err)
+		}
+
+		file[meta.MSK_CONFIG_MAGIC_SIZE] = 0xFF
+		if _, _, _, err := UnmarshalConfigFile(file); !errors.Is(err, ErrUnsupportedFileVersion) {
+			t.Fatalf("expected ErrUnsupportedFileVersion, got %v", err)
+		}
+	})
+}
+
+func TestMarshalConfig(t *testing.T) {
+	t.Run("should round-trip the vault path", func(t *testing.T) {
+		payload, err := MarshalConfig("/home/user/.msk/vault")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		got, err := UnmarshalConfig(payload)
+		if err != nil || got != "/home/user/.msk/vault" {
+			t.Fatalf("expected the vault path, got %q (%v)", got, err)
+		}
+	})
+
+	t.Run("should reject a length that does not match", func(t *testing.T) {
+		payload, err := MarshalConfig("/vault")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		for _, bad := range [][]byte{payload[:1], payload[:len(payload)-1], append(payload, 'x')} {
+			if _, err := UnmarshalConfig(bad); !errors.Is(err, ErrCorruptedFile) {
+				t.Fatalf("%v: expected ErrCorruptedFile, got %v", bad, err)
+			}
+		}
+	})
+}
@@ -3,42 +3,183 @@ package format
 import (
 	"encoding/binary"
 	"errors"
+	"time"
 
 	"github.com/amauribechtoldjr/msk/internal/domain"
 	"github.com/amauribechtoldjr/msk/internal/meta"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
 )
 
+// ErrCorruptedFile and ErrUnsupportedFileVersion are the only sentinels for
+// damaged files: the vault returns them as is, so errors.Is matches no
+// matter which layer found the problem.
 var ErrCorruptedFile = errors.New("corrupted file")
 var ErrUnsupportedFileVersion = errors.New("unsupported file version")
+var ErrFieldTooLong = errors.New("secret field exceeds maximum length")
+
+const (
+	fieldUsername byte = iota + 1
+	fieldURL
+	fieldNotes
+	fieldTags
+	fieldCreatedAt
+	fieldUpdatedAt
+	fieldType
+	fieldLoginFields
+)
+
+const timeFieldSize = 12
 
-func getBufferLength(secret domain.Secret) int {
-	return meta.SECRET_NAME_LENGTH_SIZE +
-		len(secret.Name) +
-		meta.SECRET_PASSWORD_LENGTH_SIZE +
-		len(secret.Password)
+type field struct {
+	tag   byte
+	value []byte
 }
 
-func MarshalSecret(secret domain.Secret) []byte {
-	bytesName := []byte(secret.Name)
+// MarshalSecret encodes a secret as its name and password followed, when any
+// metadata is set, by a versioned list of tagged length-prefixed fields.
+// Empty fields are omitted, so secrets without metadata keep the original
+// name+password layout and the output is deterministic.
+func MarshalSecret(secret domain.Secret) ([]byte, error) {
+	if len(secret.Name) > meta.SECRET_MAX_FIELD_LENGTH || len(secret.Password) > meta.SECRET_MAX_FIELD_LENGTH {
+		return nil, ErrFieldTooLong
+	}
 
-	offset := 0
-	buf := make([]byte, getBufferLength(secret))
-	binary.BigEndian.PutUint16(buf[offset:], uint16(len(bytesName)))
+	fields, err := metadataFields(secret)
+	if err != nil {
+		return nil, err
+	}
 
-	offset += meta.SECRET_NAME_LENGTH_SIZE
+	size := meta.SECRET_NAME_LENGTH_SIZE + len(secret.Name) + meta.SECRET_PASSWORD_LENGTH_SIZE + len(secret.Password)
+	if len(fields) > 0 {
+		size += meta.SECRET_PAYLOAD_VERSION_SIZE
+		for _, f := range fields {
+			size += meta.SECRET_FIELD_TAG_SIZE + meta.SECRET_FIELD_LENGTH_SIZE + len(f.value)
+		}
+	}
 
-	copy(buf[offset:], []byte(secret.Name))
+	buf := make([]byte, 0, size)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(secret.Name)))
+	buf = append(buf, secret.Name...)
+	buf = binary.BigEndian.AppendUint16(buf, uint16(len(secret.Password)))
+	buf = append(buf, secret.Password...)
 
-	offset += len(secret.Name)
-	binary.BigEndian.PutUint16(buf[offset:], uint16(len(secret.Password)))
+	if len(fields) == 0 {
+		return buf, nil
+	}
 
-	offset += meta.SECRET_PASSWORD_LENGTH_SIZE
+	buf = append(buf, meta.SECRET_PAYLOAD_VERSION)
+	for _, f := range fields {
+		buf = append(buf, f.tag)
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(f.value)))
+		buf = append(buf, f.value...)
+	}
+
+	return buf, nil
+}
+
+func metadataFields(secret domain.Secret) ([]field, error) {
+	tags, err := marshalStrings(secret.Tags)
+	if err != nil {
+		return nil, err
+	}
+
+	loginFields, err := marshalStrings(secret.LoginFields)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := []field{
+		{fieldUsername, []byte(secret.Username)},
+		{fieldURL, []byte(secret.URL)},
+		{fieldNotes, []byte(secret.Notes)},
+		{fieldTags, tags},
+		{fieldCreatedAt, marshalTime(secret.CreatedAt)},
+		{fieldUpdatedAt, marshalTime(secret.UpdatedAt)},
+		{fieldLoginFields, loginFields},
+	}
+
+	if secret.Type != domain.SecretTypePassword {
+		candidates = append(candidates, field{fieldType, []byte{byte(secret.Type)}})
+	}
+
+	var fields []field
+	for _, f := range candidates {
+		if len(f.value) == 0 {
+			continue
+		}
+
+		if len(f.value) > meta.SECRET_MAX_FIELD_LENGTH {
+			return nil, ErrFieldTooLong
+		}
+
+		fields = append(fields, f)
+	}
+
+	return fields, nil
+}
+
+// marshalStrings encodes a list, such as the tags, as length-prefixed
+// strings.
+func marshalStrings(values []string) ([]byte, error) {
+	var buf []byte
+
+	for _, value := range values {
+		if len(value) > meta.SECRET_MAX_FIELD_LENGTH {
+			return nil, ErrFieldTooLong
+		}
+
+		buf = binary.BigEndian.AppendUint16(buf, uint16(len(value)))
+		buf = append(buf, value...)
+	}
+
+	return buf, nil
+}
+
+func unmarshalStrings(data []byte) ([]string, error) {
+	var values []string
+
+	for offset := 0; offset < len(data); {
+		if offset+meta.SECRET_FIELD_LENGTH_SIZE > len(data) {
+			return nil, ErrCorruptedFile
+		}
 
-	copy(buf[offset:], []byte(secret.Password))
+		valueLen := int(binary.BigEndian.Uint16(data[offset:]))
+		offset += meta.SECRET_FIELD_LENGTH_SIZE
 
-	return buf
+		if offset+valueLen > len(data) {
+			return nil, ErrCorruptedFile
+		}
+
+		values = append(values, string(data[offset:offset+valueLen]))
+		offset += valueLen
+	}
+
+	return values, nil
 }
 
+func marshalTime(t time.Time) []byte {
+	if t.IsZero() {
+		return nil
+	}
+
+	buf := binary.BigEndian.AppendUint64(nil, uint64(t.Unix()))
+	return binary.BigEndian.AppendUint32(buf, uint32(t.Nanosecond()))
+}
+
+func unmarshalTime(data []byte) (time.Time, error) {
+	if len(data) != timeFieldSize {
+		return time.Time{}, ErrCorruptedFile
+	}
+
+	sec := int64(binary.BigEndian.Uint64(data))
+	nsec := int64(binary.BigEndian.Uint32(data[8:]))
+
+	return time.Unix(sec, nsec).UTC(), nil
+}
+
+// UnmarshalSecret decodes a secret payload. The password is copied out of
+// data, so the caller can wipe data as soon as this returns and owns wiping
+// the returned Password.
 func UnmarshalSecret(data []byte) (domain.Secret, error) {
 	secret := &domain.Secret{}
 	offset := 0
@@ -70,11 +211,87 @@ func UnmarshalSecret(data []byte) (domain.Secret, error) {
 
 	secret.Password = make([]byte, passLen)
 	copy(secret.Password, data[offset:offset+passLen])
+	offset += passLen
+
+	if err := unmarshalMetadata(secret, data[offset:]); err != nil {
+		wipe.Bytes(secret.Password)
+		return domain.Secret{}, err
+	}
 
 	return *secret, nil
 }
 
+// unmarshalMetadata decodes the optional metadata section. Fields with tags
+// this version does not know are skipped so newer payloads stay readable.
+func unmarshalMetadata(secret *domain.Secret, data []byte) error {
+	if len(data) == 0 {
+		return nil
+	}
+
+	if data[0] != meta.SECRET_PAYLOAD_VERSION {
+		return ErrUnsupportedFileVersion
+	}
+
+	offset := meta.SECRET_PAYLOAD_VERSION_SIZE
+
+	for offset < len(data) {
+		if offset+meta.SECRET_FIELD_TAG_SIZE+meta.SECRET_FIELD_LENGTH_SIZE > len(data) {
+			return ErrCorruptedFile
+		}
+
+		tag := data[offset]
+		offset += meta.SECRET_FIELD_TAG_SIZE
+
+		valueLen := int(binary.BigEndian.Uint16(data[offset:]))
+		offset += meta.SECRET_FIELD_LENGTH_SIZE
+
+		if offset+valueLen > len(data) {
+			return ErrCorruptedFile
+		}
+
+		value := data[offset : offset+valueLen]
+		offset += valueLen
+
+		var err error
+
+		switch tag {
+		case fieldUsername:
+			secret.Username = string(value)
+		case fieldURL:
+			secret.URL = string(value)
+		case fieldNotes:
+			secret.Notes = string(value)
+		case fieldTags:
+			secret.Tags, err = unmarshalStrings(value)
+		case fieldCreatedAt:
+			secret.CreatedAt, err = unmarshalTime(value)
+		case fieldUpdatedAt:
+			secret.UpdatedAt, err = unmarshalTime(value)
+		case fieldType:
+			if len(value) != 1 {
+				return ErrCorruptedFile
+			}
+			secret.Type = domain.SecretType(value[0])
+		case fieldLoginFields:
+			secret.LoginFields, err = unmarshalStrings(value)
+		}
+
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// MarshalFile encodes a file sealed with a master-password-derived key.
 func MarshalFile(salt, nonce, data []byte) ([]byte, error) {
+	return MarshalFileVersion(meta.MSK_FILE_VERSION, salt, nonce, data)
+}
+
+// MarshalFileVersion encodes a file with the given format version, which
+// tells readers how its key was derived.
+func MarshalFileVersion(version byte, salt, nonce, data []byte) ([]byte, error) {
 	if len(salt) != meta.MSK_SALT_SIZE {
 		return nil, errors.New("invalid salt size")
 	}
@@ -89,7 +306,7 @@ func MarshalFile(salt, nonce, data []byte) ([]byte, error) {
 	copy(file[offset:], []byte(meta.MSK_MAGIC_VALUE))
 
 	offset += meta.MSK_MAGIC_SIZE
-	file[offset] = meta.MSK_FILE_VERSION
+	file[offset] = version
 
 	offset += meta.MSK_VERSION_SIZE
 	copy(file[offset:], salt)
@@ -103,21 +320,30 @@ func MarshalFile(salt, nonce, data []byte) ([]byte, error) {
 	return file, nil
 }
 
-func UnmarshalFile(data []byte) (salt, nonce, secret []byte, err error) {
+// FileVersion returns the format version of a file after checking its
+// header.
+func FileVersion(data []byte) (byte, error) {
 	if len(data) < meta.MSK_HEADER_SIZE {
-		return nil, nil, nil, ErrCorruptedFile
+		return 0, ErrCorruptedFile
 	}
 
 	if string(data[:meta.MSK_MAGIC_SIZE]) != meta.MSK_MAGIC_VALUE {
-		return nil, nil, nil, ErrCorruptedFile
+		return 0, ErrCorruptedFile
 	}
 
-	if len(data) <= meta.MSK_MAGIC_SIZE {
-		return nil, nil, nil, ErrCorruptedFile
+	version := data[meta.MSK_MAGIC_SIZE]
+	if version != meta.MSK_FILE_VERSION && version != meta.MSK_FILE_VERSION_ENVELOPE {
+		return 0, ErrUnsupportedFileVersion
 	}
 
-	if data[meta.MSK_MAGIC_SIZE] != meta.MSK_FILE_VERSION {
-		return nil, nil, nil, ErrUnsupportedFileVersion
+	return version, nil
+}
+
+// UnmarshalFile splits a file of any supported version into its parts. Use
+// FileVersion to tell how its key was derived.
+func UnmarshalFile(data []byte) (salt, nonce, secret []byte, err error) {
+	if _, err := FileVersion(data); err != nil {
+		return nil, nil, nil, err
 	}
 
 	offset := meta.MSK_MAGIC_SIZE + meta.MSK_VERSION_SIZE
@@ -130,5 +356,11 @@ func UnmarshalFile(data []byte) (salt, nonce, secret []byte, err error) {
 
 	secret = data[offset:]
 
+	// A ciphertext shorter than the GCM tag was cut off, most likely by an
+	// interrupted write, and would otherwise be reported as a wrong password.
+	if len(secret) > 0 && len(secret) < meta.MSK_GCM_TAG_SIZE {
+		return nil, nil, nil, ErrCorruptedFile
+	}
+
 	return salt, nonce, secret, nil
 }
@@ -2,13 +2,27 @@ package format
 
 import (
 	"bytes"
+	"errors"
 	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/amauribechtoldjr/msk/internal/domain"
 	"github.com/amauribechtoldjr/msk/internal/meta"
 )
 
+func marshalSecretOrFail(t *testing.T, secret domain.Secret) []byte {
+	t.Helper()
+
+	data, err := MarshalSecret(secret)
+	if err != nil {
+		t.Fatalf("failed to marshal secret: %v", err)
+	}
+
+	return data
+}
+
 func TestMarshalUnmarshalSecret(t *testing.T) {
 	t.Run("should round-trip a secret correctly", func(t *testing.T) {
 		secret := domain.Secret{
@@ -16,7 +30,7 @@ func TestMarshalUnmarshalSecret(t *testing.T) {
 			Password: []byte("p@ssw0rd!"),
 		}
 
-		data := MarshalSecret(secret)
+		data := marshalSecretOrFail(t, secret)
 		got, err := UnmarshalSecret(data)
 		if err != nil {
 			t.Fatalf("failed to unmarshal secret: %v", err)
@@ -37,7 +51,7 @@ func TestMarshalUnmarshalSecret(t *testing.T) {
 			Password: []byte("pass"),
 		}
 
-		data := MarshalSecret(secret)
+		data := marshalSecretOrFail(t, secret)
 		got, err := UnmarshalSecret(data)
 		if err != nil {
 			t.Fatalf("failed to unmarshal secret: %v", err)
@@ -58,7 +72,7 @@ func TestMarshalUnmarshalSecret(t *testing.T) {
 			Password: []byte{},
 		}
 
-		data := MarshalSecret(secret)
+		data := marshalSecretOrFail(t, secret)
 		got, err := UnmarshalSecret(data)
 		if err != nil {
 			t.Fatalf("failed to unmarshal secret: %v", err)
@@ -79,7 +93,7 @@ func TestMarshalUnmarshalSecret(t *testing.T) {
 			Password: []byte{0x00, 0xFF, 0x01, 0xFE},
 		}
 
-		data := MarshalSecret(secret)
+		data := marshalSecretOrFail(t, secret)
 		got, err := UnmarshalSecret(data)
 		if err != nil {
 			t.Fatalf("failed to unmarshal secret: %v", err)
@@ -96,8 +110,8 @@ func TestMarshalUnmarshalSecret(t *testing.T) {
 			Password: []byte("pass"),
 		}
 
-		data1 := MarshalSecret(secret)
-		data2 := MarshalSecret(secret)
+		data1 := marshalSecretOrFail(t, secret)
+		data2 := marshalSecretOrFail(t, secret)
 
 		if !reflect.DeepEqual(data1, data2) {
 			t.Fatal("expected identical marshal output for same input")
@@ -148,7 +162,7 @@ func TestMarshalSecretFormat(t *testing.T) {
 			Password: []byte("xyz"),
 		}
 
-		data := MarshalSecret(secret)
+		data := marshalSecretOrFail(t, secret)
 
 		expectedLen := meta.SECRET_NAME_LENGTH_SIZE + 2 + meta.SECRET_PASSWORD_LENGTH_SIZE + 3
 		if len(data) != expectedLen {
@@ -319,6 +333,21 @@ func TestUnmarshalFile(t *testing.T) {
 		}
 	})
 
+	t.Run("should accept the envelope version", func(t *testing.T) {
+		file, err := MarshalFileVersion(meta.MSK_FILE_VERSION_ENVELOPE, make([]byte, meta.MSK_SALT_SIZE), make([]byte, meta.MSK_NONCE_SIZE), make([]byte, 20))
+		if err != nil {
+			t.Fatalf("failed to marshal file: %v", err)
+		}
+
+		if _, _, _, err := UnmarshalFile(file); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if version, err := FileVersion(file); err != nil || version != meta.MSK_FILE_VERSION_ENVELOPE {
+			t.Fatalf("expected version %d, got %d (%v)", meta.MSK_FILE_VERSION_ENVELOPE, version, err)
+		}
+	})
+
 	t.Run("should return ErrUnsupportedFileVersion when version is wrong", func(t *testing.T) {
 		data := make([]byte, meta.MSK_HEADER_SIZE+10)
 		copy(data[:3], meta.MSK_MAGIC_VALUE)
@@ -346,6 +375,29 @@ func TestUnmarshalFile(t *testing.T) {
 		}
 	})
 
+	t.Run("should return ErrCorruptedFile when ciphertext is shorter than the GCM tag", func(t *testing.T) {
+		file, err := MarshalFile(makeSalt(), makeNonce(), make([]byte, meta.MSK_GCM_TAG_SIZE-1))
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+
+		_, _, _, err = UnmarshalFile(file)
+		if err != ErrCorruptedFile {
+			t.Fatalf("expected ErrCorruptedFile, got %v", err)
+		}
+	})
+
+	t.Run("should accept ciphertext as long as the GCM tag", func(t *testing.T) {
+		file, err := MarshalFile(makeSalt(), makeNonce(), make([]byte, meta.MSK_GCM_TAG_SIZE))
+		if err != nil {
+			t.Fatalf("marshal failed: %v", err)
+		}
+
+		if _, _, _, err = UnmarshalFile(file); err != nil {
+			t.Fatalf("unmarshal failed: %v", err)
+		}
+	})
+
 	t.Run("should return ErrCorruptedFile for empty input", func(t *testing.T) {
 		_, _, _, err := UnmarshalFile([]byte{})
 		if err != ErrCorruptedFile {
@@ -360,3 +412,160 @@ func TestUnmarshalFile(t *testing.T) {
 		}
 	})
 }
+
+func TestMarshalSecretMetadata(t *testing.T) {
+	fullSecret := func() domain.Secret {
+		return domain.Secret{
+			Name:      "github",
+			Password:  []byte("p@ss"),
+			Username:  "octocat",
+			URL:       "https://github.com",
+			Notes:     "recovery codes in the safe",
+			Tags:      []string{"work", "", "dev"},
+			CreatedAt: time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC),
+			UpdatedAt: time.Date(2025, 6, 7, 8, 9, 10, 11, time.UTC),
+			Type:      domain.SecretType(7),
+		}
+	}
+
+	assertEqualSecret := func(t *testing.T, expected, got domain.Secret) {
+		t.Helper()
+
+		if got.Name != expected.Name || !bytes.Equal(got.Password, expected.Password) {
+			t.Fatalf("expected %q/%q, got %q/%q", expected.Name, expected.Password, got.Name, got.Password)
+		}
+
+		if got.Username != expected.Username || got.URL != expected.URL || got.Notes != expected.Notes {
+			t.Fatalf("text fields mismatch\nexpected: %+v\ngot:      %+v", expected, got)
+		}
+
+		if !reflect.DeepEqual(got.Tags, expected.Tags) {
+			t.Fatalf("expected tags %q, got %q", expected.Tags, got.Tags)
+		}
+
+		if !got.CreatedAt.Equal(expected.CreatedAt) || !got.UpdatedAt.Equal(expected.UpdatedAt) {
+			t.Fatalf("timestamps mismatch\nexpected: %v %v\ngot:      %v %v",
+				expected.CreatedAt, expected.UpdatedAt, got.CreatedAt, got.UpdatedAt)
+		}
+
+		if got.Type != expected.Type {
+			t.Fatalf("expected type %d, got %d", expected.Type, got.Type)
+		}
+	}
+
+	t.Run("should round-trip every metadata field", func(t *testing.T) {
+		secret := fullSecret()
+
+		got, err := UnmarshalSecret(marshalSecretOrFail(t, secret))
+		if err != nil {
+			t.Fatalf("failed to unmarshal secret: %v", err)
+		}
+
+		assertEqualSecret(t, secret, got)
+	})
+
+	t.Run("should round-trip each field on its own", func(t *testing.T) {
+		full := fullSecret()
+		secrets := []domain.Secret{
+			{Name: "a", Username: full.Username},
+			{Name: "a", URL: full.URL},
+			{Name: "a", Notes: full.Notes},
+			{Name: "a", Tags: full.Tags},
+			{Name: "a", CreatedAt: full.CreatedAt},
+			{Name: "a", UpdatedAt: full.UpdatedAt},
+			{Name: "a", Type: full.Type},
+		}
+
+		for _, secret := range secrets {
+			got, err := UnmarshalSecret(marshalSecretOrFail(t, secret))
+			if err != nil {
+				t.Fatalf("failed to unmarshal secret: %v", err)
+			}
+
+			assertEqualSecret(t, secret, got)
+		}
+	})
+
+	t.Run("should keep the legacy layout when no metadata is set", func(t *testing.T) {
+		data := marshalSecretOrFail(t, domain.Secret{Name: "ab", Password: []byte("xyz")})
+
+		expectedLen := meta.SECRET_NAME_LENGTH_SIZE + 2 + meta.SECRET_PASSWORD_LENGTH_SIZE + 3
+		if len(data) != expectedLen {
+			t.Fatalf("expected length %d, got %d", expectedLen, len(data))
+		}
+	})
+
+	t.Run("should produce deterministic output with metadata", func(t *testing.T) {
+		if !bytes.Equal(marshalSecretOrFail(t, fullSecret()), marshalSecretOrFail(t, fullSecret())) {
+			t.Fatal("expected identical marshal output for same input")
+		}
+	})
+
+	t.Run("should handle max-length fields", func(t *testing.T) {
+		long := strings.Repeat("x", meta.SECRET_MAX_FIELD_LENGTH)
+		secret := domain.Secret{
+			Name:     long,
+			Password: []byte(long),
+			Username: long,
+			URL:      long,
+			Notes:    long,
+		}
+
+		got, err := UnmarshalSecret(marshalSecretOrFail(t, secret))
+		if err != nil {
+			t.Fatalf("failed to unmarshal secret: %v", err)
+		}
+
+		assertEqualSecret(t, secret, got)
+	})
+
+	t.Run("should return ErrFieldTooLong for oversized fields", func(t *testing.T) {
+		tooLong := strings.Repeat("x", meta.SECRET_MAX_FIELD_LENGTH+1)
+		secrets := []domain.Secret{
+			{Name: tooLong},
+			{Password: []byte(tooLong)},
+			{Notes: tooLong},
+			{Tags: []string{tooLong}},
+			{Tags: []string{tooLong[:40000], tooLong[:40000]}},
+		}
+
+		for _, secret := range secrets {
+			if _, err := MarshalSecret(secret); !errors.Is(err, ErrFieldTooLong) {
+				t.Fatalf("expected ErrFieldTooLong, got %v", err)
+			}
+		}
+	})
+
+	t.Run("should skip unknown fields", func(t *testing.T) {
+		data := marshalSecretOrFail(t, domain.Secret{Name: "a", Username: "user"})
+		data = append(data, 0xF0, 0x00, 0x03, 'n', 'e', 'w')
+
+		got, err := UnmarshalSecret(data)
+		if err != nil {
+			t.Fatalf("failed to unmarshal secret: %v", err)
+		}
+
+		if got.Username != "user" {
+			t.Fatalf("expected username %q, got %q", "user", got.Username)
+		}
+	})
+
+	t.Run("should return ErrUnsupportedFileVersion for unknown payload version", func(t *testing.T) {
+		data := marshalSecretOrFail(t, domain.Secret{Name: "a", Username: "user"})
+		data[meta.SECRET_NAME_LENGTH_SIZE+1+meta.SECRET_PASSWORD_LENGTH_SIZE] = 0xFF
+
+		if _, err := UnmarshalSecret(data); !errors.Is(err, ErrUnsupportedFileVersion) {
+			t.Fatalf("expected ErrUnsupportedFileVersion, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrCorruptedFile for truncated metadata", func(t *testing.T) {
+		data := marshalSecretOrFail(t, fullSecret())
+
+		for _, cut := range []int{1, 2, 5} {
+			if _, err := UnmarshalSecret(data[:len(data)-cut]); !errors.Is(err, ErrCorruptedFile) {
+				t.Fatalf("expected ErrCorruptedFile when cutting %d bytes, got %v", cut, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,98 @@
+package format
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+// Run with e.g. "go test ./internal/format -fuzz FuzzUnmarshalSecret". Without
+// -fuzz only the seed corpus runs, as part of the normal tests.
+
+func FuzzUnmarshalFile(f *testing.F) {
+	salt := make([]byte, meta.MSK_SALT_SIZE)
+	nonce := make([]byte, meta.MSK_NONCE_SIZE)
+
+	for _, data := range [][]byte{nil, make([]byte, meta.MSK_GCM_TAG_SIZE)} {
+		file, err := MarshalFile(salt, nonce, data)
+		if err != nil {
+			f.Fatalf("marshal failed: %v", err)
+		}
+		f.Add(file)
+	}
+
+	envelope, err := MarshalFileVersion(meta.MSK_FILE_VERSION_ENVELOPE, salt, nonce, []byte("short"))
+	if err != nil {
+		f.Fatalf("marshal failed: %v", err)
+	}
+	f.Add(envelope)
+	f.Add([]byte("MSK"))
+	f.Add([]byte{})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		salt, nonce, secret, err := UnmarshalFile(data)
+		if err != nil {
+			if !errors.Is(err, ErrCorruptedFile) && !errors.Is(err, ErrUnsupportedFileVersion) {
+				t.Fatalf("undocumented error: %v", err)
+			}
+			return
+		}
+
+		if len(salt) != meta.MSK_SALT_SIZE || len(nonce) != meta.MSK_NONCE_SIZE {
+			t.Fatalf("got salt of %d and nonce of %d bytes", len(salt), len(nonce))
+		}
+
+		if len(secret) != len(data)-meta.MSK_HEADER_SIZE {
+			t.Fatalf("got %d ciphertext bytes from a %d byte file", len(secret), len(data))
+		}
+	})
+}
+
+func FuzzUnmarshalSecret(f *testing.F) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+
+	for _, secret := range []domain.Secret{
+		{Name: "github", Password: []byte("hunter2")},
+		{},
+		{
+			Name:      "work/github",
+			Password:  []byte("hunter2"),
+			Username:  "octocat",
+			URL:       "https://github.com",
+			Notes:     "notes",
+			Tags:      []string{"work", ""},
+			CreatedAt: created,
+			UpdatedAt: created,
+		},
+	} {
+		data, err := MarshalSecret(secret)
+		if err != nil {
+			f.Fatalf("marshal failed: %v", err)
+		}
+		f.Add(data)
+	}
+
+	// A name length that fits followed by a password length that does not.
+	f.Add([]byte{0, 1, 'a', 0xFF, 0xFF, 'b'})
+	// An unknown field tag, which is skipped.
+	f.Add([]byte{0, 0, 0, 0, meta.SECRET_PAYLOAD_VERSION, 0xEE, 0, 1, 'x'})
+	f.Add([]byte{0})
+
+	f.Fuzz(func(t *testing.T, data []byte) {
+		secret, err := UnmarshalSecret(data)
+		if err != nil {
+			if !errors.Is(err, ErrCorruptedFile) && !errors.Is(err, ErrUnsupportedFileVersion) {
+				t.Fatalf("undocumented error: %v", err)
+			}
+			return
+		}
+
+		// Whatever decodes must fit the format again.
+		if _, err := MarshalSecret(secret); err != nil {
+			t.Fatalf("decoded secret does not marshal: %v", err)
+		}
+	})
+}
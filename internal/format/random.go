@@ -1,13 +1,57 @@
 package format
 
-import "crypto/rand"
+import (
+	"crypto/rand"
+	"errors"
+	"io"
+)
+
+// ErrWeakRandom means the random source kept returning all zero bytes, which
+// only a broken source does for salts and nonces of 12 bytes or more.
+var ErrWeakRandom = errors.New("random source returned only zero bytes")
+
+// randomAttempts bounds how often RandomNonZeroBytes draws again.
+const randomAttempts = 3
+
+// randReader is the source of RandomBytes. Tests swap it for a deterministic
+// reader.
+var randReader io.Reader = rand.Reader
 
 func RandomBytes(n int) ([]byte, error) {
 	b := make([]byte, n)
-	_, err := rand.Read(b)
+	_, err := io.ReadFull(randReader, b)
 	if err != nil {
 		return nil, err
 	}
 
 	return b, nil
 }
+
+// RandomNonZeroBytes returns n random bytes for a salt or nonce. An all-zero
+// result is drawn again, up to randomAttempts times in total, and then fails
+// with ErrWeakRandom: reusing a nonce under the same AES-GCM key breaks its
+// security, and a source stuck on zeros would do exactly that.
+func RandomNonZeroBytes(n int) ([]byte, error) {
+	for range randomAttempts {
+		b, err := RandomBytes(n)
+		if err != nil {
+			return nil, err
+		}
+
+		if !allZero(b) {
+			return b, nil
+		}
+	}
+
+	return nil, ErrWeakRandom
+}
+
+func allZero(b []byte) bool {
+	for _, c := range b {
+		if c != 0 {
+			return false
+		}
+	}
+
+	return true
+}
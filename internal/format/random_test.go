@@ -2,7 +2,10 @@ package format
 
 import (
 	"bytes"
+	"errors"
+	"io"
 	"testing"
+	"testing/iotest"
 )
 
 func TestRandomBytes(t *testing.T) {
@@ -35,3 +38,70 @@ func TestRandomBytes(t *testing.T) {
 		}
 	})
 }
+
+// sequenceReader fills each Read from the next chunk, repeating the last one.
+type sequenceReader struct {
+	chunks [][]byte
+}
+
+func (r *sequenceReader) Read(p []byte) (int, error) {
+	chunk := r.chunks[0]
+	if len(r.chunks) > 1 {
+		r.chunks = r.chunks[1:]
+	}
+
+	for i := range p {
+		p[i] = chunk[i%len(chunk)]
+	}
+
+	return len(p), nil
+}
+
+func withRandReader(t *testing.T, r io.Reader) {
+	t.Helper()
+
+	previous := randReader
+	randReader = r
+	t.Cleanup(func() { randReader = previous })
+}
+
+func TestRandomNonZeroBytes(t *testing.T) {
+	t.Run("should redraw when the source returns only zeros", func(t *testing.T) {
+		withRandReader(t, &sequenceReader{chunks: [][]byte{{0}, {0}, {7}}})
+
+		b, err := RandomNonZeroBytes(12)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !bytes.Equal(b, bytes.Repeat([]byte{7}, 12)) {
+			t.Fatalf("expected the third draw, got %x", b)
+		}
+	})
+
+	t.Run("should fail with ErrWeakRandom after the bounded retries", func(t *testing.T) {
+		withRandReader(t, &sequenceReader{chunks: [][]byte{{0}}})
+
+		_, err := RandomNonZeroBytes(16)
+		if !errors.Is(err, ErrWeakRandom) {
+			t.Fatalf("expected ErrWeakRandom, got %v", err)
+		}
+	})
+
+	t.Run("should accept output with a single non-zero byte", func(t *testing.T) {
+		withRandReader(t, &sequenceReader{chunks: [][]byte{{0, 0, 0, 1}}})
+
+		if _, err := RandomNonZeroBytes(12); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("should return errors from the source", func(t *testing.T) {
+		withRandReader(t, iotest.ErrReader(io.ErrUnexpectedEOF))
+
+		_, err := RandomNonZeroBytes(12)
+		if !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Fatalf("expected the source error, got %v", err)
+		}
+	})
+}
@@ -29,7 +29,7 @@ func SealGCM(key []byte, fileBytes []byte) (*SealedCGM, error) {
 		return nil, err
 	}
 
-	nonce, err := format.RandomBytes(gcm.NonceSize())
+	nonce, err := format.RandomNonZeroBytes(gcm.NonceSize())
 	if err != nil {
 		return nil, err
 	}
@@ -2,7 +2,12 @@ package generator
 
 import (
 	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"math/big"
+	"strings"
 )
 
 const (
@@ -10,19 +15,118 @@ const (
 	symbols      = "!@#$%^&*()-_=+[]{}|;:,.<>?"
 )
 
+const (
+	DefaultLength = 16
+	MaxLength     = 1024
+)
+
+var (
+	ErrInvalidCharset = errors.New("invalid character set")
+	ErrEmptyCharset   = errors.New("character set is empty")
+	ErrInvalidLength  = fmt.Errorf("password length must be between 1 and %d", MaxLength)
+	ErrPolicyConflict = errors.New("conflicting password options")
+)
+
+// Options selects how a password is generated. The zero value gives a
+// DefaultLength password of letters, digits and the default symbols.
+type Options struct {
+	// Length is the number of characters; zero means DefaultLength.
+	Length int
+	// NoSymbols leaves symbols out.
+	NoSymbols bool
+	// Symbols replaces the default symbols when set.
+	Symbols string
+	// Alphabet replaces letters, digits and symbols when set.
+	Alphabet string
+}
+
+// Charset returns the characters a password generated with o is drawn from.
+func (o Options) Charset() (string, error) {
+	return Charset(o.NoSymbols, o.Symbols, o.Alphabet)
+}
+
+// Generate generates a password as described by opts from crypto/rand.
+func Generate(opts Options) ([]byte, error) {
+	return GenerateFrom(rand.Reader, opts)
+}
+
+// GenerateFrom generates a password as described by opts, drawing randomness
+// from r. Tests pass a fixed reader for reproducible output; everything else
+// should use Generate.
+func GenerateFrom(r io.Reader, opts Options) ([]byte, error) {
+	charset, err := opts.Charset()
+	if err != nil {
+		return nil, err
+	}
+
+	return generateFromCharset(r, opts.Length, charset)
+}
+
+// GeneratePassword generates a password of length characters.
+//
+// Deprecated: use Generate with Options.
 func GeneratePassword(length int, noSymbols bool) ([]byte, error) {
-	if length <= 0 {
-		length = 16
+	return Generate(Options{Length: length, NoSymbols: noSymbols})
+}
+
+// Charset builds the characters a password is drawn from. A non-empty
+// alphabet replaces the whole set; otherwise symbolSet, when given, replaces
+// the default symbols. Duplicate characters are dropped so every character is
+// equally likely.
+func Charset(noSymbols bool, symbolSet, alphabet string) (string, error) {
+	if alphabet != "" && (noSymbols || symbolSet != "") {
+		return "", fmt.Errorf("%w: a custom alphabet replaces the symbol options", ErrPolicyConflict)
+	}
+
+	if noSymbols && symbolSet != "" {
+		return "", fmt.Errorf("%w: custom symbols cannot be combined with no symbols", ErrPolicyConflict)
+	}
+
+	if alphabet != "" {
+		if err := validateCharset(alphabet); err != nil {
+			return "", err
+		}
+		return dedupe(alphabet), nil
 	}
 
 	charset := alphanumeric
-	if !noSymbols {
-		charset += symbols
+	if noSymbols {
+		return charset, nil
+	}
+
+	if symbolSet == "" {
+		return charset + symbols, nil
+	}
+
+	if err := validateCharset(symbolSet); err != nil {
+		return "", err
+	}
+
+	return dedupe(charset + symbolSet), nil
+}
+
+// GenerateFromCharset draws length characters uniformly from charset. A zero
+// length means DefaultLength.
+func GenerateFromCharset(length int, charset string) ([]byte, error) {
+	return generateFromCharset(rand.Reader, length, charset)
+}
+
+func generateFromCharset(r io.Reader, length int, charset string) ([]byte, error) {
+	if length == 0 {
+		length = DefaultLength
+	}
+
+	if length < 0 || length > MaxLength {
+		return nil, ErrInvalidLength
+	}
+
+	if err := validateCharset(charset); err != nil {
+		return nil, err
 	}
 
 	password := make([]byte, length)
 	for i := range password {
-		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(charset))))
+		idx, err := rand.Int(r, big.NewInt(int64(len(charset))))
 		if err != nil {
 			return nil, err
 		}
@@ -31,3 +135,46 @@ func GeneratePassword(length int, noSymbols bool) ([]byte, error) {
 
 	return password, nil
 }
+
+// Entropy estimates the strength in bits of a password of length characters
+// drawn uniformly from charset. It only accounts for the charset size, which
+// is exact for generated passwords but not for ones a person picked. A zero
+// length means DefaultLength.
+func Entropy(length int, charset string) float64 {
+	if length == 0 {
+		length = DefaultLength
+	}
+
+	if length < 0 || len(charset) == 0 {
+		return 0
+	}
+
+	return float64(length) * math.Log2(float64(len(charset)))
+}
+
+// validateCharset accepts printable ASCII only: whitespace and control
+// characters break pasting, and the generator picks single bytes.
+func validateCharset(charset string) error {
+	if charset == "" {
+		return ErrEmptyCharset
+	}
+
+	for _, c := range []byte(charset) {
+		if c <= ' ' || c > '~' {
+			return fmt.Errorf("%w: %q is not a printable ASCII character", ErrInvalidCharset, c)
+		}
+	}
+
+	return nil
+}
+
+func dedupe(charset string) string {
+	var b strings.Builder
+	for _, c := range []byte(charset) {
+		if !strings.ContainsRune(b.String(), rune(c)) {
+			b.WriteByte(c)
+		}
+	}
+
+	return b.String()
+}
@@ -1,6 +1,9 @@
 package generator
 
 import (
+	"bytes"
+	"errors"
+	"math"
 	"strings"
 	"testing"
 )
@@ -66,3 +69,172 @@ func TestGeneratePassword_Uniqueness(t *testing.T) {
 		t.Error("two generated passwords should not be identical")
 	}
 }
+
+func TestCharset_CustomSymbols(t *testing.T) {
+	charset, err := Charset(false, "-_.@", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if charset != alphanumeric+"-_.@" {
+		t.Errorf("expected alphanumeric plus custom symbols, got %q", charset)
+	}
+}
+
+func TestCharset_Alphabet(t *testing.T) {
+	charset, err := Charset(false, "", "abcabc123")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if charset != "abc123" {
+		t.Errorf("expected deduplicated alphabet, got %q", charset)
+	}
+}
+
+func TestCharset_Invalid(t *testing.T) {
+	inputs := []string{"a b", "ab\t", "ab\x00", "ção"}
+	for _, input := range inputs {
+		if _, err := Charset(false, input, ""); !errors.Is(err, ErrInvalidCharset) {
+			t.Errorf("Charset(symbols %q): expected ErrInvalidCharset, got %v", input, err)
+		}
+		if _, err := Charset(false, "", input); !errors.Is(err, ErrInvalidCharset) {
+			t.Errorf("Charset(alphabet %q): expected ErrInvalidCharset, got %v", input, err)
+		}
+	}
+}
+
+func TestGenerateFromCharset_OnlyUsesCharset(t *testing.T) {
+	pw, err := GenerateFromCharset(200, "xyz")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, b := range pw {
+		if !strings.ContainsRune("xyz", rune(b)) {
+			t.Errorf("invalid character %q in password", string(b))
+		}
+	}
+}
+
+func TestCharset_PolicyConflicts(t *testing.T) {
+	cases := []struct {
+		name      string
+		noSymbols bool
+		symbolSet string
+		alphabet  string
+	}{
+		{"custom symbols with no symbols", true, "-_", ""},
+		{"alphabet with custom symbols", false, "-_", "abc"},
+		{"alphabet with no symbols", true, "", "abc"},
+	}
+
+	for _, c := range cases {
+		_, err := Charset(c.noSymbols, c.symbolSet, c.alphabet)
+		if !errors.Is(err, ErrPolicyConflict) {
+			t.Errorf("%s: expected ErrPolicyConflict, got %v", c.name, err)
+		}
+	}
+}
+
+func TestGenerateFromCharset_EmptyCharset(t *testing.T) {
+	_, err := GenerateFromCharset(16, "")
+	if !errors.Is(err, ErrEmptyCharset) {
+		t.Fatalf("expected ErrEmptyCharset, got %v", err)
+	}
+}
+
+func TestGenerateFromCharset_InvalidLength(t *testing.T) {
+	for _, length := range []int{-1, MaxLength + 1} {
+		_, err := GenerateFromCharset(length, alphanumeric)
+		if !errors.Is(err, ErrInvalidLength) {
+			t.Errorf("length %d: expected ErrInvalidLength, got %v", length, err)
+		}
+	}
+}
+
+func TestEntropy(t *testing.T) {
+	tests := []struct {
+		length  int
+		charset string
+		bits    float64
+	}{
+		{16, alphanumeric, 95.27},
+		{16, alphanumeric + symbols, 103.35},
+		{0, alphanumeric, 95.27},
+		{8, "0123456789", 26.58},
+		{10, "ab", 10},
+		{10, "a", 0},
+		{16, "", 0},
+	}
+
+	for _, tt := range tests {
+		got := Entropy(tt.length, tt.charset)
+		if math.Abs(got-tt.bits) > 0.01 {
+			t.Errorf("Entropy(%d, %d chars): expected %.2f bits, got %.2f", tt.length, len(tt.charset), tt.bits, got)
+		}
+	}
+}
+
+func TestGenerateFrom(t *testing.T) {
+	t.Run("should be reproducible with a fixed reader", func(t *testing.T) {
+		pw, err := GenerateFrom(bytes.NewReader([]byte{0, 1, 1, 0, 1, 0}), Options{Length: 6, Alphabet: "ab"})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(pw) != "abbaba" {
+			t.Fatalf("expected %q, got %q", "abbaba", pw)
+		}
+	})
+
+	t.Run("should apply the zero-value defaults", func(t *testing.T) {
+		pw, err := GenerateFrom(bytes.NewReader(make([]byte, 64)), Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if string(pw) != strings.Repeat("a", DefaultLength) {
+			t.Fatalf("expected %d times 'a', got %q", DefaultLength, pw)
+		}
+	})
+
+	t.Run("should fail when the reader runs dry", func(t *testing.T) {
+		_, err := GenerateFrom(bytes.NewReader([]byte{0}), Options{Length: 4, Alphabet: "ab"})
+		if err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("should reject conflicting options", func(t *testing.T) {
+		_, err := GenerateFrom(bytes.NewReader(nil), Options{NoSymbols: true, Symbols: "!"})
+		if !errors.Is(err, ErrPolicyConflict) {
+			t.Fatalf("expected ErrPolicyConflict, got %v", err)
+		}
+	})
+}
+
+func TestGenerate_Options(t *testing.T) {
+	t.Run("should use the default length for the zero value", func(t *testing.T) {
+		pw, err := Generate(Options{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(pw) != DefaultLength {
+			t.Fatalf("expected length %d, got %d", DefaultLength, len(pw))
+		}
+	})
+
+	t.Run("should only use the custom symbols", func(t *testing.T) {
+		opts := Options{Length: 64, Symbols: "#"}
+		charset, err := opts.Charset()
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+
+		pw, err := Generate(opts)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		for _, c := range pw {
+			if !strings.ContainsRune(charset, rune(c)) {
+				t.Fatalf("unexpected character %q", c)
+			}
+		}
+	})
+}
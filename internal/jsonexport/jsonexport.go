@@ -0,0 +1,161 @@
+package jsonexport
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+	"unicode/utf8"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+)
+
+// VERSION is the schema version written by Marshal. Read rejects any other.
+const VERSION = 1
+
+var (
+	ErrMissingVersion     = errors.New("not an msk export: missing version")
+	ErrUnsupportedVersion = errors.New("unsupported export version")
+)
+
+// Document is the plaintext JSON export:
+//
+//	{"version":1,"exported_at":"...","secrets":[{"name":"...","password":"..."}]}
+//
+// Passwords are plain JSON strings, so anyone who can read the file can read
+// every password in it. JSON strings cannot hold arbitrary bytes, so a
+// password that is not valid UTF-8, such as one added with --stdin-raw, is
+// written as standard base64 in "password_b64" instead, with "password" left
+// empty.
+type Document struct {
+	Version    int       `json:"version"`
+	ExportedAt time.Time `json:"exported_at"`
+	Secrets    []Secret  `json:"secrets"`
+}
+
+// Secret is one exported secret. Empty metadata is omitted.
+type Secret struct {
+	Name        string    `json:"name"`
+	Username    string    `json:"username,omitempty"`
+	URL         string    `json:"url,omitempty"`
+	Password    string    `json:"password"`
+	PasswordB64 string    `json:"password_b64,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	CreatedAt   time.Time `json:"created_at,omitzero"`
+	UpdatedAt   time.Time `json:"updated_at,omitzero"`
+	LoginFields []string  `json:"login_fields,omitempty"`
+}
+
+// FromSecret converts a decrypted secret for export. A password that is not
+// valid UTF-8 goes to PasswordB64, since encoding/json would replace its
+// invalid bytes.
+func FromSecret(secret domain.Secret) Secret {
+	exported := Secret{
+		Name:        secret.Name,
+		Username:    secret.Username,
+		URL:         secret.URL,
+		Notes:       secret.Notes,
+		Tags:        secret.Tags,
+		CreatedAt:   secret.CreatedAt,
+		UpdatedAt:   secret.UpdatedAt,
+		LoginFields: secret.LoginFields,
+	}
+
+	if utf8.Valid(secret.Password) {
+		exported.Password = string(secret.Password)
+	} else {
+		exported.PasswordB64 = base64.StdEncoding.EncodeToString(secret.Password)
+	}
+
+	return exported
+}
+
+// Secret converts an exported secret back, decoding PasswordB64 when set. The
+// password is a fresh copy the caller can wipe; the string it came from cannot
+// be.
+func (s Secret) Secret() (domain.Secret, error) {
+	password := []byte(s.Password)
+
+	if s.PasswordB64 != "" {
+		if s.Password != "" {
+			return domain.Secret{}, errors.New("both password and password_b64 are set")
+		}
+
+		decoded, err := base64.StdEncoding.DecodeString(s.PasswordB64)
+		if err != nil {
+			return domain.Secret{}, fmt.Errorf("invalid password_b64: %w", err)
+		}
+		password = decoded
+	}
+
+	return domain.Secret{
+		Name:        s.Name,
+		Username:    s.Username,
+		URL:         s.URL,
+		Password:    password,
+		Notes:       s.Notes,
+		Tags:        s.Tags,
+		CreatedAt:   s.CreatedAt,
+		UpdatedAt:   s.UpdatedAt,
+		LoginFields: s.LoginFields,
+	}, nil
+}
+
+// Marshal encodes secrets as an indented VERSION document. The caller should
+// wipe the result.
+func Marshal(secrets []domain.Secret, exportedAt time.Time) ([]byte, error) {
+	doc := Document{
+		Version:    VERSION,
+		ExportedAt: exportedAt.UTC(),
+		Secrets:    make([]Secret, 0, len(secrets)),
+	}
+
+	for _, secret := range secrets {
+		doc.Secrets = append(doc.Secrets, FromSecret(secret))
+	}
+
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(data, '\n'), nil
+}
+
+// Read decodes a document, checking its version before anything else so a
+// file from a newer msk fails with ErrUnsupportedVersion rather than being
+// half understood.
+func Read(r io.Reader) (Document, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return Document{}, err
+	}
+	defer wipe.Bytes(data)
+
+	var header struct {
+		Version *int `json:"version"`
+	}
+
+	if err := json.Unmarshal(data, &header); err != nil {
+		return Document{}, fmt.Errorf("invalid export: %w", err)
+	}
+
+	if header.Version == nil {
+		return Document{}, ErrMissingVersion
+	}
+
+	if *header.Version != VERSION {
+		return Document{}, fmt.Errorf("%w %d, expected %d", ErrUnsupportedVersion, *header.Version, VERSION)
+	}
+
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return Document{}, fmt.Errorf("invalid export: %w", err)
+	}
+
+	return doc, nil
+}
@@ -0,0 +1,162 @@
+package jsonexport
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/domain"
+)
+
+func TestMarshalRead(t *testing.T) {
+	created := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	updated := created.Add(time.Hour)
+
+	secrets := []domain.Secret{
+		{
+			Name:      "github",
+			Password:  []byte("hunter2"),
+			Username:  "octocat",
+			URL:       "https://github.com",
+			Notes:     "line one\nline two",
+			Tags:      []string{"work", "git"},
+			CreatedAt: created,
+			UpdatedAt: updated,
+		},
+		{Name: "work/bare", Password: []byte(`quo"te\`)},
+	}
+
+	t.Run("should round-trip secrets", func(t *testing.T) {
+		data, err := Marshal(secrets, updated)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		doc, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if doc.Version != VERSION || !doc.ExportedAt.Equal(updated) {
+			t.Fatalf("unexpected header: version %d, exported at %v", doc.Version, doc.ExportedAt)
+		}
+
+		if len(doc.Secrets) != len(secrets) {
+			t.Fatalf("expected %d secrets, got %d", len(secrets), len(doc.Secrets))
+		}
+
+		for i, exported := range doc.Secrets {
+			got, err := exported.Secret()
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+
+			if !reflect.DeepEqual(got, secrets[i]) {
+				t.Fatalf("expected %+v, got %+v", secrets[i], got)
+			}
+		}
+	})
+
+	t.Run("should use the documented field names and leave out empty fields", func(t *testing.T) {
+		data, err := Marshal(secrets, updated)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		var raw struct {
+			Version    int              `json:"version"`
+			ExportedAt string           `json:"exported_at"`
+			Secrets    []map[string]any `json:"secrets"`
+		}
+
+		if err := json.Unmarshal(data, &raw); err != nil {
+			t.Fatalf("expected valid JSON, got %v", err)
+		}
+
+		for _, key := range []string{"name", "username", "url", "password", "notes", "tags", "created_at", "updated_at"} {
+			if _, ok := raw.Secrets[0][key]; !ok {
+				t.Fatalf("expected key %q in %v", key, raw.Secrets[0])
+			}
+		}
+
+		if len(raw.Secrets[1]) != 2 {
+			t.Fatalf("expected only name and password, got %v", raw.Secrets[1])
+		}
+
+		if raw.ExportedAt != "2024-01-02T04:04:05Z" {
+			t.Fatalf("expected exported_at in UTC, got %q", raw.ExportedAt)
+		}
+	})
+}
+
+func TestBinaryPassword(t *testing.T) {
+	t.Run("should round-trip a password that is not valid UTF-8", func(t *testing.T) {
+		secrets := []domain.Secret{{Name: "key", Password: []byte{0xff, 0x00, 'a', 0xc3}}}
+
+		data, err := Marshal(secrets, time.Now())
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		doc, err := Read(bytes.NewReader(data))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if doc.Secrets[0].Password != "" || doc.Secrets[0].PasswordB64 != "/wBhww==" {
+			t.Fatalf("expected the password in password_b64 only, got %+v", doc.Secrets[0])
+		}
+
+		got, err := doc.Secrets[0].Secret()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !bytes.Equal(got.Password, secrets[0].Password) {
+			t.Fatalf("expected password %x, got %x", secrets[0].Password, got.Password)
+		}
+	})
+
+	t.Run("should reject invalid base64", func(t *testing.T) {
+		if _, err := (Secret{Name: "key", PasswordB64: "not base64!"}).Secret(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+
+	t.Run("should reject both password fields", func(t *testing.T) {
+		if _, err := (Secret{Name: "key", Password: "a", PasswordB64: "YQ=="}).Secret(); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
+
+func TestReadVersion(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{name: "should reject a missing version", input: `{"secrets":[]}`, wantErr: ErrMissingVersion},
+		{name: "should reject a newer version", input: `{"version":2,"secrets":[]}`, wantErr: ErrUnsupportedVersion},
+		{name: "should reject version 0", input: `{"version":0}`, wantErr: ErrUnsupportedVersion},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Read(strings.NewReader(tt.input))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("expected %v, got %v", tt.wantErr, err)
+			}
+		})
+	}
+
+	t.Run("should reject invalid JSON", func(t *testing.T) {
+		if _, err := Read(strings.NewReader("KEY=value")); err == nil {
+			t.Fatal("expected an error")
+		}
+	})
+}
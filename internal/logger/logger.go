@@ -2,27 +2,80 @@ package logger
 
 import (
 	"fmt"
+	"io"
 	"os"
 
 	"github.com/fatih/color"
+	"golang.org/x/term"
 )
 
+// output receives every message. It is stderr, keeping stdout clean for
+// command output, unless SetOutput replaces it.
+var output io.Writer = os.Stderr
+
+// SetOutput sends all messages to w, e.g. a buffer in tests.
+func SetOutput(w io.Writer) {
+	output = w
+}
+
 func PrintInfo(message string) {
-	color.New(color.FgHiWhite).Fprint(os.Stderr, message)
+	color.New(color.FgHiWhite).Fprint(output, message)
 }
 
 func PrintSuccess(message string) {
-	color.New(color.FgGreen).Fprint(os.Stderr, message)
+	color.New(color.FgGreen).Fprint(output, message)
 }
 
 func PrintSuccessf(format string, a ...any) {
-	color.New(color.FgGreen).Fprintf(os.Stderr, format, a...)
+	color.New(color.FgGreen).Fprintf(output, format, a...)
 }
 
 func PrintError(format string, a ...any) {
-	color.New(color.FgRed).Fprintf(os.Stderr, format, a...)
+	color.New(color.FgRed).Fprintf(output, format, a...)
 }
 
+// Lb ends the current message line.
 func Lb() {
-	fmt.Println()
+	fmt.Fprintln(output)
+}
+
+var quiet bool
+
+// SetQuiet suppresses progress output.
+func SetQuiet(q bool) {
+	quiet = q
+}
+
+// Progress prints a "label N/M" line on stderr that rewrites itself as work
+// advances. It stays silent under --quiet, when stderr is not a terminal and
+// when output has been redirected, so it never mixes with piped output.
+type Progress struct {
+	label   string
+	enabled bool
+	printed bool
+}
+
+func NewProgress(label string) *Progress {
+	return &Progress{
+		label:   label,
+		enabled: !quiet && output == os.Stderr && term.IsTerminal(int(os.Stderr.Fd())),
+	}
+}
+
+// Update reports that done of total items have been processed.
+func (p *Progress) Update(done, total int) {
+	if !p.enabled || total == 0 {
+		return
+	}
+
+	fmt.Fprintf(output, "\r%s %d/%d", p.label, done, total)
+	p.printed = true
+}
+
+// Done ends the progress line so following output starts on a new line.
+func (p *Progress) Done() {
+	if p.printed {
+		fmt.Fprint(output, "\r\033[K")
+		p.printed = false
+	}
 }
@@ -1,17 +1,45 @@
 package meta
 
 const (
-	MSK_MAGIC_VALUE  = "MSK"
+	MSK_MAGIC_VALUE = "MSK"
+	// MSK_FILE_VERSION files are sealed with a key derived from the master
+	// password. The config and its companion files always use it.
 	MSK_FILE_VERSION = byte(1)
+	// MSK_FILE_VERSION_ENVELOPE files are sealed with a key derived from the
+	// random vault key, which is itself wrapped by the master password.
+	MSK_FILE_VERSION_ENVELOPE = byte(2)
 
 	MSK_MAGIC_SIZE   = 3
 	MSK_VERSION_SIZE = 1
 	MSK_SALT_SIZE    = 16
 	MSK_NONCE_SIZE   = 12
 	MSK_HEADER_SIZE  = MSK_MAGIC_SIZE + MSK_VERSION_SIZE + MSK_SALT_SIZE + MSK_NONCE_SIZE
+
+	// Every non-empty AES-GCM ciphertext ends with the authentication tag.
+	MSK_GCM_TAG_SIZE = 16
 )
 
 const (
 	SECRET_NAME_LENGTH_SIZE     = 2
 	SECRET_PASSWORD_LENGTH_SIZE = 2
+	SECRET_MAX_FIELD_LENGTH     = 1<<16 - 1
+
+	// Metadata follows the password as a versioned list of tagged,
+	// length-prefixed fields. Payloads without metadata end after the password.
+	SECRET_PAYLOAD_VERSION      = byte(1)
+	SECRET_PAYLOAD_VERSION_SIZE = 1
+	SECRET_FIELD_TAG_SIZE       = 1
+	SECRET_FIELD_LENGTH_SIZE    = 2
+)
+
+const (
+	// Config files start with their own magic, so they cannot be mistaken for
+	// secrets. Configs written before it existed use the secret file format.
+	MSK_CONFIG_MAGIC_VALUE = "MSKC"
+	MSK_CONFIG_VERSION     = byte(1)
+
+	MSK_CONFIG_MAGIC_SIZE  = 4
+	MSK_CONFIG_HEADER_SIZE = MSK_CONFIG_MAGIC_SIZE + MSK_VERSION_SIZE + MSK_SALT_SIZE + MSK_NONCE_SIZE
+
+	CONFIG_VAULT_PATH_LENGTH_SIZE = 2
 )
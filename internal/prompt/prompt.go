@@ -2,10 +2,10 @@ package prompt
 
 import (
 	"bufio"
+	"crypto/subtle"
 	"errors"
-	"fmt"
+	"io"
 	"os"
-	"reflect"
 	"strings"
 
 	"github.com/amauribechtoldjr/msk/internal/logger"
@@ -16,6 +16,24 @@ import (
 
 var ErrEmptyInput = errors.New("input cannot be empty")
 var ErrConfirmationMatch = errors.New("invalid master key confirmation")
+var ErrInputTooLarge = errors.New("input is too large")
+var ErrNoPrompt = errors.New("cannot prompt for a password: stdin is not a terminal or --no-prompt is set")
+var ErrNoMasterPasswordSource = errors.New("no master password source available (run \"msk unlock\" and set MSK_SESSION)")
+
+// noPrompt makes password prompts fail even on a terminal.
+var noPrompt bool
+
+// SetNoPrompt makes every password prompt fail right away instead of waiting
+// on input, e.g. for cron jobs that must never block.
+func SetNoPrompt(n bool) {
+	noPrompt = n
+}
+
+// CanPrompt reports whether a password prompt can be answered: stdin is a
+// terminal and prompts were not disabled with SetNoPrompt.
+func CanPrompt() bool {
+	return !noPrompt && term.IsTerminal(int(os.Stdin.Fd()))
+}
 
 func ReadString(label string) (string, error) {
 	reader := bufio.NewReader(os.Stdin)
@@ -45,9 +63,13 @@ func ReadBoolean(label string) (bool, error) {
 }
 
 func ReadSafeValue(label string) ([]byte, error) {
+	if !CanPrompt() {
+		return nil, ErrNoPrompt
+	}
+
 	logger.PrintInfo(label)
 	safeValue, err := term.ReadPassword(int(os.Stdin.Fd()))
-	fmt.Fprintln(os.Stderr)
+	logger.Lb()
 
 	if err != nil {
 		return nil, err
@@ -60,7 +82,36 @@ func ReadSafeValue(label string) ([]byte, error) {
 	return safeValue, nil
 }
 
+// ReadRaw reads stdin verbatim until EOF, keeping every byte including
+// newlines, and fails if it holds more than limit bytes. The buffer is sized
+// up front so reading never leaves an unwiped copy behind; the caller must
+// wipe the result.
+func ReadRaw(limit int) ([]byte, error) {
+	buf := make([]byte, limit+1)
+
+	n, err := io.ReadFull(os.Stdin, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		wipe.Bytes(buf)
+		return nil, err
+	}
+
+	if n > limit {
+		wipe.Bytes(buf)
+		return nil, ErrInputTooLarge
+	}
+
+	if n == 0 {
+		return nil, ErrEmptyInput
+	}
+
+	return buf[:n], nil
+}
+
 func ReadMasterPassword(shouldConfirm bool) ([]byte, error) {
+	if !CanPrompt() {
+		return nil, ErrNoMasterPasswordSource
+	}
+
 	pass, err := ReadSafeValue("Enter master password:")
 	if err != nil {
 		wipe.Bytes(pass)
@@ -79,13 +130,13 @@ func ReadMasterPassword(shouldConfirm bool) ([]byte, error) {
 			return nil, err
 		}
 
-		if !reflect.DeepEqual(pass, passConfirmation) {
+		match := subtle.ConstantTimeCompare(pass, passConfirmation) == 1
+		wipe.Bytes(passConfirmation)
+
+		if !match {
 			wipe.Bytes(pass)
-			wipe.Bytes(passConfirmation)
 			return nil, ErrConfirmationMatch
 		}
-
-		wipe.Bytes(passConfirmation)
 	}
 
 	return pass, nil
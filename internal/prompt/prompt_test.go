@@ -0,0 +1,25 @@
+package prompt
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNoPrompt(t *testing.T) {
+	SetNoPrompt(true)
+	t.Cleanup(func() { SetNoPrompt(false) })
+
+	t.Run("should fail instead of prompting for a value", func(t *testing.T) {
+		_, err := ReadSafeValue("Enter password:")
+		if !errors.Is(err, ErrNoPrompt) {
+			t.Fatalf("expected ErrNoPrompt, got %v", err)
+		}
+	})
+
+	t.Run("should fail instead of prompting for the master password", func(t *testing.T) {
+		_, err := ReadMasterPassword(false)
+		if !errors.Is(err, ErrNoMasterPasswordSource) {
+			t.Fatalf("expected ErrNoMasterPasswordSource, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,198 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/files"
+)
+
+// BackupDirName is the hidden folder under the vault root holding backups.
+// Being hidden keeps it out of GetFiles and GetFilesRecursive.
+const BackupDirName = ".backups"
+
+// backupTimeLayout sorts lexically and keeps nanoseconds, so two backups of
+// the same secret taken in quick succession do not collide.
+const backupTimeLayout = "20060102T150405.000000000Z"
+
+// Backup is an encrypted copy of a secret file taken before it was changed.
+type Backup struct {
+	Path      string
+	CreatedAt time.Time
+}
+
+// BackupFile copies the encrypted file of name into the backup folder as
+// "<name>.<timestamp>.msk", with long names shortened, and then prunes old backups of name according to
+// BackupKeep and BackupMaxAge. The copy is not decrypted.
+func (s *Store) BackupFile(name string) (Backup, error) {
+	data, err := s.GetFile(name)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backup := Backup{CreatedAt: time.Now().UTC()}
+	backup.Path = s.backupPrefix(name) + backup.CreatedAt.Format(backupTimeLayout) + ".msk"
+
+	if err := s.ensureBackupFolders(name); err != nil {
+		return Backup{}, ioError(err)
+	}
+
+	if err := files.WriteAtomicFile(backup.Path, data, s.fileMode()); err != nil {
+		return Backup{}, ioError(err)
+	}
+
+	if err := s.pruneBackups(name, backup.CreatedAt); err != nil {
+		return backup, err
+	}
+
+	return backup, nil
+}
+
+// Backups returns the backups of name, newest first.
+func (s *Store) Backups(name string) ([]Backup, error) {
+	prefix := s.backupPrefix(name)
+
+	entries, err := os.ReadDir(filepath.Dir(prefix))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, ioError(err)
+	}
+
+	var backups []Backup
+
+	for _, entry := range entries {
+		path := filepath.Join(filepath.Dir(prefix), entry.Name())
+		if entry.IsDir() || !strings.HasPrefix(path, prefix) || !isSecretFile(path) {
+			continue
+		}
+
+		// A secret named "a.b" shares the "a." prefix with "a", so only accept
+		// entries whose remainder is exactly a timestamp.
+		stamp := strings.TrimSuffix(strings.TrimPrefix(path, prefix), ".msk")
+		createdAt, err := time.Parse(backupTimeLayout, stamp)
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Path: path, CreatedAt: createdAt})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return backups, nil
+}
+
+// RestoreBackup atomically replaces the file of name with the given backup,
+// creating it if it was deleted. The current file, if any, is backed up first
+// so the restore can itself be undone. The restored backup is kept.
+func (s *Store) RestoreBackup(name string, backup Backup) error {
+	// Read the backup before taking a new one, which may prune it.
+	data, err := files.ReadFile(backup.Path, ErrNotFound)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return err
+		}
+
+		return ioError(err)
+	}
+
+	exists, err := s.FileExists(name)
+	if err != nil {
+		return err
+	}
+
+	if exists {
+		if _, err := s.BackupFile(name); err != nil {
+			return err
+		}
+	}
+
+	return s.SaveFile(data, name)
+}
+
+// pruneBackups shreds the backups of name beyond BackupKeep and those older
+// than BackupMaxAge. Zero disables the respective limit.
+func (s *Store) pruneBackups(name string, now time.Time) error {
+	if s.BackupKeep == 0 && s.BackupMaxAge == 0 {
+		return nil
+	}
+
+	backups, err := s.Backups(name)
+	if err != nil {
+		return err
+	}
+
+	for i, backup := range backups {
+		tooMany := s.BackupKeep > 0 && i >= s.BackupKeep
+		tooOld := s.BackupMaxAge > 0 && now.Sub(backup.CreatedAt) > s.BackupMaxAge
+
+		if !tooMany && !tooOld {
+			continue
+		}
+
+		if err := files.ShredFile(backup.Path); err != nil {
+			return ioError(err)
+		}
+	}
+
+	return nil
+}
+
+// backupBaseMaxLength leaves room for the "." and timestamp a backup adds to
+// the file name of its secret, and for the ".msk" extension.
+const backupBaseMaxLength = files.MAX_FILENAME_LENGTH - len(".") - len(backupTimeLayout) - len(".msk")
+
+// backupPrefix is the path every backup file of name starts with.
+func (s *Store) backupPrefix(name string) string {
+	path := filepath.Join(
+		s.Path,
+		BackupDirName,
+		filepath.FromSlash(s.storageName(name)),
+	)
+
+	return filepath.Join(filepath.Dir(path), backupBase(filepath.Base(path))) + "."
+}
+
+// backupBase shortens a file name too long to take a timestamp to its start,
+// a "~" and a hash of the whole name. Names cannot contain "~", so a
+// shortened name never matches the backups of another secret.
+func backupBase(base string) string {
+	if len(base) <= backupBaseMaxLength {
+		return base
+	}
+
+	sum := sha256.Sum256([]byte(base))
+	hash := hex.EncodeToString(sum[:8])
+
+	return base[:backupBaseMaxLength-len("~")-len(hash)] + "~" + hash
+}
+
+// ensureBackupFolders creates the backup folder and, for folder-aware names,
+// the matching folders inside it.
+func (s *Store) ensureBackupFolders(name string) error {
+	dir := filepath.Dir(s.backupPrefix(name))
+
+	if err := os.MkdirAll(dir, s.dirMode()); err != nil {
+		return err
+	}
+
+	// MkdirAll is subject to the umask; apply the configured mode exactly.
+	for ; dir != s.Path; dir = filepath.Dir(dir) {
+		if err := os.Chmod(dir, s.dirMode()); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
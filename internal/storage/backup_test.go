@@ -0,0 +1,276 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/amauribechtoldjr/msk/internal/files"
+	"github.com/amauribechtoldjr/msk/internal/validator"
+)
+
+func TestBackupFile(t *testing.T) {
+	t.Run("should copy the file into the backup folder", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := store.SaveFile([]byte("v1"), "work/github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		backup, err := store.BackupFile("work/github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		wantDir := filepath.Join(store.Path, BackupDirName, "work")
+		if filepath.Dir(backup.Path) != wantDir {
+			t.Fatalf("expected backup in %s, got %s", wantDir, backup.Path)
+		}
+
+		data, err := os.ReadFile(backup.Path)
+		if err != nil {
+			t.Fatalf("failed to read backup: %v", err)
+		}
+
+		if !bytes.Equal(data, []byte("v1")) {
+			t.Fatalf("expected backup to hold v1, got %q", data)
+		}
+	})
+
+	t.Run("should return ErrNotFound for a missing secret", func(t *testing.T) {
+		store := initializeStore(t)
+
+		_, err := store.BackupFile("missing")
+		if !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("should not list backups as secrets", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := store.SaveFile([]byte("v1"), "github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		if _, err := store.BackupFile("github"); err != nil {
+			t.Fatalf("failed to back up: %v", err)
+		}
+
+		names, err := store.GetFilesRecursive()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(names) != 1 || names[0] != "github" {
+			t.Fatalf("expected only github, got %v", names)
+		}
+	})
+
+	t.Run("should keep at most BackupKeep backups", func(t *testing.T) {
+		store := initializeStore(t)
+		store.BackupKeep = 2
+
+		if err := store.SaveFile([]byte("v1"), "github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		for range 4 {
+			if _, err := store.BackupFile("github"); err != nil {
+				t.Fatalf("failed to back up: %v", err)
+			}
+		}
+
+		backups, err := store.Backups("github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(backups) != 2 {
+			t.Fatalf("expected 2 backups, got %d", len(backups))
+		}
+	})
+
+	t.Run("should drop backups older than BackupMaxAge", func(t *testing.T) {
+		store := initializeStore(t)
+		store.BackupMaxAge = time.Hour
+
+		if err := store.SaveFile([]byte("v1"), "github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		old := filepath.Join(store.Path, BackupDirName, "github."+time.Now().UTC().Add(-2*time.Hour).Format(backupTimeLayout)+".msk")
+		if err := os.MkdirAll(filepath.Dir(old), 0o700); err != nil {
+			t.Fatalf("failed to create backup folder: %v", err)
+		}
+
+		if err := os.WriteFile(old, []byte("v0"), 0o600); err != nil {
+			t.Fatalf("failed to write old backup: %v", err)
+		}
+
+		if _, err := store.BackupFile("github"); err != nil {
+			t.Fatalf("failed to back up: %v", err)
+		}
+
+		if _, err := os.Stat(old); !os.IsNotExist(err) {
+			t.Fatalf("expected the old backup to be removed, got %v", err)
+		}
+	})
+}
+
+func TestBackupLongName(t *testing.T) {
+	t.Run("should back up and restore a name of the maximum length", func(t *testing.T) {
+		store := initializeStore(t)
+
+		name := "work/" + strings.Repeat("a", validator.NAME_MAX_LENGTH)
+		other := "work/" + strings.Repeat("a", validator.NAME_MAX_LENGTH-1) + "b"
+
+		for _, n := range []string{name, other} {
+			if err := store.SaveFile([]byte("v1-"+n), n); err != nil {
+				t.Fatalf("failed to save file: %v", err)
+			}
+
+			if _, err := store.BackupFile(n); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		backups, err := store.Backups(name)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(backups) != 1 {
+			t.Fatalf("expected 1 backup, got %d", len(backups))
+		}
+
+		if length := len(filepath.Base(backups[0].Path)); length > files.MAX_FILENAME_LENGTH {
+			t.Fatalf("expected a file name of at most %d bytes, got %d", files.MAX_FILENAME_LENGTH, length)
+		}
+
+		if err := store.SaveFile([]byte("v2"), name); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		if err := store.RestoreBackup(name, backups[0]); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		data, err := store.GetFile(name)
+		if err != nil || string(data) != "v1-"+name {
+			t.Fatalf("expected the backup to be restored, got %q (%v)", data, err)
+		}
+	})
+}
+
+func TestBackups(t *testing.T) {
+	t.Run("should list backups newest first and ignore similar names", func(t *testing.T) {
+		store := initializeStore(t)
+
+		for _, name := range []string{"github", "github.work"} {
+			if err := store.SaveFile([]byte(name), name); err != nil {
+				t.Fatalf("failed to save file: %v", err)
+			}
+
+			if _, err := store.BackupFile(name); err != nil {
+				t.Fatalf("failed to back up: %v", err)
+			}
+		}
+
+		if _, err := store.BackupFile("github"); err != nil {
+			t.Fatalf("failed to back up: %v", err)
+		}
+
+		backups, err := store.Backups("github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(backups) != 2 {
+			t.Fatalf("expected 2 backups, got %d", len(backups))
+		}
+
+		if !backups[0].CreatedAt.After(backups[1].CreatedAt) {
+			t.Fatalf("expected newest first, got %v then %v", backups[0].CreatedAt, backups[1].CreatedAt)
+		}
+	})
+
+	t.Run("should return no backups when there are none", func(t *testing.T) {
+		store := initializeStore(t)
+
+		backups, err := store.Backups("github")
+		if err != nil || len(backups) != 0 {
+			t.Fatalf("expected no backups and no error, got %v, %v", backups, err)
+		}
+	})
+}
+
+func TestRestoreBackup(t *testing.T) {
+	t.Run("should restore a deleted secret", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := store.SaveFile([]byte("v1"), "github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		backup, err := store.BackupFile("github")
+		if err != nil {
+			t.Fatalf("failed to back up: %v", err)
+		}
+
+		if err := store.DeleteFile("github"); err != nil {
+			t.Fatalf("failed to delete: %v", err)
+		}
+
+		if err := store.RestoreBackup("github", backup); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		data, err := store.GetFile("github")
+		if err != nil || !bytes.Equal(data, []byte("v1")) {
+			t.Fatalf("expected v1, got %q (%v)", data, err)
+		}
+	})
+
+	t.Run("should back up the current version before replacing it", func(t *testing.T) {
+		store := initializeStore(t)
+		store.BackupKeep = 1
+
+		if err := store.SaveFile([]byte("v1"), "github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		backup, err := store.BackupFile("github")
+		if err != nil {
+			t.Fatalf("failed to back up: %v", err)
+		}
+
+		if err := store.SaveFile([]byte("v2"), "github"); err != nil {
+			t.Fatalf("failed to save file: %v", err)
+		}
+
+		// With BackupKeep at 1, backing up v2 prunes the backup being restored.
+		if err := store.RestoreBackup("github", backup); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		data, err := store.GetFile("github")
+		if err != nil || !bytes.Equal(data, []byte("v1")) {
+			t.Fatalf("expected v1, got %q (%v)", data, err)
+		}
+
+		backups, err := store.Backups("github")
+		if err != nil || len(backups) != 1 {
+			t.Fatalf("expected one backup, got %v (%v)", backups, err)
+		}
+
+		saved, err := os.ReadFile(backups[0].Path)
+		if err != nil || !bytes.Equal(saved, []byte("v2")) {
+			t.Fatalf("expected the backup to hold v2, got %q (%v)", saved, err)
+		}
+	})
+}
@@ -0,0 +1,270 @@
+package storage
+
+import (
+	"context"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// memRoot is the directory a MemStore pretends to live in.
+const memRoot = "/memstore"
+
+// MemStore is a Repository kept in memory, for tests of code built on a
+// Repository that should not touch the filesystem. Like Store, names are
+// lowercased unless CaseSensitive is set. Backups are kept without limits.
+//
+// Fail makes operations fail: an entry keyed by a method name, such as
+// "SaveFile", is returned wrapped in ErrStorageIO whenever that method runs.
+//
+// Repository methods take no context, so Context stands in for one: once it
+// is done, every method that can fail returns its error, as if the operation
+// had been cancelled halfway.
+type MemStore struct {
+	CaseSensitive bool
+	Fail          map[string]error
+	Context       context.Context
+
+	mu      sync.Mutex
+	files   map[string][]byte
+	backups map[string][]byte
+}
+
+func NewMemStore() *MemStore {
+	return &MemStore{
+		files:   map[string][]byte{},
+		backups: map[string][]byte{},
+	}
+}
+
+func (m *MemStore) FileExists(name string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("FileExists"); err != nil {
+		return false, err
+	}
+
+	_, ok := m.files[m.storageName(name)]
+	return ok, nil
+}
+
+func (m *MemStore) GetFile(name string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("GetFile"); err != nil {
+		return nil, err
+	}
+
+	data, ok := m.files[m.storageName(name)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return slices.Clone(data), nil
+}
+
+func (m *MemStore) SaveFile(encryptedFile []byte, name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("SaveFile"); err != nil {
+		return err
+	}
+
+	m.files[m.storageName(name)] = slices.Clone(encryptedFile)
+	return nil
+}
+
+func (m *MemStore) DeleteFile(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("DeleteFile"); err != nil {
+		return err
+	}
+
+	key := m.storageName(name)
+	if _, ok := m.files[key]; !ok {
+		return ErrNotFound
+	}
+
+	delete(m.files, key)
+	return nil
+}
+
+// GetFiles returns the secrets outside folders with their ".msk" extension,
+// like Store.GetFiles.
+func (m *MemStore) GetFiles() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("GetFiles"); err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, name := range m.sortedNames() {
+		if !strings.Contains(name, "/") {
+			names = append(names, name+".msk")
+		}
+	}
+
+	return names, nil
+}
+
+// GetFilesRecursive returns every secret name, folders included, like
+// Store.GetFilesRecursive.
+func (m *MemStore) GetFilesRecursive() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("GetFilesRecursive"); err != nil {
+		return nil, err
+	}
+
+	return m.sortedNames(), nil
+}
+
+func (m *MemStore) Dir() string {
+	return memRoot
+}
+
+func (m *MemStore) FilePath(name string) string {
+	return path.Join(memRoot, m.storageName(name)+".msk")
+}
+
+func (m *MemStore) Purge() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("Purge"); err != nil {
+		return nil, err
+	}
+
+	clear(m.files)
+	clear(m.backups)
+	return nil, nil
+}
+
+func (m *MemStore) BackupFile(name string) (Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.backupFile(name)
+}
+
+func (m *MemStore) Backups(name string) ([]Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("Backups"); err != nil {
+		return nil, err
+	}
+
+	prefix := m.backupPrefix(name)
+
+	var backups []Backup
+	for backupPath := range m.backups {
+		stamp, ok := strings.CutPrefix(backupPath, prefix)
+		if !ok {
+			continue
+		}
+
+		createdAt, err := time.Parse(backupTimeLayout, strings.TrimSuffix(stamp, ".msk"))
+		if err != nil {
+			continue
+		}
+
+		backups = append(backups, Backup{Path: backupPath, CreatedAt: createdAt})
+	}
+
+	slices.SortFunc(backups, func(a, b Backup) int {
+		return b.CreatedAt.Compare(a.CreatedAt)
+	})
+
+	return backups, nil
+}
+
+func (m *MemStore) RestoreBackup(name string, backup Backup) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.fail("RestoreBackup"); err != nil {
+		return err
+	}
+
+	data, ok := m.backups[backup.Path]
+	if !ok {
+		return ErrNotFound
+	}
+
+	key := m.storageName(name)
+	if _, exists := m.files[key]; exists {
+		if _, err := m.backupFile(name); err != nil {
+			return err
+		}
+	}
+
+	m.files[key] = slices.Clone(data)
+	return nil
+}
+
+func (m *MemStore) backupFile(name string) (Backup, error) {
+	if err := m.fail("BackupFile"); err != nil {
+		return Backup{}, err
+	}
+
+	data, ok := m.files[m.storageName(name)]
+	if !ok {
+		return Backup{}, ErrNotFound
+	}
+
+	backup := Backup{CreatedAt: time.Now().UTC()}
+	backup.Path = m.backupPrefix(name) + backup.CreatedAt.Format(backupTimeLayout) + ".msk"
+	m.backups[backup.Path] = slices.Clone(data)
+
+	return backup, nil
+}
+
+func (m *MemStore) backupPrefix(name string) string {
+	return path.Join(memRoot, BackupDirName, m.storageName(name)) + "."
+}
+
+// fail returns the error of a done Context, or else the injected error for
+// op, if any, wrapped like a real I/O failure. Every method that can fail
+// calls it first.
+func (m *MemStore) fail(op string) error {
+	if m.Context != nil {
+		if err := m.Context.Err(); err != nil {
+			return err
+		}
+	}
+
+	if err, ok := m.Fail[op]; ok {
+		return ioError(err)
+	}
+
+	return nil
+}
+
+func (m *MemStore) storageName(name string) string {
+	if m.CaseSensitive {
+		return name
+	}
+
+	return strings.ToLower(name)
+}
+
+func (m *MemStore) sortedNames() []string {
+	names := make([]string, 0, len(m.files))
+	for name := range m.files {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	return names
+}
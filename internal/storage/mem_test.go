@@ -0,0 +1,140 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestMemStore(t *testing.T) {
+	t.Run("should save, read and delete files", func(t *testing.T) {
+		var repo Repository = NewMemStore()
+
+		if err := repo.SaveFile([]byte("data"), "Work/GitHub"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		data, err := repo.GetFile("work/github")
+		if err != nil || string(data) != "data" {
+			t.Fatalf("expected data, got %q (%v)", data, err)
+		}
+
+		if err := repo.DeleteFile("work/github"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if _, err := repo.GetFile("work/github"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+
+		if err := repo.DeleteFile("work/github"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("should list files like Store", func(t *testing.T) {
+		store := NewMemStore()
+		for _, name := range []string{"b", "a", "work/c"} {
+			if err := store.SaveFile([]byte("data"), name); err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+		}
+
+		names, err := store.GetFiles()
+		if err != nil || !slices.Equal(names, []string{"a.msk", "b.msk"}) {
+			t.Fatalf("expected [a.msk b.msk], got %v (%v)", names, err)
+		}
+
+		names, err = store.GetFilesRecursive()
+		if err != nil || !slices.Equal(names, []string{"a", "b", "work/c"}) {
+			t.Fatalf("expected [a b work/c], got %v (%v)", names, err)
+		}
+	})
+
+	t.Run("should back up and restore files", func(t *testing.T) {
+		store := NewMemStore()
+		if err := store.SaveFile([]byte("old"), "github"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		backup, err := store.BackupFile("github")
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := store.SaveFile([]byte("new"), "github"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if err := store.RestoreBackup("github", backup); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		data, _ := store.GetFile("github")
+		if string(data) != "old" {
+			t.Fatalf("expected old, got %q", data)
+		}
+
+		backups, err := store.Backups("github")
+		if err != nil || len(backups) != 2 {
+			t.Fatalf("expected 2 backups, got %v (%v)", backups, err)
+		}
+	})
+
+	t.Run("should return injected failures as ErrStorageIO", func(t *testing.T) {
+		cause := errors.New("disk full")
+		store := NewMemStore()
+		store.Fail = map[string]error{"SaveFile": cause}
+
+		err := store.SaveFile([]byte("data"), "github")
+		if !errors.Is(err, ErrStorageIO) || !errors.Is(err, cause) {
+			t.Fatalf("expected ErrStorageIO wrapping the cause, got %v", err)
+		}
+
+		if exists, err := store.FileExists("github"); err != nil || exists {
+			t.Fatalf("expected no file, got %v (%v)", exists, err)
+		}
+	})
+
+	t.Run("should fail every operation once its context is cancelled", func(t *testing.T) {
+		store := NewMemStore()
+		if err := store.SaveFile([]byte("data"), "github"); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		store.Context = ctx
+		cancel()
+
+		_, existsErr := store.FileExists("github")
+		_, getErr := store.GetFile("github")
+		_, filesErr := store.GetFiles()
+		_, recursiveErr := store.GetFilesRecursive()
+		_, backupErr := store.BackupFile("github")
+		_, backupsErr := store.Backups("github")
+		_, purgeErr := store.Purge()
+
+		for _, err := range []error{
+			existsErr,
+			getErr,
+			store.SaveFile([]byte("new"), "github"),
+			store.DeleteFile("github"),
+			filesErr,
+			recursiveErr,
+			backupErr,
+			backupsErr,
+			store.RestoreBackup("github", Backup{}),
+			purgeErr,
+		} {
+			if !errors.Is(err, context.Canceled) {
+				t.Fatalf("expected context.Canceled, got %v", err)
+			}
+		}
+
+		store.Context = context.Background()
+		if data, err := store.GetFile("github"); err != nil || string(data) != "data" {
+			t.Fatalf("expected the file to be left untouched, got %q (%v)", data, err)
+		}
+	})
+}
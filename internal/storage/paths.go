@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 )
@@ -8,6 +9,45 @@ import (
 func (s *Store) getFilePath(name string) string {
 	return filepath.Join(
 		s.Path,
-		strings.ToLower(name)+".msk",
+		filepath.FromSlash(s.storageName(name))+".msk",
 	)
 }
+
+// FilePath returns the path of the file that holds name, whether or not it
+// exists.
+func (s *Store) FilePath(name string) string {
+	return s.getFilePath(name)
+}
+
+// storageName is the on-disk form of a secret name: lowercased unless the
+// store is case-sensitive.
+func (s *Store) storageName(name string) string {
+	if s.CaseSensitive {
+		return name
+	}
+
+	return strings.ToLower(name)
+}
+
+// ensureFolders creates the intermediate folders of a folder-aware name such
+// as "work/github". The vault root itself must already exist.
+func (s *Store) ensureFolders(name string) error {
+	segments := strings.Split(s.storageName(name), "/")
+
+	dir := s.Path
+	for _, segment := range segments[:len(segments)-1] {
+		dir = filepath.Join(dir, segment)
+
+		err := os.Mkdir(dir, s.dirMode())
+		if err == nil {
+			// Mkdir is subject to the umask; apply the configured mode exactly.
+			err = os.Chmod(dir, s.dirMode())
+		}
+
+		if err != nil && !os.IsExist(err) {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -41,3 +41,17 @@ func TestGetFilePathLowerCase(t *testing.T) {
 		}
 	})
 }
+
+func TestGetFilePathCaseSensitive(t *testing.T) {
+	secretName := "Work/GitHub"
+	store := &Store{Path: t.TempDir(), CaseSensitive: true}
+	expected := filepath.Join(store.Path, "Work", "GitHub."+FILE_EXT)
+
+	t.Run("should keep the original case", func(t *testing.T) {
+		result := store.getFilePath(secretName)
+
+		if result != expected {
+			t.Errorf("getFilePath() = %v; want %v", result, expected)
+		}
+	})
+}
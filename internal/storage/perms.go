@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"runtime"
+)
+
+// ErrLoosePermissions is returned for a vault directory or config file that
+// grants more access than its configured mode allows.
+var ErrLoosePermissions = errors.New("permissions are looser than configured")
+
+// CheckPermissions returns ErrLoosePermissions when path has permission bits
+// outside allowed, such as group or other access under the default 0700. A
+// missing path passes. Unix permissions do not apply on Windows, so the check
+// is skipped there.
+func CheckPermissions(path string, allowed os.FileMode) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	if err != nil {
+		return ioError(err)
+	}
+
+	if info.Mode().Perm()&^allowed.Perm() != 0 {
+		return fmt.Errorf("%w: %s has mode %04o, expected at most %04o", ErrLoosePermissions, path, info.Mode().Perm(), allowed.Perm())
+	}
+
+	return nil
+}
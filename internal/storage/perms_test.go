@@ -0,0 +1,47 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestCheckPermissions(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Unix permissions do not apply on Windows")
+	}
+
+	dir := t.TempDir()
+
+	t.Run("should accept the configured mode", func(t *testing.T) {
+		if err := os.Chmod(dir, 0o700); err != nil {
+			t.Fatalf("chmod failed: %v", err)
+		}
+
+		if err := CheckPermissions(dir, DefaultDirMode); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+
+	t.Run("should reject group or other access", func(t *testing.T) {
+		if err := os.Chmod(dir, 0o755); err != nil {
+			t.Fatalf("chmod failed: %v", err)
+		}
+
+		if err := CheckPermissions(dir, DefaultDirMode); !errors.Is(err, ErrLoosePermissions) {
+			t.Fatalf("expected ErrLoosePermissions, got %v", err)
+		}
+
+		if err := CheckPermissions(dir, 0o755); err != nil {
+			t.Fatalf("expected a configured loose mode to pass, got %v", err)
+		}
+	})
+
+	t.Run("should accept a missing path", func(t *testing.T) {
+		if err := CheckPermissions(filepath.Join(dir, "missing"), DefaultFileMode); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
@@ -2,51 +2,175 @@ package storage
 
 import (
 	"errors"
+	"fmt"
+	"io/fs"
 	"os"
+	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/amauribechtoldjr/msk/internal/files"
 )
 
 var ErrNotFound = errors.New("secret not found")
+
+// ErrInvalidSecret is returned when something other than a regular file sits
+// where a secret's file should be, such as a directory.
 var ErrInvalidSecret = errors.New("secret invalid")
 
+// ErrStorageIO wraps filesystem failures other than a missing secret, such as
+// permission problems or a full disk, so they are not mistaken for one.
+var ErrStorageIO = errors.New("vault I/O error")
+
+// ErrInvalidVaultPath is returned for a vault directory that is empty or not
+// absolute, e.g. from a damaged config or an empty --vault.
+var ErrInvalidVaultPath = errors.New("invalid vault path")
+
+// ErrSecretTooLarge is returned for a secret file above the store's size
+// limit, so a huge file dropped into the vault is never read into memory.
+var ErrSecretTooLarge = errors.New("secret file too large")
+
 type Repository interface {
 	FileExists(name string) (bool, error)
 	GetFile(name string) ([]byte, error)
 	SaveFile(encryptedFile []byte, name string) error
 	DeleteFile(name string) error
 	GetFiles() ([]string, error)
+	GetFilesRecursive() ([]string, error)
+	Dir() string
+	FilePath(name string) string
+	Purge() ([]string, error)
+	BackupFile(name string) (Backup, error)
+	Backups(name string) ([]Backup, error)
+	RestoreBackup(name string, backup Backup) error
 }
 
+const (
+	DefaultFileMode = os.FileMode(0o600)
+	DefaultDirMode  = os.FileMode(0o700)
+
+	// DefaultMaxFileSize is far above any real secret file.
+	DefaultMaxFileSize = int64(16 << 20)
+)
+
+// Store keeps one encrypted file per secret under Path. A zero FileMode or
+// DirMode falls back to DefaultFileMode and DefaultDirMode. Names are
+// lowercased on disk unless CaseSensitive is set; on case-insensitive
+// filesystems names differing only in case still share a file.
+//
+// BackupKeep and BackupMaxAge cap how many backups of each secret are
+// retained and for how long; zero means no limit. GetFile refuses files
+// larger than MaxFileSize bytes, DefaultMaxFileSize when zero.
 type Store struct {
-	Path string
+	Path          string
+	FileMode      os.FileMode
+	DirMode       os.FileMode
+	CaseSensitive bool
+	BackupKeep    int
+	BackupMaxAge  time.Duration
+	MaxFileSize   int64
 }
 
 func NewStore(path string) (*Store, error) {
-	if err := os.MkdirAll(path, 0o700); err != nil {
+	return NewStoreWithModes(path, DefaultFileMode, DefaultDirMode)
+}
+
+func NewStoreWithModes(path string, fileMode, dirMode os.FileMode) (*Store, error) {
+	if err := CheckVaultPath(path); err != nil {
 		return nil, err
 	}
 
-	return &Store{path}, nil
+	if err := os.MkdirAll(path, dirMode); err != nil {
+		return nil, err
+	}
+
+	return &Store{Path: path, FileMode: fileMode, DirMode: dirMode}, nil
+}
+
+// CheckVaultPath rejects an empty, blank or relative vault path, which
+// would otherwise silently resolve against the working directory.
+func CheckVaultPath(path string) error {
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("%w: the path is empty", ErrInvalidVaultPath)
+	}
+
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%w: %s is not absolute", ErrInvalidVaultPath, path)
+	}
+
+	return nil
+}
+
+func (s *Store) Dir() string {
+	return s.Path
+}
+
+func (s *Store) fileMode() os.FileMode {
+	if s.FileMode == 0 {
+		return DefaultFileMode
+	}
+
+	return s.FileMode
+}
+
+func (s *Store) dirMode() os.FileMode {
+	if s.DirMode == 0 {
+		return DefaultDirMode
+	}
+
+	return s.DirMode
+}
+
+func (s *Store) maxFileSize() int64 {
+	if s.MaxFileSize == 0 {
+		return DefaultMaxFileSize
+	}
+
+	return s.MaxFileSize
 }
 
 func (s *Store) SaveFile(encryptedFile []byte, name string) error {
-	return files.WriteAtomicFile(s.getFilePath(name), encryptedFile, 0o600)
+	filePath := s.getFilePath(name)
+
+	if _, err := secretFileExists(filePath); err != nil {
+		return err
+	}
+
+	if err := s.ensureFolders(name); err != nil {
+		return ioError(err)
+	}
+
+	return ioError(files.WriteAtomicFile(filePath, encryptedFile, s.fileMode()))
 }
 
 func (s *Store) GetFile(name string) ([]byte, error) {
-	return files.ReadFile(s.getFilePath(name), ErrNotFound)
+	filePath := s.getFilePath(name)
+
+	info, err := secretFileInfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	if info != nil && info.Size() > s.maxFileSize() {
+		return nil, fmt.Errorf("%w: %s is %d bytes, the limit is %d", ErrSecretTooLarge, filePath, info.Size(), s.maxFileSize())
+	}
+
+	data, err := files.ReadFile(filePath, ErrNotFound)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, ioError(err)
+	}
+
+	return data, err
 }
 
 func (s *Store) FileExists(name string) (bool, error) {
-	return files.FileExists(s.getFilePath(name))
+	return secretFileExists(s.getFilePath(name))
 }
 
 func (s *Store) DeleteFile(name string) error {
 	filePath := s.getFilePath(name)
 
-	exists, err := files.FileExists(filePath)
+	exists, err := secretFileExists(filePath)
 	if err != nil {
 		return err
 	}
@@ -55,33 +179,154 @@ func (s *Store) DeleteFile(name string) error {
 		return ErrNotFound
 	}
 
-	return os.Remove(filePath)
+	return ioError(os.Remove(filePath))
+}
+
+// secretFileExists reports whether the file of a secret exists. A directory
+// in its place, e.g. a folder named "foo.msk", yields ErrInvalidSecret rather
+// than a confusing I/O error later on.
+func secretFileExists(path string) (bool, error) {
+	info, err := secretFileInfo(path)
+	return info != nil, err
+}
+
+// secretFileInfo stats the file of a secret like secretFileExists, returning
+// nil info when it does not exist.
+func secretFileInfo(path string) (fs.FileInfo, error) {
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, ioError(err)
+	}
+
+	if info.IsDir() {
+		return nil, fmt.Errorf("%w: %s is a directory", ErrInvalidSecret, path)
+	}
+
+	return info, nil
 }
 
 func (s *Store) GetFiles() ([]string, error) {
 	files, err := os.ReadDir(s.Path)
 	if err != nil {
-		return nil, err
+		return nil, ioError(err)
 	}
 
 	var names []string
 
 	for _, file := range files {
-		info, err := file.Info()
-		if err != nil {
-			return nil, err
-		}
-
-		if info.IsDir() {
+		if file.IsDir() {
 			continue
 		}
 
-		if !strings.Contains(file.Name(), ".msk") {
+		if strings.HasPrefix(file.Name(), ".") || !isSecretFile(file.Name()) {
 			continue
 		}
 
 		names = append(names, file.Name())
 	}
 
-	return names, err
+	return names, nil
+}
+
+// GetFilesRecursive walks the vault including folders and returns every
+// secret as a vault-relative, slash-separated name with the ".msk" extension
+// trimmed (e.g. "work/github"). Temp files and hidden entries, which hold
+// bookkeeping data rather than secrets, are skipped.
+func (s *Store) GetFilesRecursive() ([]string, error) {
+	var names []string
+
+	err := filepath.WalkDir(s.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path == s.Path {
+			return nil
+		}
+
+		if strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if d.IsDir() || !isSecretFile(d.Name()) {
+			return nil
+		}
+
+		rel, err := filepath.Rel(s.Path, path)
+		if err != nil {
+			return err
+		}
+
+		names = append(names, strings.TrimSuffix(filepath.ToSlash(rel), ".msk"))
+		return nil
+	})
+
+	if err != nil {
+		return nil, ioError(err)
+	}
+
+	return names, nil
+}
+
+// Purge shreds every secret and leftover temp file in the vault, then removes
+// the folders that became empty, including the vault root. Files that do not
+// belong to msk are left alone. It returns the paths that could not be
+// removed.
+func (s *Store) Purge() ([]string, error) {
+	var failed []string
+	var dirs []string
+
+	err := filepath.WalkDir(s.Path, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			failed = append(failed, path)
+			return nil
+		}
+
+		if d.IsDir() {
+			dirs = append(dirs, path)
+			return nil
+		}
+
+		if !isSecretFile(d.Name()) && !files.IsTempFile(d.Name(), ".msk") {
+			return nil
+		}
+
+		if err := files.ShredFile(path); err != nil {
+			failed = append(failed, path)
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// WalkDir visits parents first, so remove folders in reverse order.
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i])
+	}
+
+	return failed, nil
+}
+
+// ioError wraps a filesystem error in ErrStorageIO, keeping the original
+// error in the chain.
+func ioError(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	return fmt.Errorf("%w: %w", ErrStorageIO, err)
+}
+
+func isSecretFile(name string) bool {
+	return strings.HasSuffix(name, ".msk")
 }
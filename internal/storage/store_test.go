@@ -3,12 +3,16 @@ package storage
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"strings"
 	"testing"
 
 	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/validator"
 )
 
 func initializeStore(t *testing.T) Store {
@@ -54,6 +58,15 @@ func TestNewStore(t *testing.T) {
 			t.Fatal("expected error when creating store with path collision, got nil")
 		}
 	})
+
+	t.Run("should reject an empty, blank or relative path", func(t *testing.T) {
+		for _, path := range []string{"", "  ", "vault"} {
+			_, err := NewStore(path)
+			if !errors.Is(err, ErrInvalidVaultPath) {
+				t.Fatalf("%q: expected ErrInvalidVaultPath, got %v", path, err)
+			}
+		}
+	})
 }
 
 func TestFileExists(t *testing.T) {
@@ -92,6 +105,36 @@ func TestFileExists(t *testing.T) {
 	})
 }
 
+func TestDirectorySecret(t *testing.T) {
+	t.Run("should return ErrInvalidSecret for a directory named like a secret", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := os.Mkdir(filepath.Join(store.Path, "foo.msk"), 0o700); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+
+		if _, err := store.FileExists("foo"); !errors.Is(err, ErrInvalidSecret) {
+			t.Fatalf("FileExists: expected ErrInvalidSecret, got %v", err)
+		}
+
+		if _, err := store.GetFile("foo"); !errors.Is(err, ErrInvalidSecret) {
+			t.Fatalf("GetFile: expected ErrInvalidSecret, got %v", err)
+		}
+
+		if err := store.SaveFile([]byte("data"), "foo"); !errors.Is(err, ErrInvalidSecret) {
+			t.Fatalf("SaveFile: expected ErrInvalidSecret, got %v", err)
+		}
+
+		if err := store.DeleteFile("foo"); !errors.Is(err, ErrInvalidSecret) {
+			t.Fatalf("DeleteFile: expected ErrInvalidSecret, got %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(store.Path, "foo.msk")); err != nil {
+			t.Fatalf("expected the directory to be left alone, got %v", err)
+		}
+	})
+}
+
 func TestGetFile(t *testing.T) {
 	t.Run("should return file contents for an existing secret", func(t *testing.T) {
 		store := initializeStore(t)
@@ -143,6 +186,46 @@ func TestGetFile(t *testing.T) {
 			t.Fatalf("expected %q, got %q", expected, data)
 		}
 	})
+
+	t.Run("should return ErrSecretTooLarge for a file above the limit", func(t *testing.T) {
+		store := initializeStore(t)
+		store.MaxFileSize = 16
+
+		if err := os.WriteFile(filepath.Join(store.Path, "big.msk"), make([]byte, 17), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+
+		if err := os.WriteFile(filepath.Join(store.Path, "small.msk"), make([]byte, 16), 0o600); err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+
+		if _, err := store.GetFile("big"); !errors.Is(err, ErrSecretTooLarge) {
+			t.Fatalf("expected ErrSecretTooLarge, got %v", err)
+		}
+
+		if _, err := store.GetFile("small"); err != nil {
+			t.Fatalf("expected a file at the limit to be read, got %v", err)
+		}
+	})
+
+	t.Run("should apply DefaultMaxFileSize when no limit is set", func(t *testing.T) {
+		store := initializeStore(t)
+
+		file, err := os.Create(filepath.Join(store.Path, "video.msk"))
+		if err != nil {
+			t.Fatalf("failed to create test file: %v", err)
+		}
+
+		// A sparse file, so the test does not write 16MB to disk.
+		if err := file.Truncate(DefaultMaxFileSize + 1); err != nil {
+			t.Fatalf("failed to grow test file: %v", err)
+		}
+		file.Close()
+
+		if _, err := store.GetFile("video"); !errors.Is(err, ErrSecretTooLarge) {
+			t.Fatalf("expected ErrSecretTooLarge, got %v", err)
+		}
+	})
 }
 
 func TestDeleteFile(t *testing.T) {
@@ -272,6 +355,34 @@ func TestGetFiles(t *testing.T) {
 		}
 	})
 
+	t.Run("should skip temp, backup-like and hidden files", func(t *testing.T) {
+		store := initializeStore(t)
+
+		fileNames := []string{
+			"file-1.msk",
+			"file-1.msk.tmp",
+			"notes.mskbak",
+			".lock",
+			".manifest.msk",
+		}
+
+		for _, fileName := range fileNames {
+			err := os.WriteFile(filepath.Join(store.Path, fileName), []byte{}, 0o600)
+			if err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+		}
+
+		files, err := store.GetFiles()
+		if err != nil {
+			t.Fatal("failed to retrieve existing files")
+		}
+
+		if !slices.Equal(files, []string{"file-1.msk"}) {
+			t.Fatalf("expected only file-1.msk, got %v", files)
+		}
+	})
+
 	t.Run("should return empty string array when no files", func(t *testing.T) {
 		store := initializeStore(t)
 
@@ -291,6 +402,99 @@ func TestGetFiles(t *testing.T) {
 	})
 }
 
+func TestGetFilesRecursive(t *testing.T) {
+	t.Run("should return vault-relative paths for nested files", func(t *testing.T) {
+		store := initializeStore(t)
+
+		for _, name := range []string{"root", "work/github", "work/infra/aws"} {
+			if err := store.SaveFile([]byte{}, name); err != nil {
+				t.Fatalf("failed to save %s: %v", name, err)
+			}
+		}
+
+		err := os.WriteFile(filepath.Join(store.Path, "work", "notes.txt"), []byte{}, 0o600)
+		if err != nil {
+			t.Fatalf("failed to write test file: %v", err)
+		}
+
+		files, err := store.GetFilesRecursive()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		expected := []string{"root", "work/github", "work/infra/aws"}
+		if !slices.Equal(files, expected) {
+			t.Fatalf("expected %v, got %v", expected, files)
+		}
+	})
+
+	t.Run("should skip temp files and hidden entries", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := store.SaveFile([]byte{}, "work/github"); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+
+		leftovers := []string{
+			filepath.Join("work", "github.msk.tmp"),
+			".lock",
+			filepath.Join(".hidden", "old.msk"),
+		}
+
+		if err := os.Mkdir(filepath.Join(store.Path, ".hidden"), 0o700); err != nil {
+			t.Fatalf("failed to create hidden dir: %v", err)
+		}
+
+		for _, leftover := range leftovers {
+			err := os.WriteFile(filepath.Join(store.Path, leftover), []byte{}, 0o600)
+			if err != nil {
+				t.Fatalf("failed to write test file: %v", err)
+			}
+		}
+
+		files, err := store.GetFilesRecursive()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !slices.Equal(files, []string{"work/github"}) {
+			t.Fatalf("expected only work/github, got %v", files)
+		}
+	})
+
+	t.Run("should return empty slice when vault is empty", func(t *testing.T) {
+		store := initializeStore(t)
+
+		files, err := store.GetFilesRecursive()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if len(files) != 0 {
+			t.Fatalf("expected no files, got %v", files)
+		}
+	})
+}
+
+func TestSaveFileLongName(t *testing.T) {
+	t.Run("should save a secret with the longest valid name", func(t *testing.T) {
+		store := initializeStore(t)
+
+		name := strings.Repeat("a", validator.NAME_MAX_LENGTH)
+		if err := validator.ValidateName(name); err != nil {
+			t.Fatalf("expected a valid name, got %v", err)
+		}
+
+		if err := store.SaveFile([]byte("data"), name); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if _, err := store.GetFile(name); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+	})
+}
+
 func TestSaveFile(t *testing.T) {
 	makeSalt := func() [16]byte {
 		var s [16]byte
@@ -385,6 +589,39 @@ func TestSaveFile(t *testing.T) {
 		}
 	})
 
+	t.Run("should not touch a stale temp file from an interrupted write", func(t *testing.T) {
+		store := initializeStore(t)
+
+		stalePath := filepath.Join(store.Path, "stale.msk.tmp")
+		if err := os.WriteFile(stalePath, []byte("stale"), 0o600); err != nil {
+			t.Fatalf("failed to create stale temp file: %v", err)
+		}
+
+		encryptedFile := marshalOrFail(t, makeSalt(), makeNonce(), []byte("fresh"))
+
+		if err := store.SaveFile(encryptedFile, "stale"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		data, err := store.GetFile("stale")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+
+		if !bytes.Equal(data, encryptedFile) {
+			t.Fatalf("expected the fresh write, got %q", data)
+		}
+
+		stale, err := os.ReadFile(stalePath)
+		if err != nil {
+			t.Fatalf("expected the stale temp file to be left alone, got %v", err)
+		}
+
+		if string(stale) != "stale" {
+			t.Fatalf("expected the stale temp file to keep its content, got %q", stale)
+		}
+	})
+
 	t.Run("should clean up temp file after success", func(t *testing.T) {
 		store := initializeStore(t)
 
@@ -426,6 +663,65 @@ func TestSaveFile(t *testing.T) {
 		}
 	})
 
+	t.Run("should create intermediate folders for nested names", func(t *testing.T) {
+		store := initializeStore(t)
+
+		encryptedFile := marshalOrFail(t, makeSalt(), makeNonce(), []byte("data"))
+
+		if err := store.SaveFile(encryptedFile, "Work/GitHub"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		expectedPath := filepath.Join(store.Path, "work", "github.msk")
+		if _, err := os.Stat(expectedPath); err != nil {
+			t.Fatalf("expected file at %s: %v", expectedPath, err)
+		}
+
+		got, err := store.GetFile("work/github")
+		if err != nil {
+			t.Fatalf("get failed: %v", err)
+		}
+
+		if !bytes.Equal(got, encryptedFile) {
+			t.Fatalf("roundtrip mismatch\nexpected: %x\ngot:      %x", encryptedFile, got)
+		}
+	})
+
+	t.Run("should apply configured file and directory modes", func(t *testing.T) {
+		if runtime.GOOS == "windows" {
+			t.Skip("unix permission bits are not supported on windows")
+		}
+
+		store, err := NewStoreWithModes(t.TempDir(), 0o640, 0o750)
+		if err != nil {
+			t.Fatalf("failed to create store: %v", err)
+		}
+
+		encryptedFile := marshalOrFail(t, makeSalt(), makeNonce(), []byte("data"))
+
+		if err := store.SaveFile(encryptedFile, "work/github"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		fileInfo, err := os.Stat(filepath.Join(store.Path, "work", "github.msk"))
+		if err != nil {
+			t.Fatalf("stat failed: %v", err)
+		}
+
+		if fileInfo.Mode().Perm() != 0o640 {
+			t.Fatalf("expected file mode 0640, got %04o", fileInfo.Mode().Perm())
+		}
+
+		dirInfo, err := os.Stat(filepath.Join(store.Path, "work"))
+		if err != nil {
+			t.Fatalf("stat failed: %v", err)
+		}
+
+		if dirInfo.Mode().Perm() != 0o750 {
+			t.Fatalf("expected directory mode 0750, got %04o", dirInfo.Mode().Perm())
+		}
+	})
+
 	t.Run("should return error for unwritable directory", func(t *testing.T) {
 		store := &Store{Path: filepath.Join(t.TempDir(), "no", "such", "deep", "path")}
 
@@ -458,3 +754,154 @@ func TestSaveFile(t *testing.T) {
 		}
 	})
 }
+
+func TestPurge(t *testing.T) {
+	t.Run("should remove secrets, temp files and empty folders", func(t *testing.T) {
+		store := initializeStore(t)
+
+		for _, name := range []string{"github", "work/gitlab", "work/cloud/aws"} {
+			if err := store.SaveFile([]byte("data"), name); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+		}
+
+		for _, tmpName := range []string{"leftover.msk.tmp", "leftover.msk.123456.tmp"} {
+			if err := os.WriteFile(filepath.Join(store.Path, tmpName), []byte("data"), 0o600); err != nil {
+				t.Fatalf("failed to create temp file: %v", err)
+			}
+		}
+
+		failed, err := store.Purge()
+		if err != nil {
+			t.Fatalf("purge failed: %v", err)
+		}
+
+		if len(failed) != 0 {
+			t.Fatalf("expected no failures, got %v", failed)
+		}
+
+		if _, err := os.Stat(store.Path); !os.IsNotExist(err) {
+			t.Fatalf("expected vault directory to be removed, got %v", err)
+		}
+	})
+
+	t.Run("should keep files that do not belong to the vault", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := store.SaveFile([]byte("data"), "github"); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+
+		otherPath := filepath.Join(store.Path, "notes.txt")
+		if err := os.WriteFile(otherPath, []byte("keep me"), 0o600); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+
+		if _, err := store.Purge(); err != nil {
+			t.Fatalf("purge failed: %v", err)
+		}
+
+		if _, err := os.Stat(otherPath); err != nil {
+			t.Fatalf("expected unrelated file to be kept: %v", err)
+		}
+
+		if _, err := os.Stat(filepath.Join(store.Path, "github.msk")); !os.IsNotExist(err) {
+			t.Fatalf("expected secret to be removed, got %v", err)
+		}
+	})
+}
+
+func populateStore(b *testing.B, store *Store, count int) {
+	b.Helper()
+
+	for i := range count {
+		err := os.WriteFile(filepath.Join(store.Path, fmt.Sprintf("secret-%05d.msk", i)), []byte{}, 0o600)
+		if err != nil {
+			b.Fatalf("failed to write test file: %v", err)
+		}
+	}
+}
+
+func BenchmarkGetFiles(b *testing.B) {
+	for _, count := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("%d", count), func(b *testing.B) {
+			store, err := NewStore(b.TempDir())
+			if err != nil {
+				b.Fatalf("failed to create store: %v", err)
+			}
+
+			populateStore(b, store, count)
+
+			for b.Loop() {
+				if _, err := store.GetFiles(); err != nil {
+					b.Fatalf("GetFiles failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkGetFilesRecursive(b *testing.B) {
+	for _, count := range []int{1_000, 10_000} {
+		b.Run(fmt.Sprintf("%d", count), func(b *testing.B) {
+			store, err := NewStore(b.TempDir())
+			if err != nil {
+				b.Fatalf("failed to create store: %v", err)
+			}
+
+			populateStore(b, store, count)
+
+			for b.Loop() {
+				if _, err := store.GetFilesRecursive(); err != nil {
+					b.Fatalf("GetFilesRecursive failed: %v", err)
+				}
+			}
+		})
+	}
+}
+
+func TestStorageIOErrors(t *testing.T) {
+	t.Run("should keep ErrNotFound for a missing secret", func(t *testing.T) {
+		store := initializeStore(t)
+
+		_, err := store.GetFile("missing")
+		if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrStorageIO) {
+			t.Fatalf("expected only ErrNotFound, got %v", err)
+		}
+	})
+
+	t.Run("should wrap read failures in ErrStorageIO", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := os.WriteFile(filepath.Join(store.Path, "work"), nil, 0o600); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+
+		_, err := store.GetFile("work/github")
+		if !errors.Is(err, ErrStorageIO) {
+			t.Fatalf("expected ErrStorageIO, got %v", err)
+		}
+	})
+
+	t.Run("should wrap write failures in ErrStorageIO", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := os.WriteFile(filepath.Join(store.Path, "work"), nil, 0o600); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+
+		err := store.SaveFile([]byte("data"), "work/github")
+		if !errors.Is(err, ErrStorageIO) {
+			t.Fatalf("expected ErrStorageIO, got %v", err)
+		}
+	})
+
+	t.Run("should wrap listing failures in ErrStorageIO", func(t *testing.T) {
+		store := Store{Path: filepath.Join(t.TempDir(), "missing")}
+
+		_, err := store.GetFiles()
+		if !errors.Is(err, ErrStorageIO) {
+			t.Fatalf("expected ErrStorageIO, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,173 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/amauribechtoldjr/msk/internal/files"
+)
+
+var ErrTxDone = errors.New("transaction already committed or rolled back")
+
+type txOpKind int
+
+const (
+	txSave txOpKind = iota
+	txDelete
+	txRename
+)
+
+type txOp struct {
+	kind   txOpKind
+	name   string
+	source string
+}
+
+// Tx stages changes to several secret files and applies them together.
+//
+// Writes are staged into synced temp files next to their destination, so
+// Commit only has to rename files into place. Each individual change is
+// atomic, but the transaction as a whole is not: most filesystems cannot
+// rename several files at once, so a crash during Commit may leave some
+// changes applied and others not. Commit applies changes in the order they
+// were staged and stops at the first failure.
+type Tx struct {
+	store *Store
+	ops   []txOp
+	done  bool
+}
+
+func (s *Store) Begin() *Tx {
+	return &Tx{store: s}
+}
+
+func (tx *Tx) SaveFile(encryptedFile []byte, name string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	if err := tx.store.ensureFolders(name); err != nil {
+		return err
+	}
+
+	tx.dropPendingSave(name)
+
+	tmpPath, err := files.WriteTempFile(tx.store.getFilePath(name), encryptedFile, tx.store.fileMode())
+	if err != nil {
+		return err
+	}
+
+	tx.ops = append(tx.ops, txOp{kind: txSave, name: name, source: tmpPath})
+	return nil
+}
+
+func (tx *Tx) DeleteFile(name string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	exists, err := tx.store.FileExists(name)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	tx.ops = append(tx.ops, txOp{kind: txDelete, name: name})
+	return nil
+}
+
+func (tx *Tx) Rename(oldName, newName string) error {
+	if tx.done {
+		return ErrTxDone
+	}
+
+	exists, err := tx.store.FileExists(oldName)
+	if err != nil {
+		return err
+	}
+
+	if !exists {
+		return ErrNotFound
+	}
+
+	tx.ops = append(tx.ops, txOp{kind: txRename, name: newName, source: tx.store.getFilePath(oldName)})
+	return nil
+}
+
+func (tx *Tx) Commit() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	for i, op := range tx.ops {
+		if err := tx.apply(op); err != nil {
+			tx.cleanup(tx.ops[i:])
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tx *Tx) Rollback() error {
+	if tx.done {
+		return ErrTxDone
+	}
+	tx.done = true
+
+	tx.cleanup(tx.ops)
+	return nil
+}
+
+func (tx *Tx) apply(op txOp) error {
+	path := tx.store.getFilePath(op.name)
+
+	switch op.kind {
+	case txSave:
+		return files.CommitTempFile(op.source, path)
+	case txDelete:
+		return os.Remove(path)
+	case txRename:
+		if err := tx.store.ensureFolders(op.name); err != nil {
+			return err
+		}
+
+		if err := os.Rename(op.source, path); err != nil {
+			return err
+		}
+
+		files.SyncDir(filepath.Dir(path))
+	}
+
+	return nil
+}
+
+// dropPendingSave discards an earlier staged write to the same secret, so the
+// last SaveFile for a name wins.
+func (tx *Tx) dropPendingSave(name string) {
+	path := tx.store.getFilePath(name)
+
+	tx.ops = slices.DeleteFunc(tx.ops, func(op txOp) bool {
+		if op.kind != txSave || tx.store.getFilePath(op.name) != path {
+			return false
+		}
+
+		os.Remove(op.source)
+		return true
+	})
+}
+
+// cleanup removes the temp files of staged writes that were never committed.
+func (tx *Tx) cleanup(ops []txOp) {
+	for _, op := range ops {
+		if op.kind == txSave {
+			os.Remove(op.source)
+		}
+	}
+}
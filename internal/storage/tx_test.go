@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestTxCommit(t *testing.T) {
+	t.Run("should apply staged saves, deletes and renames", func(t *testing.T) {
+		store := initializeStore(t)
+
+		for _, name := range []string{"to-delete", "to-rename"} {
+			if err := store.SaveFile([]byte(name), name); err != nil {
+				t.Fatalf("failed to save %s: %v", name, err)
+			}
+		}
+
+		tx := store.Begin()
+
+		if err := tx.SaveFile([]byte("new"), "work/new"); err != nil {
+			t.Fatalf("stage save failed: %v", err)
+		}
+
+		if err := tx.DeleteFile("to-delete"); err != nil {
+			t.Fatalf("stage delete failed: %v", err)
+		}
+
+		if err := tx.Rename("to-rename", "work/renamed"); err != nil {
+			t.Fatalf("stage rename failed: %v", err)
+		}
+
+		if exists, _ := store.FileExists("work/new"); exists {
+			t.Fatal("staged save should not be visible before commit")
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("commit failed: %v", err)
+		}
+
+		files, err := store.GetFilesRecursive()
+		if err != nil {
+			t.Fatalf("failed to list files: %v", err)
+		}
+
+		if !slices.Equal(files, []string{"work/new", "work/renamed"}) {
+			t.Fatalf("unexpected vault content after commit: %v", files)
+		}
+
+		renamed, err := store.GetFile("work/renamed")
+		if err != nil {
+			t.Fatalf("failed to read renamed file: %v", err)
+		}
+
+		if string(renamed) != "to-rename" {
+			t.Fatalf("expected renamed content %q, got %q", "to-rename", renamed)
+		}
+	})
+
+	t.Run("should keep the last staged save for the same name", func(t *testing.T) {
+		store := initializeStore(t)
+		tx := store.Begin()
+
+		if err := tx.SaveFile([]byte("first"), "secret"); err != nil {
+			t.Fatalf("stage save failed: %v", err)
+		}
+
+		if err := tx.SaveFile([]byte("second"), "secret"); err != nil {
+			t.Fatalf("stage save failed: %v", err)
+		}
+
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("commit failed: %v", err)
+		}
+
+		got, err := store.GetFile("secret")
+		if err != nil {
+			t.Fatalf("failed to read file: %v", err)
+		}
+
+		if string(got) != "second" {
+			t.Fatalf("expected %q, got %q", "second", got)
+		}
+	})
+
+	t.Run("should return ErrNotFound when staging a missing file", func(t *testing.T) {
+		store := initializeStore(t)
+		tx := store.Begin()
+
+		if err := tx.DeleteFile("missing"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound on delete, got %v", err)
+		}
+
+		if err := tx.Rename("missing", "other"); !errors.Is(err, ErrNotFound) {
+			t.Fatalf("expected ErrNotFound on rename, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrTxDone after commit", func(t *testing.T) {
+		store := initializeStore(t)
+		tx := store.Begin()
+
+		if err := tx.Commit(); err != nil {
+			t.Fatalf("commit failed: %v", err)
+		}
+
+		if err := tx.SaveFile([]byte("data"), "late"); !errors.Is(err, ErrTxDone) {
+			t.Fatalf("expected ErrTxDone, got %v", err)
+		}
+
+		if err := tx.Commit(); !errors.Is(err, ErrTxDone) {
+			t.Fatalf("expected ErrTxDone, got %v", err)
+		}
+	})
+}
+
+func TestTxRollback(t *testing.T) {
+	t.Run("should discard staged changes and temp files", func(t *testing.T) {
+		store := initializeStore(t)
+
+		if err := store.SaveFile([]byte("keep"), "existing"); err != nil {
+			t.Fatalf("failed to save: %v", err)
+		}
+
+		tx := store.Begin()
+
+		if err := tx.SaveFile([]byte("new"), "staged"); err != nil {
+			t.Fatalf("stage save failed: %v", err)
+		}
+
+		if err := tx.DeleteFile("existing"); err != nil {
+			t.Fatalf("stage delete failed: %v", err)
+		}
+
+		if err := tx.Rollback(); err != nil {
+			t.Fatalf("rollback failed: %v", err)
+		}
+
+		files, err := store.GetFiles()
+		if err != nil {
+			t.Fatalf("failed to list files: %v", err)
+		}
+
+		if !slices.Equal(files, []string{"existing.msk"}) {
+			t.Fatalf("expected only existing.msk, got %v", files)
+		}
+
+		matches, err := filepath.Glob(filepath.Join(store.Path, "*.tmp"))
+		if err != nil {
+			t.Fatalf("glob failed: %v", err)
+		}
+
+		if len(matches) != 0 {
+			t.Fatalf("expected no .tmp files, found: %v", matches)
+		}
+
+		if _, err := os.Stat(filepath.Join(store.Path, "existing.msk")); err != nil {
+			t.Fatalf("expected existing.msk to be kept: %v", err)
+		}
+	})
+}
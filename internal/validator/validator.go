@@ -2,19 +2,29 @@ package validator
 
 import (
 	"errors"
+	"fmt"
 	"regexp"
 	"strings"
 	"unicode"
+
+	"github.com/amauribechtoldjr/msk/internal/files"
 )
 
+// NAME_MAX_LENGTH leaves room for the ".msk" extension every secret file gets
+// within the file name limit. Names are ASCII, so lowercasing them keeps their
+// length.
+const NAME_MAX_LENGTH = files.MAX_FILENAME_LENGTH - len(".msk")
+
 var (
 	ErrEmptyName         = errors.New("name cannot be empty")
-	ErrNameTooLong       = errors.New("name cannot exceed 255 characters")
+	ErrNameTooLong       = fmt.Errorf("name cannot exceed %d characters", NAME_MAX_LENGTH)
 	ErrInvalidCharacters = errors.New("name can only contain letters, numbers, hyphens and underscores")
 	ErrPathSeparator     = errors.New("name cannot contain path separators")
 	ErrReservedName      = errors.New("name cannot be a reserved system name")
 	ErrControlCharacter  = errors.New("name cannot contain control characters")
 	ErrWhitespace        = errors.New("name cannot contain whitespace")
+	ErrEmptySegment      = errors.New("name cannot contain empty folder segments")
+	ErrPathTraversal     = errors.New("name cannot contain '.' or '..' folder segments")
 )
 
 var windowsReservedNames = map[string]bool{
@@ -44,7 +54,7 @@ func ValidateName(name string) error {
 		return ErrEmptyName
 	}
 
-	if len(name) > 255 {
+	if len(name) > NAME_MAX_LENGTH {
 		return ErrNameTooLong
 	}
 
@@ -99,3 +109,32 @@ func Validate(name string) error {
 
 	return nil
 }
+
+// ValidatePath validates a folder-aware secret name such as "work/github".
+// Folders are separated by "/" and every segment must be a valid name on its
+// own, which rules out absolute paths and ".." traversal.
+func ValidatePath(name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+
+	if strings.Contains(name, "\\") {
+		return ErrPathSeparator
+	}
+
+	for _, segment := range strings.Split(name, "/") {
+		if segment == "" {
+			return ErrEmptySegment
+		}
+
+		if segment == "." || segment == ".." {
+			return ErrPathTraversal
+		}
+
+		if err := Validate(segment); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -21,6 +21,17 @@ func TestValidateName(t *testing.T) {
 		}
 	})
 
+	t.Run("should leave room for the .msk extension", func(t *testing.T) {
+		if err := ValidateName(strings.Repeat("T", 251)); err != nil {
+			t.Fatalf("expected a 251 character name to be valid, got %v", err)
+		}
+
+		// 252 characters plus ".msk" would be a 256 byte file name.
+		if err := ValidateName(strings.Repeat("T", 252)); !errors.Is(err, ErrNameTooLong) {
+			t.Fatalf("expected ErrNameTooLong, got %v", err)
+		}
+	})
+
 	t.Run("should return error for name with control characters", func(t *testing.T) {
 		inputs := []string{
 			"test\x00name",
@@ -124,3 +135,69 @@ func TestValidateWindowsReservedName(t *testing.T) {
 		}
 	})
 }
+
+func TestValidatePath(t *testing.T) {
+	t.Run("should accept folder-aware names", func(t *testing.T) {
+		inputs := []string{
+			"github",
+			"work/github",
+			"personal/bank/main-account",
+		}
+
+		for _, input := range inputs {
+			if err := ValidatePath(input); err != nil {
+				t.Fatalf("ValidatePath(%q): expected no error, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should return error for empty folder segments", func(t *testing.T) {
+		inputs := []string{
+			"/leading",
+			"trailing/",
+			"double//slash",
+		}
+
+		for _, input := range inputs {
+			err := ValidatePath(input)
+			if !errors.Is(err, ErrEmptySegment) {
+				t.Fatalf("ValidatePath(%q): expected ErrEmptySegment, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should return error for traversal segments", func(t *testing.T) {
+		inputs := []string{
+			"..",
+			"../secret",
+			"work/../../secret",
+			"./secret",
+		}
+
+		for _, input := range inputs {
+			err := ValidatePath(input)
+			if !errors.Is(err, ErrPathTraversal) {
+				t.Fatalf("ValidatePath(%q): expected ErrPathTraversal, got %v", input, err)
+			}
+		}
+	})
+
+	t.Run("should return error for backslash separators", func(t *testing.T) {
+		err := ValidatePath("work\\github")
+		if !errors.Is(err, ErrPathSeparator) {
+			t.Fatalf("expected ErrPathSeparator, got %v", err)
+		}
+	})
+
+	t.Run("should validate every segment", func(t *testing.T) {
+		err := ValidatePath("work/con")
+		if !errors.Is(err, ErrReservedName) {
+			t.Fatalf("expected ErrReservedName, got %v", err)
+		}
+
+		err = ValidatePath("work/my secret")
+		if !errors.Is(err, ErrWhitespace) {
+			t.Fatalf("expected ErrWhitespace, got %v", err)
+		}
+	})
+}
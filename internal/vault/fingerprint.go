@@ -0,0 +1,51 @@
+package vault
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+)
+
+// fingerprintInfo binds the fingerprint MAC to its purpose.
+const fingerprintInfo = "msk fingerprint"
+
+var fingerprintAdjectives = [64]string{
+	"amber", "bold", "brave", "brisk", "calm", "clever", "cosmic", "crisp",
+	"curious", "daring", "dusty", "eager", "early", "fancy", "fierce", "gentle",
+	"giant", "glad", "golden", "happy", "hidden", "humble", "icy", "jolly",
+	"keen", "kind", "lively", "lucky", "lunar", "mellow", "merry", "misty",
+	"noble", "odd", "olive", "plucky", "polar", "proud", "quick", "quiet",
+	"rapid", "rosy", "royal", "rustic", "shy", "silent", "silver", "sleek",
+	"snowy", "solar", "steady", "stormy", "sunny", "swift", "tidy", "tiny",
+	"urban", "vivid", "warm", "wild", "windy", "wise", "witty", "young",
+}
+
+var fingerprintAnimals = [64]string{
+	"badger", "bat", "bear", "beaver", "bison", "camel", "cat", "cobra",
+	"crane", "crow", "deer", "dingo", "dove", "duck", "eagle", "eel",
+	"falcon", "ferret", "finch", "fox", "frog", "gecko", "goat", "goose",
+	"hare", "hawk", "heron", "horse", "ibis", "jackal", "koala", "lemur",
+	"lion", "llama", "lynx", "mole", "moose", "moth", "newt", "otter",
+	"owl", "panda", "parrot", "puma", "quail", "rabbit", "raven", "robin",
+	"salmon", "seal", "shark", "sloth", "snail", "swan", "tiger", "toad",
+	"trout", "turtle", "viper", "walrus", "whale", "wolf", "wombat", "yak",
+}
+
+// Fingerprint names the loaded vault key with a word pair such as
+// "brave-otter". It is a MAC of a fixed message under the vault key, so it
+// reveals nothing about the key, stays the same when the master password
+// changes and differs between vaults, telling the user which vault they
+// unlocked.
+func (v *vault) Fingerprint() (string, error) {
+	var fingerprint string
+
+	err := v.withVK(func(vk []byte) error {
+		mac := hmac.New(sha256.New, vk)
+		mac.Write([]byte(fingerprintInfo))
+		sum := mac.Sum(nil)
+
+		fingerprint = fingerprintAdjectives[sum[0]%64] + "-" + fingerprintAnimals[sum[1]%64]
+		return nil
+	})
+
+	return fingerprint, err
+}
@@ -0,0 +1,57 @@
+package vault
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFingerprint(t *testing.T) {
+	t.Run("should stay the same for the vault key under any master password", func(t *testing.T) {
+		v := newVaultWithKeys(t, "master-key")
+
+		key, err := v.VaultKey()
+		if err != nil {
+			t.Fatalf("failed to read vault key: %v", err)
+		}
+
+		other := NewVaultWithMK([]byte("another-master-key"))
+		if err := other.SetVaultKey(key); err != nil {
+			t.Fatalf("failed to set vault key: %v", err)
+		}
+
+		first, err := v.Fingerprint()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		second, err := other.Fingerprint()
+		if err != nil || first != second {
+			t.Fatalf("expected %q, got %q (%v)", first, second, err)
+		}
+
+		if words := strings.Split(first, "-"); len(words) != 2 || words[0] == "" || words[1] == "" {
+			t.Fatalf("expected a word pair, got %q", first)
+		}
+	})
+
+	t.Run("should be derived from the vault key", func(t *testing.T) {
+		key := make([]byte, VAULT_KEY_SIZE)
+		v := NewVaultWithMK([]byte("master-key"))
+		if err := v.SetVaultKey(key); err != nil {
+			t.Fatalf("failed to set vault key: %v", err)
+		}
+
+		fingerprint, err := v.Fingerprint()
+		if err != nil || fingerprint != "brisk-ferret" {
+			t.Fatalf("expected %q, got %q (%v)", "brisk-ferret", fingerprint, err)
+		}
+	})
+
+	t.Run("should return ErrNoVaultKey without a vault key", func(t *testing.T) {
+		_, err := NewVaultWithMK([]byte("master-key")).Fingerprint()
+		if !errors.Is(err, ErrNoVaultKey) {
+			t.Fatalf("expected ErrNoVaultKey, got %v", err)
+		}
+	})
+}
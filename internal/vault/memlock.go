@@ -0,0 +1,35 @@
+package vault
+
+import (
+	"os"
+	"sync"
+
+	"github.com/awnumar/memcall"
+)
+
+const (
+	MSK_ALLOW_UNLOCKED_ENV = "MSK_ALLOW_UNLOCKED"
+
+	// memguard locks at least one page per buffer, and a single unseal keeps a
+	// handful of buffers alive at once: the master key, the enclave key held in
+	// memguard's coffer and a derived key.
+	memlockProbePages = 8
+)
+
+// MemoryLockAvailable reports whether this process may lock memory, which
+// memguard needs for every buffer it creates. It fails in containers and CI
+// runners with a low RLIMIT_MEMLOCK, where memguard would otherwise panic. The
+// probe runs once per process.
+var MemoryLockAvailable = sync.OnceValue(func() bool {
+	probe, err := memcall.Alloc(memlockProbePages * os.Getpagesize())
+	if err != nil {
+		return false
+	}
+	defer memcall.Free(probe)
+
+	if err := memcall.Lock(probe); err != nil {
+		return false
+	}
+
+	return memcall.Unlock(probe) == nil
+})
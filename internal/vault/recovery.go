@@ -0,0 +1,159 @@
+package vault
+
+import (
+	"bytes"
+	"encoding/base32"
+	"errors"
+
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/gcm"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+)
+
+// RECOVERY_CODE_SIZE random bytes make a 160-bit recovery code, printed as 32
+// base32 characters.
+const RECOVERY_CODE_SIZE = 20
+
+var ErrWrongRecoveryCode = errors.New("wrong recovery code")
+
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateRecoveryCode returns a random recovery code in groups of four
+// base32 characters, e.g. "ABCD-EFGH-...". The caller must wipe it.
+func GenerateRecoveryCode() ([]byte, error) {
+	random, err := format.RandomBytes(RECOVERY_CODE_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Bytes(random)
+
+	encoded := make([]byte, recoveryEncoding.EncodedLen(len(random)))
+	defer wipe.Bytes(encoded)
+	recoveryEncoding.Encode(encoded, random)
+
+	code := make([]byte, 0, len(encoded)+len(encoded)/4)
+	for i := 0; i < len(encoded); i += 4 {
+		if i > 0 {
+			code = append(code, '-')
+		}
+		code = append(code, encoded[i:i+4]...)
+	}
+
+	return code, nil
+}
+
+// normalizeRecoveryCode drops separators and whitespace and uppercases the
+// code, so it can be typed loosely. Codes that cannot have come from
+// GenerateRecoveryCode are rejected before any key derivation. The caller
+// must wipe the result.
+func normalizeRecoveryCode(code []byte) ([]byte, error) {
+	normalized := make([]byte, 0, len(code))
+	for _, c := range code {
+		switch {
+		case c == '-' || c == ' ' || c == '\t':
+			continue
+		case c >= 'a' && c <= 'z':
+			c -= 'a' - 'A'
+		}
+		normalized = append(normalized, c)
+	}
+
+	decoded := make([]byte, recoveryEncoding.DecodedLen(len(normalized)))
+	defer wipe.Bytes(decoded)
+
+	n, err := recoveryEncoding.Decode(decoded, normalized)
+	if err != nil || n != RECOVERY_CODE_SIZE {
+		wipe.Bytes(normalized)
+		return nil, ErrWrongRecoveryCode
+	}
+
+	return normalized, nil
+}
+
+// WrapKey seals key under the key-encryption key kek with AES-GCM.
+func WrapKey(kek, key []byte) (*gcm.SealedCGM, error) {
+	return gcm.SealGCM(kek, key)
+}
+
+// UnwrapKey opens a key sealed by WrapKey. The caller must wipe the result.
+func UnwrapKey(kek, nonce, wrapped []byte) ([]byte, error) {
+	key, err := gcm.OpenGCM(nonce, kek, wrapped)
+	if err != nil {
+		return nil, ErrDecryption
+	}
+
+	return key, nil
+}
+
+// deriveRecoveryKey derives the key-encryption key for a recovery code.
+func deriveRecoveryKey(code, salt []byte) ([]byte, error) {
+	normalized, err := normalizeRecoveryCode(code)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Bytes(normalized)
+
+	return DeriveArgonKey(normalized, salt)
+}
+
+// SealRecovery wraps the loaded vault key, followed by data, under a key
+// derived from a recovery code, with a fresh salt, so LoadRecovery can later
+// load it without the master password. The master key itself is never
+// wrapped, so the code reveals nothing about the master password.
+func (v *vault) SealRecovery(code, data []byte) (*gcm.SaltedGCM, error) {
+	salt, err := format.RandomNonZeroBytes(meta.MSK_SALT_SIZE)
+	if err != nil {
+		return nil, err
+	}
+
+	kek, err := deriveRecoveryKey(code, salt)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Bytes(kek)
+
+	var sealed *gcm.SealedCGM
+
+	err = v.withVK(func(vk []byte) error {
+		payload := make([]byte, 0, len(vk)+len(data))
+		payload = append(append(payload, vk...), data...)
+		defer wipe.Bytes(payload)
+
+		sealed, err = WrapKey(kek, payload)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &gcm.SaltedGCM{Nonce: sealed.Nonce, Salt: salt, CipherData: sealed.CipherData}, nil
+}
+
+// LoadRecovery unwraps a vault key sealed by SealRecovery and loads it, as
+// unlocking the config would, and returns the data sealed along with it. The
+// caller must wipe the data.
+func (v *vault) LoadRecovery(code []byte, sealed *gcm.SaltedGCM) ([]byte, error) {
+	kek, err := deriveRecoveryKey(code, sealed.Salt)
+	if err != nil {
+		return nil, err
+	}
+	defer wipe.Bytes(kek)
+
+	payload, err := UnwrapKey(kek, sealed.Nonce, sealed.CipherData)
+	if err != nil {
+		return nil, ErrWrongRecoveryCode
+	}
+	defer wipe.Bytes(payload)
+
+	if len(payload) < VAULT_KEY_SIZE {
+		return nil, format.ErrCorruptedFile
+	}
+
+	if err := v.SetVaultKey(bytes.Clone(payload[:VAULT_KEY_SIZE])); err != nil {
+		return nil, err
+	}
+
+	return bytes.Clone(payload[VAULT_KEY_SIZE:]), nil
+}
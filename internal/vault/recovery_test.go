@@ -0,0 +1,133 @@
+package vault
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestGenerateRecoveryCode(t *testing.T) {
+	t.Run("should return eight dash-separated groups of base32", func(t *testing.T) {
+		code, err := GenerateRecoveryCode()
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if !regexp.MustCompile(`^([A-Z2-7]{4}-){7}[A-Z2-7]{4}$`).Match(code) {
+			t.Fatalf("unexpected code format %q", code)
+		}
+	})
+
+	t.Run("should return a different code each time", func(t *testing.T) {
+		first, _ := GenerateRecoveryCode()
+		second, _ := GenerateRecoveryCode()
+
+		if bytes.Equal(first, second) {
+			t.Fatal("expected two different codes")
+		}
+	})
+}
+
+func TestRecovery(t *testing.T) {
+	seal := func(t *testing.T) ([]byte, *vault) {
+		t.Helper()
+
+		code, err := GenerateRecoveryCode()
+		if err != nil {
+			t.Fatalf("failed to generate code: %v", err)
+		}
+
+		v := NewVaultWithMK([]byte("master-key")).(*vault)
+		key, _ := NewVaultKey()
+		if err := v.SetVaultKey(key); err != nil {
+			t.Fatalf("failed to set vault key: %v", err)
+		}
+
+		return code, v
+	}
+
+	t.Run("should load the vault key sealed under the code", func(t *testing.T) {
+		code, v := seal(t)
+
+		sealed, err := v.SealRecovery(code, []byte("vault path"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		encrypted, err := v.EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		// Codes are accepted in lowercase and without dashes.
+		typed := []byte(strings.ToLower(strings.ReplaceAll(string(code), "-", "")))
+
+		recovered := NewVault()
+		data, err := recovered.LoadRecovery(typed, sealed)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if string(data) != "vault path" {
+			t.Fatalf("expected the sealed data back, got %q", data)
+		}
+
+		plain, err := recovered.DecryptFile(encrypted)
+		if err != nil || string(plain) != "payload" {
+			t.Fatalf("expected the recovered key to decrypt, got %q (%v)", plain, err)
+		}
+	})
+
+	t.Run("should not seal the master key", func(t *testing.T) {
+		code, v := seal(t)
+
+		sealed, err := v.SealRecovery(code, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		recovered := NewVault()
+		if _, err := recovered.LoadRecovery(code, sealed); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if _, err := recovered.Encrypt([]byte("payload")); err == nil {
+			t.Fatal("expected the recovered vault to hold no master key")
+		}
+	})
+
+	t.Run("should fail without a vault key", func(t *testing.T) {
+		code, _ := seal(t)
+
+		_, err := NewVaultWithMK([]byte("master-key")).SealRecovery(code, nil)
+		if !errors.Is(err, ErrNoVaultKey) {
+			t.Fatalf("expected ErrNoVaultKey, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrWrongRecoveryCode for another code", func(t *testing.T) {
+		code, v := seal(t)
+
+		sealed, err := v.SealRecovery(code, nil)
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		other, _ := GenerateRecoveryCode()
+		_, err = NewVault().LoadRecovery(other, sealed)
+		if !errors.Is(err, ErrWrongRecoveryCode) {
+			t.Fatalf("expected ErrWrongRecoveryCode, got %v", err)
+		}
+	})
+
+	t.Run("should reject malformed codes before deriving a key", func(t *testing.T) {
+		_, v := seal(t)
+
+		_, err := v.SealRecovery([]byte("not-a-code"), nil)
+		if !errors.Is(err, ErrWrongRecoveryCode) {
+			t.Fatalf("expected ErrWrongRecoveryCode, got %v", err)
+		}
+	})
+}
@@ -1,9 +1,11 @@
 package vault
 
 import (
+	"encoding/hex"
 	"errors"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/amauribechtoldjr/msk/internal/format"
 	"github.com/amauribechtoldjr/msk/internal/meta"
@@ -132,3 +134,52 @@ func TestGetSecretKey(t *testing.T) {
 		}
 	})
 }
+
+// argonGoldenKey is DeriveArgonKey("correct horse battery staple",
+// "msk-golden-salt!") with the current Argon2id parameters. Every existing
+// vault depends on those parameters, so this must only change together with
+// a deliberate format migration. To regenerate it, derive the key with the
+// new parameters, hex-encode it and replace the value below.
+const argonGoldenKey = "fe0b414b56deb3eaad060f7b6729e1ba2f0d77af305759a0607c50d9b03b3335"
+
+// argonMinDuration is a floor well below what the current parameters take on
+// CI-class hardware, but far above what a weakened memory or time cost would.
+const argonMinDuration = 50 * time.Millisecond
+
+func TestDeriveArgonKeyGoldenVector(t *testing.T) {
+	t.Run("should derive the committed golden key", func(t *testing.T) {
+		key, err := DeriveArgonKey([]byte("correct horse battery staple"), []byte("msk-golden-salt!"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if got := hex.EncodeToString(key); got != argonGoldenKey {
+			t.Fatalf("Argon2 parameters changed: expected %s, got %s", argonGoldenKey, got)
+		}
+	})
+
+	t.Run("should stay expensive to derive", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("skipping timing check in short mode")
+		}
+
+		start := time.Now()
+		if _, err := DeriveArgonKey([]byte("correct horse battery staple"), []byte("msk-golden-salt!")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if elapsed := time.Since(start); elapsed < argonMinDuration {
+			t.Fatalf("key derivation took %v, expected at least %v; were the Argon2 costs lowered?", elapsed, argonMinDuration)
+		}
+	})
+}
+
+func BenchmarkDeriveArgonKey(b *testing.B) {
+	salt := []byte("msk-golden-salt!")
+
+	for b.Loop() {
+		if _, err := DeriveArgonKey([]byte("correct horse battery staple"), salt); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
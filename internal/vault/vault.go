@@ -1,10 +1,14 @@
 package vault
 
 import (
+	"bytes"
+	"crypto/subtle"
 	"errors"
+	"sync"
 
 	"github.com/amauribechtoldjr/msk/internal/format"
 	"github.com/amauribechtoldjr/msk/internal/gcm"
+	"github.com/amauribechtoldjr/msk/internal/logger"
 	"github.com/amauribechtoldjr/msk/internal/meta"
 	"github.com/amauribechtoldjr/msk/internal/prompt"
 	"github.com/amauribechtoldjr/msk/internal/session"
@@ -13,19 +17,50 @@ import (
 	"github.com/awnumar/memguard"
 )
 
-var ErrDecryption = errors.New("decryption failed")
+var (
+	ErrDecryption     = errors.New("decryption failed")
+	ErrMKConfirmation = errors.New("master password confirmation failed")
+	ErrMemoryLock     = errors.New("memory locking is unavailable")
+)
 
 type Vault interface {
 	Encrypt([]byte) (*gcm.SaltedGCM, error)
+	EncryptWithSalt(salt, fileBytes []byte) (*gcm.SaltedGCM, error)
 	Decrypt(salt, nonce, data []byte) ([]byte, error)
+	EncryptFile(plaintext []byte) ([]byte, error)
+	DecryptFile(file []byte) ([]byte, error)
+	SetVaultKey(key []byte) error
+	VaultKey() ([]byte, error)
+	Fingerprint() (string, error)
 	DestroyMK()
 	CreateSession(token []byte) (*gcm.SealedCGM, error)
 	LoadSession(bs *session.BinarySession) error
 	LoadMK() error
+	LoadNewMK() error
+	SealRecovery(code, data []byte) (*gcm.SaltedGCM, error)
+	LoadRecovery(code []byte, sealed *gcm.SaltedGCM) ([]byte, error)
+	EnableKeyCache()
+	DisableKeyCache()
+	ConfirmMK() error
+	AllowUnlockedMemory()
 }
 
 type vault struct {
 	mk *memguard.Enclave
+
+	// cacheMu guards keyCache, since bulk operations decrypt in parallel.
+	cacheMu  sync.Mutex
+	keyCache map[string]*memguard.Enclave
+
+	// allowUnlocked lets the master key live in ordinary memory when memory
+	// locking is unavailable; unlockedMK then holds it instead of mk.
+	allowUnlocked bool
+	unlockedMK    []byte
+
+	// vk is the vault key sealing envelope files; unlockedVK holds it instead
+	// when memory locking is unavailable.
+	vk         *memguard.Enclave
+	unlockedVK []byte
 }
 
 func NewVault() Vault {
@@ -34,21 +69,108 @@ func NewVault() Vault {
 
 func NewVaultWithMK(mk []byte) Vault {
 	v := &vault{}
-	v.configMK(mk)
+	// On failure the vault has no master key, which every operation reports.
+	_ = v.configMK(mk)
 	return v
 }
 
-func (v *vault) configMK(mk []byte) {
+// AllowUnlockedMemory opts into keeping the master key in ordinary, swappable
+// memory when memory locking is unavailable, instead of failing to unlock. The
+// key is still wiped by DestroyMK, but it may end up in swap or a core dump.
+func (v *vault) AllowUnlockedMemory() {
+	v.allowUnlocked = true
+}
+
+func (v *vault) configMK(mk []byte) error {
+	if !MemoryLockAvailable() {
+		if !v.allowUnlocked {
+			wipe.Bytes(mk)
+			return ErrMemoryLock
+		}
+
+		logger.PrintError("Warning: memory locking is unavailable, keeping the master key in unlocked memory\n")
+		v.unlockedMK = bytes.Clone(mk)
+		wipe.Bytes(mk)
+		return nil
+	}
+
 	buffer := memguard.NewBufferFromBytes(mk)
 	v.mk = buffer.Seal()
+	return nil
 }
 
 func (v *vault) DestroyMK() {
 	memguard.Purge()
+	wipe.Bytes(v.unlockedMK)
+	wipe.Bytes(v.unlockedVK)
 	v.mk = nil
+	v.unlockedMK = nil
+	v.vk = nil
+	v.unlockedVK = nil
+	v.keyCache = nil
+}
+
+// EnableKeyCache keeps the Argon2 keys this vault derives to decrypt sealed
+// in memory, keyed by salt, so re-reading the same file within one command
+// skips the expensive derivation. It is opt-in for bulk commands and for
+// unlocking, where the config, verifier and vault key share a salt. Each file
+// still has its own salt and key, and keys for the fresh salts of Encrypt are
+// never cached, since nothing reads them back. The cache is dropped by
+// DisableKeyCache and DestroyMK. Without memory locking nothing is cached.
+func (v *vault) EnableKeyCache() {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+
+	if v.keyCache == nil {
+		v.keyCache = make(map[string]*memguard.Enclave)
+	}
+}
+
+// DisableKeyCache drops every cached key and stops caching new ones.
+func (v *vault) DisableKeyCache() {
+	v.cacheMu.Lock()
+	defer v.cacheMu.Unlock()
+
+	v.keyCache = nil
+}
+
+// deriveKey returns the Argon2 key for salt, serving it from the key cache when
+// enabled. With store set a freshly derived key is added to the cache. The
+// caller owns the returned key and must wipe it.
+func (v *vault) deriveKey(mk, salt []byte, store bool) ([]byte, error) {
+	v.cacheMu.Lock()
+	cached, ok := v.keyCache[string(salt)]
+	v.cacheMu.Unlock()
+
+	if ok {
+		lockedBuffer, err := cached.Open()
+		if err != nil {
+			return nil, err
+		}
+		defer lockedBuffer.Destroy()
+
+		return bytes.Clone(lockedBuffer.Bytes()), nil
+	}
+
+	key, err := DeriveArgonKey(mk, salt)
+	if err != nil {
+		return nil, err
+	}
+
+	v.cacheMu.Lock()
+	if store && v.keyCache != nil && v.unlockedMK == nil {
+		v.keyCache[string(salt)] = memguard.NewBufferFromBytes(bytes.Clone(key)).Seal()
+	}
+	v.cacheMu.Unlock()
+
+	return key, nil
 }
 
 func (v *vault) withMk(fn func(mk []byte) error) error {
+	if v.unlockedMK != nil {
+		return fn(v.unlockedMK)
+	}
+
 	if v.mk == nil {
 		return errors.New("failed to load master key")
 	}
@@ -63,10 +185,14 @@ func (v *vault) withMk(fn func(mk []byte) error) error {
 }
 
 func (v *vault) Decrypt(salt, nonce, data []byte) ([]byte, error) {
+	if len(data) > 0 && len(data) < meta.MSK_GCM_TAG_SIZE {
+		return nil, format.ErrCorruptedFile
+	}
+
 	var fileBytes []byte
 
 	err := v.withMk(func(mk []byte) error {
-		key, err := DeriveArgonKey(mk, salt)
+		key, err := v.deriveKey(mk, salt, true)
 		if err != nil {
 			return err
 		}
@@ -88,15 +214,22 @@ func (v *vault) Decrypt(salt, nonce, data []byte) ([]byte, error) {
 }
 
 func (v *vault) Encrypt(fileBytes []byte) (*gcm.SaltedGCM, error) {
-	salt, err := format.RandomBytes(meta.MSK_SALT_SIZE)
+	salt, err := format.RandomNonZeroBytes(meta.MSK_SALT_SIZE)
 	if err != nil {
 		return nil, err
 	}
 
+	return v.EncryptWithSalt(salt, fileBytes)
+}
+
+// EncryptWithSalt seals fileBytes under the key derived for an existing salt,
+// with a fresh nonce. It lets a small record share the key of a file written
+// alongside it, so both can be opened with a single key derivation.
+func (v *vault) EncryptWithSalt(salt, fileBytes []byte) (*gcm.SaltedGCM, error) {
 	var sealedGCM *gcm.SealedCGM
 
-	err = v.withMk(func(mk []byte) error {
-		key, err := DeriveArgonKey(mk, salt)
+	err := v.withMk(func(mk []byte) error {
+		key, err := v.deriveKey(mk, salt, false)
 		if err != nil {
 			return err
 		}
@@ -155,9 +288,7 @@ func (v *vault) LoadSession(bs *session.BinarySession) error {
 		return ErrDecryption
 	}
 
-	v.configMK(mk)
-
-	return nil
+	return v.configMK(mk)
 }
 
 func (v *vault) LoadMK() error {
@@ -166,6 +297,33 @@ func (v *vault) LoadMK() error {
 		return err
 	}
 	defer wipe.Bytes(mk)
-	v.configMK(mk)
-	return nil
+	return v.configMK(mk)
+}
+
+// LoadNewMK prompts for a new master password twice and loads it.
+func (v *vault) LoadNewMK() error {
+	mk, err := prompt.ReadMasterPassword(true)
+	if err != nil {
+		return err
+	}
+	defer wipe.Bytes(mk)
+	return v.configMK(mk)
+}
+
+// ConfirmMK asks for the master password again and checks it against the one
+// already loaded, for commands that write plaintext secrets out. It also works
+// when the vault was unlocked from a session.
+func (v *vault) ConfirmMK() error {
+	candidate, err := prompt.ReadSafeValue("Enter master password again to confirm:")
+	if err != nil {
+		return err
+	}
+	defer wipe.Bytes(candidate)
+
+	return v.withMk(func(mk []byte) error {
+		if subtle.ConstantTimeCompare(mk, candidate) != 1 {
+			return ErrMKConfirmation
+		}
+		return nil
+	})
 }
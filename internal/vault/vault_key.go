@@ -0,0 +1,174 @@
+package vault
+
+import (
+	"bytes"
+	"crypto/hkdf"
+	"crypto/sha256"
+	"errors"
+
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/gcm"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+	"github.com/amauribechtoldjr/msk/internal/wipe"
+	"github.com/awnumar/memguard"
+)
+
+// VAULT_KEY_SIZE is the size of the random vault key that seals secrets in
+// the envelope format.
+const VAULT_KEY_SIZE = 32
+
+var ErrNoVaultKey = errors.New("vault key not loaded")
+
+// vaultKeyInfo binds keys derived from the vault key to their purpose.
+const vaultKeyInfo = "msk file key"
+
+// NewVaultKey returns a fresh random vault key. The caller must wipe it.
+func NewVaultKey() ([]byte, error) {
+	return format.RandomBytes(VAULT_KEY_SIZE)
+}
+
+// SetVaultKey loads the vault key used by EncryptFile and DecryptFile for
+// envelope files. Like the master key it is kept in locked memory and wiped
+// by DestroyMK. key is wiped.
+func (v *vault) SetVaultKey(key []byte) error {
+	if len(key) != VAULT_KEY_SIZE {
+		wipe.Bytes(key)
+		return errors.New("invalid vault key size")
+	}
+
+	if !MemoryLockAvailable() {
+		if !v.allowUnlocked {
+			wipe.Bytes(key)
+			return ErrMemoryLock
+		}
+
+		v.unlockedVK = bytes.Clone(key)
+		wipe.Bytes(key)
+		return nil
+	}
+
+	v.vk = memguard.NewBufferFromBytes(key).Seal()
+	return nil
+}
+
+// VaultKey returns a copy of the loaded vault key, e.g. to wrap it under a
+// new master password. The caller must wipe it.
+func (v *vault) VaultKey() ([]byte, error) {
+	var key []byte
+
+	err := v.withVK(func(vk []byte) error {
+		key = bytes.Clone(vk)
+		return nil
+	})
+
+	return key, err
+}
+
+func (v *vault) withVK(fn func(vk []byte) error) error {
+	if v.unlockedVK != nil {
+		return fn(v.unlockedVK)
+	}
+
+	if v.vk == nil {
+		return ErrNoVaultKey
+	}
+
+	lockedBuffer, err := v.vk.Open()
+	if err != nil {
+		return err
+	}
+	defer lockedBuffer.Destroy()
+
+	return fn(lockedBuffer.Bytes())
+}
+
+// hasVaultKey reports whether a vault key is loaded.
+func (v *vault) hasVaultKey() bool {
+	return v.vk != nil || v.unlockedVK != nil
+}
+
+// EncryptFile seals plaintext into a complete file. With a vault key loaded
+// it writes the envelope format, whose per-file key is derived from the vault
+// key with HKDF; otherwise it falls back to the master-password format.
+// plaintext is wiped.
+func (v *vault) EncryptFile(plaintext []byte) ([]byte, error) {
+	if !v.hasVaultKey() {
+		sealed, err := v.Encrypt(plaintext)
+		if err != nil {
+			return nil, err
+		}
+
+		return format.MarshalFile(sealed.Salt, sealed.Nonce, sealed.CipherData)
+	}
+	defer wipe.Bytes(plaintext)
+
+	salt, err := format.RandomNonZeroBytes(meta.MSK_SALT_SIZE)
+	if err != nil {
+		return nil, err
+	}
+
+	var sealed *gcm.SealedCGM
+
+	err = v.withVK(func(vk []byte) error {
+		key, err := deriveFileKey(vk, salt)
+		if err != nil {
+			return err
+		}
+		defer wipe.Bytes(key)
+
+		sealed, err = gcm.SealGCM(key, plaintext)
+		return err
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return format.MarshalFileVersion(meta.MSK_FILE_VERSION_ENVELOPE, salt, sealed.Nonce, sealed.CipherData)
+}
+
+// DecryptFile opens a file written by EncryptFile in either format.
+func (v *vault) DecryptFile(file []byte) ([]byte, error) {
+	version, err := format.FileVersion(file)
+	if err != nil {
+		return nil, err
+	}
+
+	salt, nonce, data, err := format.UnmarshalFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	if version == meta.MSK_FILE_VERSION {
+		return v.Decrypt(salt, nonce, data)
+	}
+
+	var plaintext []byte
+
+	err = v.withVK(func(vk []byte) error {
+		key, err := deriveFileKey(vk, salt)
+		if err != nil {
+			return err
+		}
+		defer wipe.Bytes(key)
+
+		plaintext, err = gcm.OpenGCM(nonce, key, data)
+		if err != nil {
+			return ErrDecryption
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return plaintext, nil
+}
+
+// deriveFileKey derives the key of one envelope file from the vault key and
+// the file's salt. The vault key is random, so a fast KDF is enough.
+func deriveFileKey(vk, salt []byte) ([]byte, error) {
+	return hkdf.Key(sha256.New, vk, salt, vaultKeyInfo, 32)
+}
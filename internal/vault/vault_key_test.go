@@ -0,0 +1,138 @@
+package vault
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/amauribechtoldjr/msk/internal/format"
+	"github.com/amauribechtoldjr/msk/internal/meta"
+)
+
+func newVaultWithKeys(t *testing.T, mk string) Vault {
+	t.Helper()
+
+	v := NewVaultWithMK([]byte(mk))
+
+	key, err := NewVaultKey()
+	if err != nil {
+		t.Fatalf("failed to create vault key: %v", err)
+	}
+
+	if err := v.SetVaultKey(key); err != nil {
+		t.Fatalf("failed to set vault key: %v", err)
+	}
+
+	return v
+}
+
+func TestEncryptFile(t *testing.T) {
+	t.Run("should write the envelope format with a vault key", func(t *testing.T) {
+		v := newVaultWithKeys(t, "master-key")
+
+		file, err := v.EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if version, _ := format.FileVersion(file); version != meta.MSK_FILE_VERSION_ENVELOPE {
+			t.Fatalf("expected version %d, got %d", meta.MSK_FILE_VERSION_ENVELOPE, version)
+		}
+
+		plaintext, err := v.DecryptFile(file)
+		if err != nil || string(plaintext) != "payload" {
+			t.Fatalf("expected payload, got %q (%v)", plaintext, err)
+		}
+	})
+
+	t.Run("should fall back to the master password format without a vault key", func(t *testing.T) {
+		v := NewVaultWithMK([]byte("master-key"))
+
+		file, err := v.EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		if version, _ := format.FileVersion(file); version != meta.MSK_FILE_VERSION {
+			t.Fatalf("expected version %d, got %d", meta.MSK_FILE_VERSION, version)
+		}
+	})
+}
+
+func TestDecryptFile(t *testing.T) {
+	t.Run("should keep reading master password files once a vault key is loaded", func(t *testing.T) {
+		legacy, err := NewVaultWithMK([]byte("master-key")).EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		plaintext, err := newVaultWithKeys(t, "master-key").DecryptFile(legacy)
+		if err != nil || string(plaintext) != "payload" {
+			t.Fatalf("expected payload, got %q (%v)", plaintext, err)
+		}
+	})
+
+	t.Run("should open envelope files under any master password sharing the vault key", func(t *testing.T) {
+		v := newVaultWithKeys(t, "master-key")
+
+		file, err := v.EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		key, err := v.VaultKey()
+		if err != nil {
+			t.Fatalf("failed to read vault key: %v", err)
+		}
+
+		other := NewVaultWithMK([]byte("another-master-key"))
+		if err := other.SetVaultKey(key); err != nil {
+			t.Fatalf("failed to set vault key: %v", err)
+		}
+
+		plaintext, err := other.DecryptFile(file)
+		if err != nil || string(plaintext) != "payload" {
+			t.Fatalf("expected payload, got %q (%v)", plaintext, err)
+		}
+	})
+
+	t.Run("should return ErrNoVaultKey for envelope files without a vault key", func(t *testing.T) {
+		file, err := newVaultWithKeys(t, "master-key").EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		_, err = NewVaultWithMK([]byte("master-key")).DecryptFile(file)
+		if !errors.Is(err, ErrNoVaultKey) {
+			t.Fatalf("expected ErrNoVaultKey, got %v", err)
+		}
+	})
+
+	t.Run("should return ErrDecryption for another vault key", func(t *testing.T) {
+		file, err := newVaultWithKeys(t, "master-key").EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		_, err = newVaultWithKeys(t, "master-key").DecryptFile(file)
+		if !errors.Is(err, ErrDecryption) {
+			t.Fatalf("expected ErrDecryption, got %v", err)
+		}
+	})
+	t.Run("should return the format errors for damaged files", func(t *testing.T) {
+		v := newVaultWithKeys(t, "master-key")
+
+		file, err := v.EncryptFile([]byte("payload"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		if _, err := v.DecryptFile(file[:3]); !errors.Is(err, format.ErrCorruptedFile) {
+			t.Fatalf("expected ErrCorruptedFile, got %v", err)
+		}
+
+		file[meta.MSK_MAGIC_SIZE] = 0xff
+		if _, err := v.DecryptFile(file); !errors.Is(err, format.ErrUnsupportedFileVersion) {
+			t.Fatalf("expected ErrUnsupportedFileVersion, got %v", err)
+		}
+	})
+}
@@ -148,3 +148,151 @@ func TestDecrypt(t *testing.T) {
 		}
 	})
 }
+
+func TestKeyCache(t *testing.T) {
+	t.Run("should reuse the derived key for the same salt", func(t *testing.T) {
+		crypt := newConfiguredCrypt("master-password")
+		crypt.EnableKeyCache()
+
+		result, err := crypt.Encrypt([]byte("s3cur3p@ss"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		cache := crypt.(*vault).keyCache
+		if len(cache) != 0 {
+			t.Fatalf("expected the fresh salt of Encrypt not to be cached, got %d entries", len(cache))
+		}
+
+		for range 2 {
+			plaintext, err := crypt.Decrypt(result.Salt, result.Nonce, result.CipherData)
+			if err != nil {
+				t.Fatalf("decrypt failed: %v", err)
+			}
+
+			if !reflect.DeepEqual(plaintext, []byte("s3cur3p@ss")) {
+				t.Fatalf("expected %q, got %q", "s3cur3p@ss", plaintext)
+			}
+		}
+
+		if len(cache) != 1 {
+			t.Fatalf("expected cached key to be reused, got %d entries", len(cache))
+		}
+	})
+
+	t.Run("should not cache keys unless enabled", func(t *testing.T) {
+		crypt := newConfiguredCrypt("master-password")
+
+		if _, err := crypt.Encrypt([]byte("data")); err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		if crypt.(*vault).keyCache != nil {
+			t.Fatal("expected no key cache by default")
+		}
+	})
+
+	t.Run("should drop cached keys on DestroyMK", func(t *testing.T) {
+		crypt := newConfiguredCrypt("master-password")
+		crypt.EnableKeyCache()
+
+		result, err := crypt.Encrypt([]byte("data"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		if _, err := crypt.Decrypt(result.Salt, result.Nonce, result.CipherData); err != nil {
+			t.Fatalf("decrypt failed: %v", err)
+		}
+
+		crypt.DestroyMK()
+
+		if crypt.(*vault).keyCache != nil {
+			t.Fatal("expected key cache to be cleared")
+		}
+	})
+
+	t.Run("should drop cached keys and stop caching on DisableKeyCache", func(t *testing.T) {
+		crypt := newConfiguredCrypt("master-password")
+		crypt.EnableKeyCache()
+
+		result, err := crypt.Encrypt([]byte("data"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		if _, err := crypt.Decrypt(result.Salt, result.Nonce, result.CipherData); err != nil {
+			t.Fatalf("decrypt failed: %v", err)
+		}
+
+		crypt.DisableKeyCache()
+
+		if _, err := crypt.Decrypt(result.Salt, result.Nonce, result.CipherData); err != nil {
+			t.Fatalf("decrypt failed: %v", err)
+		}
+
+		if crypt.(*vault).keyCache != nil {
+			t.Fatal("expected key cache to stay off")
+		}
+	})
+}
+
+func TestUnlockedMemory(t *testing.T) {
+	withoutMemoryLock := func(t *testing.T) {
+		available := MemoryLockAvailable
+		MemoryLockAvailable = func() bool { return false }
+		t.Cleanup(func() { MemoryLockAvailable = available })
+	}
+
+	t.Run("should refuse the master key when memory locking is unavailable", func(t *testing.T) {
+		withoutMemoryLock(t)
+
+		v := &vault{}
+		mk := []byte("master-password")
+
+		if err := v.configMK(mk); !errors.Is(err, ErrMemoryLock) {
+			t.Fatalf("expected ErrMemoryLock, got %v", err)
+		}
+
+		if string(mk) == "master-password" {
+			t.Fatal("expected the refused master key to be wiped")
+		}
+	})
+
+	t.Run("should round-trip in unlocked memory when allowed", func(t *testing.T) {
+		withoutMemoryLock(t)
+
+		v := &vault{}
+		v.AllowUnlockedMemory()
+		v.EnableKeyCache()
+
+		if err := v.configMK([]byte("master-password")); err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+
+		result, err := v.Encrypt([]byte("s3cur3p@ss"))
+		if err != nil {
+			t.Fatalf("encrypt failed: %v", err)
+		}
+
+		plaintext, err := v.Decrypt(result.Salt, result.Nonce, result.CipherData)
+		if err != nil {
+			t.Fatalf("decrypt failed: %v", err)
+		}
+
+		if string(plaintext) != "s3cur3p@ss" {
+			t.Fatalf("expected s3cur3p@ss, got %q", plaintext)
+		}
+
+		if len(v.keyCache) != 0 {
+			t.Fatalf("expected no cached keys in unlocked memory, got %d", len(v.keyCache))
+		}
+
+		mk := v.unlockedMK
+		v.DestroyMK()
+
+		if v.unlockedMK != nil || string(mk) == "master-password" {
+			t.Fatal("expected DestroyMK to wipe the unlocked master key")
+		}
+	})
+}